@@ -43,4 +43,39 @@ type Accumulator interface {
 	SetPrecision(precision, interval time.Duration)
 
 	AddError(err error)
+
+	// WithTracking upgrades to a TrackingAccumulator with space for maxTracked
+	// metrics/batches.
+	WithTracking(maxTracked int) TrackingAccumulator
+}
+
+// TrackingID uniquely identifies a tracked metric group
+type TrackingID uint64
+
+// DeliveryInfo provides the results of a delivered metric group.
+type DeliveryInfo interface {
+	// ID is the TrackingID
+	ID() TrackingID
+
+	// Delivered returns true if the metric was processed successfully.
+	Delivered() bool
+}
+
+// TrackingAccumulator is an Accumulator that provides a signal when the
+// metric has been fully processed.  Sending more metrics than the accumulator
+// has been allocated for without reading status from the Accepted or Rejected
+// channels is an error.
+type TrackingAccumulator interface {
+	Accumulator
+
+	// AddTrackingMetric adds a metric and returns its TrackingID.
+	AddTrackingMetric(m Metric) TrackingID
+
+	// AddTrackingMetricGroup adds a group of metrics which are tracked as a
+	// single unit; the group is delivered once every metric in it has been
+	// written or dropped.
+	AddTrackingMetricGroup(group []Metric) TrackingID
+
+	// Delivered returns a channel that will contain the tracking results.
+	Delivered() <-chan DeliveryInfo
 }
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -32,14 +34,47 @@ func NewAccumulator(
 		metrics:   metrics,
 		precision: time.Nanosecond,
 	}
+	if p, ok := maker.(pipelineMaker); ok {
+		acc.pipeline = p.Pipeline()
+	}
 	return &acc
 }
 
+// pipelineMaker is a MetricMaker whose metrics are sent to a pipeline.
+type pipelineMaker interface {
+	Pipeline() string
+}
+
+// errorRecorder is a MetricMaker that keeps the last error of its plugin.
+type errorRecorder interface {
+	SetLastError(err error)
+}
+
+// pipelineMetric carries the pipeline of a metric through the metric
+// channels to the flusher.
+type pipelineMetric struct {
+	telegraf.Metric
+	pipeline string
+}
+
+// unwrapPipeline returns the metric sent by an accumulator and the pipeline
+// it belongs to.
+func unwrapPipeline(m telegraf.Metric) (telegraf.Metric, string) {
+	if pm, ok := m.(*pipelineMetric); ok {
+		return pm.Metric, pm.pipeline
+	}
+	return m, models.DefaultPipeline
+}
+
 type accumulator struct {
 	metrics chan telegraf.Metric
 
 	maker MetricMaker
 
+	// pipeline the metrics are sent to, metrics of the default pipeline are
+	// sent as is.
+	pipeline string
+
 	precision time.Duration
 }
 
@@ -50,7 +85,7 @@ func (ac *accumulator) AddFields(
 	t ...time.Time,
 ) {
 	if m := ac.maker.MakeMetric(measurement, fields, tags, telegraf.Untyped, ac.getTime(t)); m != nil {
-		ac.metrics <- m
+		ac.addMetric(m)
 	}
 }
 
@@ -61,7 +96,7 @@ func (ac *accumulator) AddGauge(
 	t ...time.Time,
 ) {
 	if m := ac.maker.MakeMetric(measurement, fields, tags, telegraf.Gauge, ac.getTime(t)); m != nil {
-		ac.metrics <- m
+		ac.addMetric(m)
 	}
 }
 
@@ -72,7 +107,7 @@ func (ac *accumulator) AddCounter(
 	t ...time.Time,
 ) {
 	if m := ac.maker.MakeMetric(measurement, fields, tags, telegraf.Counter, ac.getTime(t)); m != nil {
-		ac.metrics <- m
+		ac.addMetric(m)
 	}
 }
 
@@ -83,7 +118,7 @@ func (ac *accumulator) AddSummary(
 	t ...time.Time,
 ) {
 	if m := ac.maker.MakeMetric(measurement, fields, tags, telegraf.Summary, ac.getTime(t)); m != nil {
-		ac.metrics <- m
+		ac.addMetric(m)
 	}
 }
 
@@ -94,8 +129,15 @@ func (ac *accumulator) AddHistogram(
 	t ...time.Time,
 ) {
 	if m := ac.maker.MakeMetric(measurement, fields, tags, telegraf.Histogram, ac.getTime(t)); m != nil {
-		ac.metrics <- m
+		ac.addMetric(m)
+	}
+}
+
+func (ac *accumulator) addMetric(m telegraf.Metric) {
+	if ac.pipeline != "" && ac.pipeline != models.DefaultPipeline {
+		m = &pipelineMetric{Metric: m, pipeline: ac.pipeline}
 	}
+	ac.metrics <- m
 }
 
 // AddError passes a runtime error to the accumulator.
@@ -105,6 +147,9 @@ func (ac *accumulator) AddError(err error) {
 		return
 	}
 	NErrors.Incr(1)
+	if r, ok := ac.maker.(errorRecorder); ok {
+		r.SetLastError(err)
+	}
 	//TODO suppress/throttle consecutive duplicate errors?
 	log.Printf("E! Error in plugin [%s]: %s", ac.maker.Name(), err)
 }
@@ -130,6 +175,14 @@ func (ac *accumulator) SetPrecision(precision, interval time.Duration) {
 	}
 }
 
+func (ac *accumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
+	return &trackingAccumulator{
+		Accumulator: ac,
+		acc:         ac,
+		delivered:   make(chan telegraf.DeliveryInfo, maxTracked),
+	}
+}
+
 func (ac accumulator) getTime(t []time.Time) time.Time {
 	var timestamp time.Time
 	if len(t) > 0 {
@@ -139,3 +192,48 @@ func (ac accumulator) getTime(t []time.Time) time.Time {
 	}
 	return timestamp.Round(ac.precision)
 }
+
+type trackingAccumulator struct {
+	telegraf.Accumulator
+	acc       *accumulator
+	delivered chan telegraf.DeliveryInfo
+}
+
+func (a *trackingAccumulator) AddTrackingMetric(m telegraf.Metric) telegraf.TrackingID {
+	return a.AddTrackingMetricGroup([]telegraf.Metric{m})
+}
+
+func (a *trackingAccumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
+	ac := a.acc
+	metrics := make([]telegraf.Metric, 0, len(group))
+	for _, m := range group {
+		dm := ac.maker.MakeMetric(m.Name(), m.Fields(), m.Tags(), m.Type(),
+			ac.getTime([]time.Time{m.Time()}))
+		if dm != nil {
+			metrics = append(metrics, dm)
+		}
+	}
+
+	tracked, id := metric.WithGroupTracking(metrics, a.onDelivery)
+	for _, m := range tracked {
+		ac.addMetric(m)
+	}
+	return id
+}
+
+func (a *trackingAccumulator) Delivered() <-chan telegraf.DeliveryInfo {
+	return a.delivered
+}
+
+// onDelivery is called from the outputs when a metric group has been fully
+// processed. The channel has room for every tracked group, but don't block
+// the output if the plugin is tracking more than it allocated for.
+func (a *trackingAccumulator) onDelivery(info telegraf.DeliveryInfo) {
+	select {
+	case a.delivered <- info:
+	default:
+		go func() {
+			a.delivered <- info
+		}()
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/models"
 	"github.com/influxdata/telegraf/metric"
 
 	"github.com/stretchr/testify/assert"
@@ -309,6 +310,92 @@ func TestAddCounter(t *testing.T) {
 	assert.Equal(t, testm.Type(), telegraf.Counter)
 }
 
+func TestAddTrackingMetricGroup(t *testing.T) {
+	now := time.Now()
+	metrics := make(chan telegraf.Metric, 10)
+	defer close(metrics)
+	a := NewAccumulator(&TestMetricMaker{}, metrics).WithTracking(10)
+
+	m1, err := metric.New("acctest",
+		map[string]string{},
+		map[string]interface{}{"value": float64(101)},
+		now)
+	require.NoError(t, err)
+	m2, err := metric.New("acctest",
+		map[string]string{"acc": "test"},
+		map[string]interface{}{"value": float64(102)},
+		now)
+	require.NoError(t, err)
+
+	id := a.AddTrackingMetricGroup([]telegraf.Metric{m1, m2})
+
+	first := <-metrics
+	second := <-metrics
+	assert.Equal(t,
+		fmt.Sprintf("acctest value=101 %d\n", now.UnixNano()),
+		first.String())
+
+	// A copy must be processed as well before the group is delivered.
+	copied := second.Copy()
+	first.Accept()
+	second.Accept()
+	select {
+	case <-a.Delivered():
+		t.Fatal("group delivered before all metrics were processed")
+	default:
+	}
+
+	copied.Drop()
+	track := <-a.Delivered()
+	assert.Equal(t, id, track.ID())
+	assert.True(t, track.Delivered())
+}
+
+func TestAddTrackingMetricRejected(t *testing.T) {
+	metrics := make(chan telegraf.Metric, 10)
+	defer close(metrics)
+	a := NewAccumulator(&TestMetricMaker{}, metrics).WithTracking(10)
+
+	m, err := metric.New("acctest",
+		map[string]string{},
+		map[string]interface{}{"value": float64(101)},
+		time.Now())
+	require.NoError(t, err)
+
+	id := a.AddTrackingMetric(m)
+	(<-metrics).Reject()
+
+	track := <-a.Delivered()
+	assert.Equal(t, id, track.ID())
+	assert.False(t, track.Delivered())
+}
+
+func TestAccumulatorPipeline(t *testing.T) {
+	metrics := make(chan telegraf.Metric, 10)
+	defer close(metrics)
+
+	a := NewAccumulator(&TestMetricMaker{}, metrics)
+	a.AddFields("acctest", map[string]interface{}{"value": 1}, nil)
+	m, pipeline := unwrapPipeline(<-metrics)
+	assert.Equal(t, models.DefaultPipeline, pipeline)
+	assert.Equal(t, "acctest", m.Name())
+
+	a = NewAccumulator(&pipelineMetricMaker{pipeline: "security"}, metrics)
+	a.AddFields("acctest", map[string]interface{}{"value": 1}, nil)
+	m, pipeline = unwrapPipeline(<-metrics)
+	assert.Equal(t, "security", pipeline)
+	assert.Equal(t, "acctest", m.Name())
+}
+
+type pipelineMetricMaker struct {
+	TestMetricMaker
+	pipeline string
+}
+
+func (tm *pipelineMetricMaker) Pipeline() string {
+	return tm.pipeline
+}
+
 type TestMetricMaker struct {
 }
 
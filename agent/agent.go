@@ -1,10 +1,14 @@
 package agent
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"reflect"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 
@@ -15,9 +19,56 @@ import (
 	"github.com/influxdata/telegraf/selfstat"
 )
 
+// ErrRestartRequired is returned by Reload when the new configuration
+// changes settings that can only be applied by restarting the agent.
+var ErrRestartRequired = errors.New("agent settings changed, restart required")
+
+// processorFlushInterval is how often the processors holding on to metrics
+// are asked for the metrics that are due.
+const processorFlushInterval = time.Second
+
 // Agent runs telegraf and collects data based on the given config
 type Agent struct {
 	Config *config.Config
+
+	// mu guards the plugin slices of Config while they are swapped by Reload.
+	mu sync.RWMutex
+
+	// reloadMu serializes Reload with starting and stopping the agent.
+	reloadMu    sync.Mutex
+	running     bool
+	metricC     chan telegraf.Metric
+	aggC        chan telegraf.Metric
+	inputs      map[*models.RunningInput]*runningPlugin
+	aggregators map[*models.RunningAggregator]*runningPlugin
+	outputs     map[*models.RunningOutput]*runningPlugin
+	wg          sync.WaitGroup
+
+	// fingerprints of the running plugins once the config has been reloaded
+	fingerprints map[interface{}]string
+
+	// api serves the health and state of the agent over HTTP.
+	api *http.Server
+}
+
+// runningPlugin tracks the goroutine of an input, aggregator or output, so
+// that it can be stopped on its own when the configuration is reloaded.
+type runningPlugin struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newRunningPlugin() *runningPlugin {
+	return &runningPlugin{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Stop signals the plugin goroutine to exit and waits for it to return.
+func (p *runningPlugin) Stop() {
+	close(p.stop)
+	<-p.done
 }
 
 // NewAgent returns an Agent struct based off the given Config
@@ -26,47 +77,97 @@ func NewAgent(config *config.Config) (*Agent, error) {
 		Config: config,
 	}
 
-	if !a.Config.Agent.OmitHostname {
-		if a.Config.Agent.Hostname == "" {
-			hostname, err := os.Hostname()
-			if err != nil {
-				return nil, err
+	if err := setHostname(config); err != nil {
+		return nil, err
+	}
+
+	if err := models.LinkDeadLetters(config.Outputs); err != nil {
+		return nil, err
+	}
+	checkPipelines(config)
+
+	return a, nil
+}
+
+// checkPipelines warns about inputs sending their metrics to a pipeline
+// without any outputs.
+func checkPipelines(c *config.Config) {
+	if len(c.Outputs) == 0 {
+		// running with --test
+		return
+	}
+	for _, input := range c.Inputs {
+		var found bool
+		for _, o := range c.Outputs {
+			if !o.DeadLetterSink && o.InPipeline(input.Pipeline()) {
+				found = true
+				break
 			}
+		}
+		if !found {
+			log.Printf("W! Input [%s] sends metrics to pipeline %q, which has "+
+				"no outputs\n", input.Name(), input.Pipeline())
+		}
+	}
+}
 
-			a.Config.Agent.Hostname = hostname
+// setHostname sets the host tag of the config, unless omitted.
+func setHostname(c *config.Config) error {
+	if c.Agent.OmitHostname {
+		return nil
+	}
+
+	if c.Agent.Hostname == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
 		}
 
-		config.Tags["host"] = a.Config.Agent.Hostname
+		c.Agent.Hostname = hostname
 	}
 
-	return a, nil
+	c.Tags["host"] = c.Agent.Hostname
+	return nil
 }
 
 // Connect connects to all configured outputs
 func (a *Agent) Connect() error {
 	for _, o := range a.Config.Outputs {
-		switch ot := o.Output.(type) {
-		case telegraf.ServiceOutput:
-			if err := ot.Start(); err != nil {
-				log.Printf("E! Service for output %s failed to start, exiting\n%s\n",
-					o.Name, err.Error())
-				return err
-			}
+		if err := o.OpenDiskBuffer(); err != nil {
+			log.Printf("E! %s\n", err)
+			return err
+		}
+		if err := connectOutput(o); err != nil {
+			return err
 		}
+	}
+	return nil
+}
+
+// connectOutput starts the output if it is a service output and connects
+// it.
+func connectOutput(o *models.RunningOutput) error {
+	switch ot := o.Output.(type) {
+	case telegraf.ServiceOutput:
+		if err := ot.Start(); err != nil {
+			log.Printf("E! Service for output %s failed to start, exiting\n%s\n",
+				o.Name, err.Error())
+			return err
+		}
+	}
 
-		log.Printf("D! Attempting connection to output: %s\n", o.Name)
-		err := o.Output.Connect()
+	log.Printf("D! Attempting connection to output: %s\n", o.Name)
+	err := o.Output.Connect()
+	if err != nil {
+		log.Printf("E! Failed to connect to output %s, retrying in 15s, "+
+			"error was '%s' \n", o.Name, err)
+		time.Sleep(15 * time.Second)
+		err = o.Output.Connect()
 		if err != nil {
-			log.Printf("E! Failed to connect to output %s, retrying in 15s, "+
-				"error was '%s' \n", o.Name, err)
-			time.Sleep(15 * time.Second)
-			err = o.Output.Connect()
-			if err != nil {
-				return err
-			}
+			return err
 		}
-		log.Printf("D! Successfully connected to output: %s\n", o.Name)
 	}
+	log.Printf("D! Successfully connected to output: %s\n", o.Name)
 	return nil
 }
 
@@ -74,11 +175,16 @@ func (a *Agent) Connect() error {
 func (a *Agent) Close() error {
 	var err error
 	for _, o := range a.Config.Outputs {
-		err = o.Output.Close()
-		switch ot := o.Output.(type) {
-		case telegraf.ServiceOutput:
-			ot.Stop()
-		}
+		err = closeOutput(o)
+	}
+	return err
+}
+
+func closeOutput(o *models.RunningOutput) error {
+	err := o.Close()
+	switch ot := o.Output.(type) {
+	case telegraf.ServiceOutput:
+		ot.Stop()
 	}
 	return err
 }
@@ -125,6 +231,7 @@ func (a *Agent) gatherer(
 		elapsed := time.Since(start)
 
 		GatherTime.Incr(elapsed.Nanoseconds())
+		input.SetGatherResult(start, elapsed, elapsed > interval)
 
 		select {
 		case <-shutdown:
@@ -163,6 +270,7 @@ func gatherWithTimeout(
 		case <-ticker.C:
 			err := fmt.Errorf("took longer to collect than collection interval (%s)",
 				timeout)
+			input.SetOverrun()
 			acc.AddError(err)
 			continue
 		case <-shutdown:
@@ -227,34 +335,119 @@ func (a *Agent) Test() error {
 	return nil
 }
 
-// flush writes a list of metrics to all configured outputs
-func (a *Agent) flush() {
-	var wg sync.WaitGroup
+// process runs the metrics through the processors of the pipeline
+func (a *Agent) process(metrics []telegraf.Metric, pipeline string) []telegraf.Metric {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, processor := range a.Config.Processors {
+		if processor.InPipeline(pipeline) {
+			metrics = processor.ApplyPipeline(pipeline, metrics...)
+		}
+	}
+	return metrics
+}
 
-	wg.Add(len(a.Config.Outputs))
-	for _, o := range a.Config.Outputs {
-		go func(output *models.RunningOutput) {
-			defer wg.Done()
-			err := output.Write()
-			if err != nil {
-				log.Printf("E! Error writing to output [%s]: %s\n",
-					output.Name, err.Error())
+// flushProcessors passes the metrics that are due from the processors holding
+// on to metrics on to the aggregators and outputs.
+func (a *Agent) flushProcessors(outMetricC chan *pipelineMetric) {
+	a.mu.RLock()
+	flushed := processorMetrics(a.Config.Processors, func(*models.RunningProcessor) bool {
+		return false
+	})
+	a.mu.RUnlock()
+
+	// outMetricC is drained by a goroutine taking a.mu, so the lock is not
+	// held while sending.
+	for _, pm := range flushed {
+		outMetricC <- pm
+	}
+}
+
+// processorMetrics flushes the processors holding on to metrics, and returns
+// the metrics that are due, passed through the processors that follow them in
+// their pipeline. All the metrics held by the processors for which final
+// returns true are due.
+func processorMetrics(
+	processors models.RunningProcessors,
+	final func(*models.RunningProcessor) bool,
+) []*pipelineMetric {
+	var out []*pipelineMetric
+	for i, processor := range processors {
+		for pipeline, metrics := range processor.Flush(final(processor)) {
+			for _, next := range processors[i+1:] {
+				if next.InPipeline(pipeline) {
+					metrics = next.ApplyPipeline(pipeline, metrics...)
+				}
+			}
+			for _, m := range metrics {
+				out = append(out, &pipelineMetric{Metric: m, pipeline: pipeline})
 			}
-		}(o)
+		}
 	}
+	return out
+}
 
-	wg.Wait()
+// aggregate adds the metric to the aggregators and outputs of the pipeline.
+// If any aggregator has drop_original set the metric is only sent to the
+// aggregators.
+func (a *Agent) aggregate(m telegraf.Metric, pipeline string) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var dropOriginal bool
+	if !m.IsAggregate() {
+		for _, agg := range a.Config.Aggregators {
+			if !agg.InPipeline(pipeline) {
+				continue
+			}
+			if ok := agg.Add(m.Copy()); ok {
+				dropOriginal = true
+			}
+		}
+	}
+	if dropOriginal {
+		m.Drop()
+		return
+	}
+	a.output(m, pipeline)
 }
 
-// flusher monitors the metrics input channel and flushes on the minimum interval
-func (a *Agent) flusher(shutdown chan struct{}, metricC chan telegraf.Metric, aggC chan telegraf.Metric) error {
-	// Inelegant, but this sleep is to allow the Gather threads to run, so that
-	// the flusher will flush after metrics are collected.
-	time.Sleep(time.Millisecond * 300)
+// output adds the metric to the outputs of the pipeline, other than dead
+// letter outputs. The caller must hold a.mu.
+func (a *Agent) output(m telegraf.Metric, pipeline string) {
+	receives := func(o *models.RunningOutput) bool {
+		return !o.DeadLetterSink && o.InPipeline(pipeline)
+	}
 
+	last := -1
+	for i, o := range a.Config.Outputs {
+		if receives(o) {
+			last = i
+		}
+	}
+	if last < 0 {
+		m.Drop()
+		return
+	}
+
+	for i, o := range a.Config.Outputs[:last+1] {
+		if !receives(o) {
+			continue
+		}
+		if i == last {
+			o.AddMetric(m)
+		} else {
+			o.AddMetric(m.Copy())
+		}
+	}
+}
+
+// flusher monitors the metrics input channel and passes the metrics on to
+// the processors, aggregators and outputs.
+func (a *Agent) flusher(shutdown chan struct{}, metricC chan telegraf.Metric, aggC chan telegraf.Metric) error {
 	// create an output metric channel and a gorouting that continuously passes
 	// each metric onto the output plugins & aggregators.
-	outMetricC := make(chan telegraf.Metric, 100)
+	outMetricC := make(chan *pipelineMetric, 100)
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
@@ -267,26 +460,8 @@ func (a *Agent) flusher(shutdown chan struct{}, metricC chan telegraf.Metric, ag
 					continue
 				}
 				return
-			case m := <-outMetricC:
-				// if dropOriginal is set to true, then we will only send this
-				// metric to the aggregators, not the outputs.
-				var dropOriginal bool
-				if !m.IsAggregate() {
-					for _, agg := range a.Config.Aggregators {
-						if ok := agg.Add(m.Copy()); ok {
-							dropOriginal = true
-						}
-					}
-				}
-				if !dropOriginal {
-					for i, o := range a.Config.Outputs {
-						if i == len(a.Config.Outputs)-1 {
-							o.AddMetric(m)
-						} else {
-							o.AddMetric(m.Copy())
-						}
-					}
-				}
+			case pm := <-outMetricC:
+				a.aggregate(pm.Metric, pm.pipeline)
 			}
 		}
 	}()
@@ -303,88 +478,201 @@ func (a *Agent) flusher(shutdown chan struct{}, metricC chan telegraf.Metric, ag
 				}
 				return
 			case metric := <-aggC:
-				metrics := []telegraf.Metric{metric}
-				for _, processor := range a.Config.Processors {
-					metrics = processor.Apply(metrics...)
-				}
+				metric, pipeline := unwrapPipeline(metric)
+				metrics := a.process([]telegraf.Metric{metric}, pipeline)
+				a.mu.RLock()
 				for _, m := range metrics {
-					for i, o := range a.Config.Outputs {
-						if i == len(a.Config.Outputs)-1 {
-							o.AddMetric(m)
-						} else {
-							o.AddMetric(m.Copy())
-						}
-					}
+					a.output(m, pipeline)
 				}
+				a.mu.RUnlock()
 			}
 		}
 	}()
 
-	ticker := time.NewTicker(a.Config.Agent.FlushInterval.Duration)
-	semaphore := make(chan struct{}, 1)
+	ticker := time.NewTicker(processorFlushInterval)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-shutdown:
 			log.Println("I! Hang on, flushing any cached metrics before shutdown")
 			// wait for outMetricC to get flushed before flushing outputs
 			wg.Wait()
-			a.flush()
 			return nil
-		case <-ticker.C:
-			go func() {
-				select {
-				case semaphore <- struct{}{}:
-					internal.RandomSleep(a.Config.Agent.FlushJitter.Duration, shutdown)
-					a.flush()
-					<-semaphore
-				default:
-					// skipping this flush because one is already happening
-					log.Println("W! Skipping a scheduled flush because there is" +
-						" already a flush ongoing.")
-				}
-			}()
 		case metric := <-metricC:
 			// NOTE potential bottleneck here as we put each metric through the
 			// processors serially.
-			mS := []telegraf.Metric{metric}
-			for _, processor := range a.Config.Processors {
-				mS = processor.Apply(mS...)
-			}
+			metric, pipeline := unwrapPipeline(metric)
+			mS := a.process([]telegraf.Metric{metric}, pipeline)
 			for _, m := range mS {
-				outMetricC <- m
+				outMetricC <- &pipelineMetric{Metric: m, pipeline: pipeline}
 			}
+		case <-ticker.C:
+			a.flushProcessors(outMetricC)
 		}
 	}
 }
 
+// startServiceInput sets the default tags of the input and starts it if it
+// is a service input.
+func (a *Agent) startServiceInput(input *models.RunningInput) error {
+	input.SetDefaultTags(a.Config.Tags)
+	switch p := input.Input.(type) {
+	case telegraf.ServiceInput:
+		acc := NewAccumulator(input, a.metricC)
+		// Service input plugins should set their own precision of their
+		// metrics.
+		acc.SetPrecision(time.Nanosecond, 0)
+		if err := p.Start(acc); err != nil {
+			log.Printf("E! Service for input %s failed to start, exiting\n%s\n",
+				input.Name(), err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// startGatherer starts gathering from the input on its interval.
+func (a *Agent) startGatherer(input *models.RunningInput) {
+	interval := a.Config.Agent.Interval.Duration
+	// overwrite global interval if this plugin has it's own.
+	if input.Config.Interval != 0 {
+		interval = input.Config.Interval
+	}
+
+	p := newRunningPlugin()
+	a.inputs[input] = p
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer close(p.done)
+		a.gatherer(p.stop, input, interval, a.metricC)
+	}()
+}
+
+// stopInput stops gathering from the input and stops it if it is a service
+// input.
+func (a *Agent) stopInput(input *models.RunningInput) {
+	if p, ok := a.inputs[input]; ok {
+		p.Stop()
+		delete(a.inputs, input)
+	}
+	switch p := input.Input.(type) {
+	case telegraf.ServiceInput:
+		p.Stop()
+	}
+}
+
+func (a *Agent) startAggregator(agg *models.RunningAggregator) {
+	p := newRunningPlugin()
+	a.aggregators[agg] = p
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer close(p.done)
+		acc := NewAccumulator(agg, a.aggC)
+		acc.SetPrecision(a.Config.Agent.Precision.Duration,
+			a.Config.Agent.Interval.Duration)
+		agg.Run(acc, p.stop)
+	}()
+}
+
+func (a *Agent) stopAggregator(agg *models.RunningAggregator) {
+	if p, ok := a.aggregators[agg]; ok {
+		p.Stop()
+		delete(a.aggregators, agg)
+	}
+}
+
+// startOutput starts the flush loop of the output.
+func (a *Agent) startOutput(o *models.RunningOutput) {
+	p := newRunningPlugin()
+	a.outputs[o] = p
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer close(p.done)
+		a.flushLoop(p.stop, o)
+	}()
+}
+
+// stopOutput stops the flush loop of the output, which flushes it one last
+// time.
+func (a *Agent) stopOutput(o *models.RunningOutput) {
+	if p, ok := a.outputs[o]; ok {
+		p.Stop()
+		delete(a.outputs, o)
+	}
+}
+
+// flushLoop writes the metrics buffered by the output on its flush interval,
+// and whenever a full batch is ready in between. The output is written once
+// more when stop is closed.
+func (a *Agent) flushLoop(stop chan struct{}, o *models.RunningOutput) {
+	interval := a.Config.Agent.FlushInterval.Duration
+	if o.Config.FlushInterval != 0 {
+		interval = o.Config.FlushInterval
+	}
+	jitter := a.Config.Agent.FlushJitter.Duration
+	if o.Config.FlushJitter != 0 {
+		jitter = o.Config.FlushJitter
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			writeOutput(o)
+			return
+		case <-ticker.C:
+			internal.RandomSleep(jitter, stop)
+			writeOutput(o)
+		case <-o.BatchReady:
+			if err := o.WriteBatch(); err != nil {
+				log.Printf("E! Error writing to output [%s]: %s\n",
+					o.Name, err.Error())
+			}
+		}
+	}
+}
+
+func writeOutput(o *models.RunningOutput) {
+	if err := o.Write(); err != nil {
+		log.Printf("E! Error writing to output [%s]: %s\n",
+			o.Name, err.Error())
+	}
+}
+
 // Run runs the agent daemon, gathering every Interval
 func (a *Agent) Run(shutdown chan struct{}) error {
-	var wg sync.WaitGroup
-
 	log.Printf("I! Agent Config: Interval:%s, Quiet:%#v, Hostname:%#v, "+
 		"Flush Interval:%s \n",
 		a.Config.Agent.Interval.Duration, a.Config.Agent.Quiet,
 		a.Config.Agent.Hostname, a.Config.Agent.FlushInterval.Duration)
 
+	if err := a.startAPI(); err != nil {
+		return err
+	}
+	defer a.stopAPI()
+
+	a.reloadMu.Lock()
+
 	// channel shared between all input threads for accumulating metrics
-	metricC := make(chan telegraf.Metric, 100)
-	aggC := make(chan telegraf.Metric, 100)
+	a.metricC = make(chan telegraf.Metric, 100)
+	a.aggC = make(chan telegraf.Metric, 100)
+	a.inputs = make(map[*models.RunningInput]*runningPlugin)
+	a.aggregators = make(map[*models.RunningAggregator]*runningPlugin)
+	a.outputs = make(map[*models.RunningOutput]*runningPlugin)
 
 	// Start all ServicePlugins
-	for _, input := range a.Config.Inputs {
-		input.SetDefaultTags(a.Config.Tags)
-		switch p := input.Input.(type) {
-		case telegraf.ServiceInput:
-			acc := NewAccumulator(input, metricC)
-			// Service input plugins should set their own precision of their
-			// metrics.
-			acc.SetPrecision(time.Nanosecond, 0)
-			if err := p.Start(acc); err != nil {
-				log.Printf("E! Service for input %s failed to start, exiting\n%s\n",
-					input.Name(), err.Error())
-				return err
+	for i, input := range a.Config.Inputs {
+		if err := a.startServiceInput(input); err != nil {
+			for _, started := range a.Config.Inputs[:i] {
+				a.stopInput(started)
 			}
-			defer p.Stop()
+			a.reloadMu.Unlock()
+			return err
 		}
 	}
 
@@ -394,40 +682,295 @@ func (a *Agent) Run(shutdown chan struct{}) error {
 		time.Sleep(time.Duration(i - (time.Now().UnixNano() % i)))
 	}
 
-	wg.Add(1)
+	for _, o := range a.Config.Outputs {
+		a.startOutput(o)
+	}
+
+	flusherDone := make(chan struct{})
 	go func() {
-		defer wg.Done()
-		if err := a.flusher(shutdown, metricC, aggC); err != nil {
+		defer close(flusherDone)
+		if err := a.flusher(shutdown, a.metricC, a.aggC); err != nil {
 			log.Printf("E! Flusher routine failed, exiting: %s\n", err.Error())
 			close(shutdown)
 		}
 	}()
 
-	wg.Add(len(a.Config.Aggregators))
 	for _, aggregator := range a.Config.Aggregators {
-		go func(agg *models.RunningAggregator) {
-			defer wg.Done()
-			acc := NewAccumulator(agg, aggC)
-			acc.SetPrecision(a.Config.Agent.Precision.Duration,
-				a.Config.Agent.Interval.Duration)
-			agg.Run(acc, shutdown)
-		}(aggregator)
+		a.startAggregator(aggregator)
 	}
 
-	wg.Add(len(a.Config.Inputs))
 	for _, input := range a.Config.Inputs {
-		interval := a.Config.Agent.Interval.Duration
-		// overwrite global interval if this plugin has it's own.
-		if input.Config.Interval != 0 {
-			interval = input.Config.Interval
-		}
-		go func(in *models.RunningInput, interv time.Duration) {
-			defer wg.Done()
-			a.gatherer(shutdown, in, interv, metricC)
-		}(input, interval)
+		a.startGatherer(input)
+	}
+
+	a.running = true
+	a.reloadMu.Unlock()
+
+	<-shutdown
+
+	a.reloadMu.Lock()
+	a.running = false
+	for _, input := range a.Config.Inputs {
+		a.stopInput(input)
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		a.stopAggregator(aggregator)
+	}
+
+	// flush the outputs once all metrics have been passed on to them
+	<-flusherDone
+	// pass on the metrics still held by processors, straight to the outputs
+	// as the aggregators are stopped
+	a.mu.RLock()
+	flushed := processorMetrics(a.Config.Processors, func(*models.RunningProcessor) bool {
+		return true
+	})
+	for _, pm := range flushed {
+		a.output(pm.Metric, pm.pipeline)
+	}
+	a.mu.RUnlock()
+	for _, o := range a.Config.Outputs {
+		a.stopOutput(o)
 	}
+	a.reloadMu.Unlock()
 
-	wg.Wait()
+	a.wg.Wait()
 	a.Close()
 	return nil
 }
+
+// Reload applies the plugins of the given config to the running agent.
+// Plugins with an unchanged configuration keep running, along with the
+// metrics buffered in outputs and the current period of aggregators.
+// Removed plugins are stopped, removed outputs are flushed before being
+// closed, the metrics held by removed processors are passed on, and new
+// plugins are started.
+//
+// ErrRestartRequired is returned if the [agent] or [global_tags] sections
+// changed, if any other error occurs the running plugins are left as is.
+func (a *Agent) Reload(c *config.Config) error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	if !a.running {
+		return ErrRestartRequired
+	}
+
+	if err := setHostname(c); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(a.Config.Agent, c.Agent) ||
+		!reflect.DeepEqual(a.Config.Tags, c.Tags) {
+		return ErrRestartRequired
+	}
+	if err := models.LinkDeadLetters(c.Outputs); err != nil {
+		return err
+	}
+	checkPipelines(c)
+
+	// Match the new plugins against the running ones.
+	var oldIDs, newIDs []string
+	for _, o := range a.Config.Outputs {
+		oldIDs = append(oldIDs, a.fingerprint(o))
+	}
+	for _, o := range c.Outputs {
+		newIDs = append(newIDs, c.Fingerprint(o))
+	}
+	outputMatches := matchPlugins(oldIDs, newIDs)
+
+	oldIDs, newIDs = nil, nil
+	for _, input := range a.Config.Inputs {
+		oldIDs = append(oldIDs, a.fingerprint(input))
+	}
+	for _, input := range c.Inputs {
+		newIDs = append(newIDs, c.Fingerprint(input))
+	}
+	inputMatches := matchPlugins(oldIDs, newIDs)
+
+	oldIDs, newIDs = nil, nil
+	for _, p := range a.Config.Processors {
+		oldIDs = append(oldIDs, a.fingerprint(p))
+	}
+	for _, p := range c.Processors {
+		newIDs = append(newIDs, c.Fingerprint(p))
+	}
+	processorMatches := matchPlugins(oldIDs, newIDs)
+
+	oldIDs, newIDs = nil, nil
+	for _, agg := range a.Config.Aggregators {
+		oldIDs = append(oldIDs, a.fingerprint(agg))
+	}
+	for _, agg := range c.Aggregators {
+		newIDs = append(newIDs, c.Fingerprint(agg))
+	}
+	aggregatorMatches := matchPlugins(oldIDs, newIDs)
+
+	// Connect the new outputs and start the new service inputs before
+	// touching the running plugins, so that a failure leaves them as is.
+	fingerprints := make(map[interface{}]string)
+
+	var outputs, addedOutputs []*models.RunningOutput
+	keptOutputs := make(map[*models.RunningOutput]bool)
+	for i, o := range c.Outputs {
+		if m := outputMatches[i]; m >= 0 {
+			outputs = append(outputs, a.Config.Outputs[m])
+			keptOutputs[a.Config.Outputs[m]] = true
+			fingerprints[a.Config.Outputs[m]] = c.Fingerprint(o)
+			continue
+		}
+		fingerprints[o] = c.Fingerprint(o)
+		if err := connectOutput(o); err != nil {
+			for _, added := range addedOutputs {
+				closeOutput(added)
+			}
+			return err
+		}
+		outputs = append(outputs, o)
+		addedOutputs = append(addedOutputs, o)
+	}
+
+	var inputs, addedInputs []*models.RunningInput
+	keptInputs := make(map[*models.RunningInput]bool)
+	for i, input := range c.Inputs {
+		if m := inputMatches[i]; m >= 0 {
+			inputs = append(inputs, a.Config.Inputs[m])
+			keptInputs[a.Config.Inputs[m]] = true
+			fingerprints[a.Config.Inputs[m]] = c.Fingerprint(input)
+			continue
+		}
+		fingerprints[input] = c.Fingerprint(input)
+		if err := a.startServiceInput(input); err != nil {
+			for _, added := range addedInputs {
+				a.stopInput(added)
+			}
+			for _, added := range addedOutputs {
+				closeOutput(added)
+			}
+			return err
+		}
+		inputs = append(inputs, input)
+		addedInputs = append(addedInputs, input)
+	}
+
+	var processors models.RunningProcessors
+	keptProcessors := make(map[*models.RunningProcessor]bool)
+	for i, p := range c.Processors {
+		id := c.Fingerprint(p)
+		if m := processorMatches[i]; m >= 0 {
+			p = a.Config.Processors[m]
+			keptProcessors[p] = true
+		}
+		processors = append(processors, p)
+		fingerprints[p] = id
+	}
+	sort.Sort(processors)
+
+	var aggregators, addedAggregators []*models.RunningAggregator
+	keptAggregators := make(map[*models.RunningAggregator]bool)
+	for i, agg := range c.Aggregators {
+		if m := aggregatorMatches[i]; m >= 0 {
+			aggregators = append(aggregators, a.Config.Aggregators[m])
+			keptAggregators[a.Config.Aggregators[m]] = true
+			fingerprints[a.Config.Aggregators[m]] = c.Fingerprint(agg)
+			continue
+		}
+		fingerprints[agg] = c.Fingerprint(agg)
+		a.startAggregator(agg)
+		aggregators = append(aggregators, agg)
+		addedAggregators = append(addedAggregators, agg)
+	}
+
+	// Swap in the new plugins, the lock is only held for the swap so that
+	// metrics keep flowing while the removed outputs are flushed.
+	a.mu.Lock()
+	oldInputs := a.Config.Inputs
+	oldOutputs := a.Config.Outputs
+	oldProcessors := a.Config.Processors
+	oldAggregators := a.Config.Aggregators
+	a.Config.Inputs = inputs
+	a.Config.Outputs = outputs
+	// Cannot fail, the same outputs were linked above.
+	models.LinkDeadLetters(outputs)
+	a.Config.Processors = processors
+	a.Config.Aggregators = aggregators
+	a.mu.Unlock()
+	a.fingerprints = fingerprints
+
+	// The removed outputs are flushed and closed before the disk buffers of
+	// the new outputs are opened, so that a buffer directory can be handed
+	// over.
+	var removed int
+	for _, o := range oldOutputs {
+		if keptOutputs[o] {
+			continue
+		}
+		a.stopOutput(o)
+		if err := closeOutput(o); err != nil {
+			log.Printf("E! Error closing removed output [%s]: %s\n",
+				o.Name, err.Error())
+		}
+		removed++
+	}
+	for _, o := range addedOutputs {
+		if err := o.OpenDiskBuffer(); err != nil {
+			log.Printf("E! %s, buffering in memory\n", err)
+		}
+		a.startOutput(o)
+	}
+	for _, input := range addedInputs {
+		a.startGatherer(input)
+	}
+
+	for _, input := range oldInputs {
+		if !keptInputs[input] {
+			a.stopInput(input)
+			removed++
+		}
+	}
+	// pass on the metrics held by the removed processors through the
+	// processors that followed them
+	flushed := processorMetrics(oldProcessors, func(p *models.RunningProcessor) bool {
+		return !keptProcessors[p]
+	})
+	for _, pm := range flushed {
+		a.aggregate(pm.Metric, pm.pipeline)
+	}
+	for _, agg := range oldAggregators {
+		if !keptAggregators[agg] {
+			a.stopAggregator(agg)
+			removed++
+		}
+	}
+
+	log.Printf("I! Reloaded config, started %d and stopped %d plugins\n",
+		len(addedInputs)+len(addedOutputs)+len(addedAggregators), removed)
+	return nil
+}
+
+// fingerprint returns the fingerprint of a running plugin.
+func (a *Agent) fingerprint(plugin interface{}) string {
+	if a.fingerprints == nil {
+		return a.Config.Fingerprint(plugin)
+	}
+	return a.fingerprints[plugin]
+}
+
+// matchPlugins pairs each new plugin with an unused running plugin that has
+// the same fingerprint. The result holds the index of the matching running
+// plugin for each new plugin, or -1 if there is none.
+func matchPlugins(running, new []string) []int {
+	unused := make(map[string][]int)
+	for i, id := range running {
+		unused[id] = append(unused[id], i)
+	}
+
+	matches := make([]int, len(new))
+	for i, id := range new {
+		matches[i] = -1
+		if idx := unused[id]; len(idx) > 0 {
+			matches[i] = idx[0]
+			unused[id] = idx[1:]
+		}
+	}
+	return matches
+}
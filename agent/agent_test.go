@@ -1,9 +1,18 @@
 package agent
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/outputs"
 
 	// needing to load the plugins
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
@@ -11,6 +20,7 @@ import (
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAgent_OmitHostname(t *testing.T) {
@@ -109,3 +119,191 @@ func TestAgent_LoadOutput(t *testing.T) {
 	a, _ = NewAgent(c)
 	assert.Equal(t, 3, len(a.Config.Outputs))
 }
+
+func TestAgent_MatchPlugins(t *testing.T) {
+	matches := matchPlugins(
+		[]string{"a", "b", "b", "c"},
+		[]string{"b", "d", "a", "b", "b"},
+	)
+	assert.Equal(t, []int{1, -1, 0, 2, -1}, matches)
+
+	assert.Equal(t, []int{-1}, matchPlugins(nil, []string{"a"}))
+	assert.Equal(t, []int{}, matchPlugins([]string{"a"}, nil))
+}
+
+// heldProcessor holds on to the metrics of each pipeline until its final
+// flush.
+type heldProcessor struct {
+	held map[string][]telegraf.Metric
+}
+
+func (p *heldProcessor) SampleConfig() string { return "" }
+func (p *heldProcessor) Description() string  { return "" }
+func (p *heldProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	return p.ApplyPipeline(models.DefaultPipeline, in...)
+}
+
+func (p *heldProcessor) ApplyPipeline(pipeline string, in ...telegraf.Metric) []telegraf.Metric {
+	p.held[pipeline] = append(p.held[pipeline], in...)
+	return nil
+}
+
+func (p *heldProcessor) Flush(final bool) map[string][]telegraf.Metric {
+	if !final {
+		return nil
+	}
+	out := p.held
+	p.held = make(map[string][]telegraf.Metric)
+	return out
+}
+
+// suffixProcessor appends a suffix to the name of the metrics.
+type suffixProcessor struct {
+	suffix string
+}
+
+func (p *suffixProcessor) SampleConfig() string { return "" }
+func (p *suffixProcessor) Description() string  { return "" }
+func (p *suffixProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		m.SetSuffix(p.suffix)
+	}
+	return in
+}
+
+func TestAgent_ProcessorMetrics(t *testing.T) {
+	held := &models.RunningProcessor{
+		Name:      "held",
+		Processor: &heldProcessor{held: make(map[string][]telegraf.Metric)},
+		Config:    &models.ProcessorConfig{Pipelines: []string{"default", "security"}},
+	}
+	processors := models.RunningProcessors{
+		held,
+		{
+			Name:      "default",
+			Processor: &suffixProcessor{suffix: "_default"},
+			Config:    &models.ProcessorConfig{},
+		},
+		{
+			Name:      "security",
+			Processor: &suffixProcessor{suffix: "_security"},
+			Config:    &models.ProcessorConfig{Pipelines: []string{"security"}},
+		},
+	}
+
+	cpu, err := metric.New("cpu", nil, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	assert.NoError(t, err)
+	auth, err := metric.New("auth", nil, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	assert.NoError(t, err)
+	held.ApplyPipeline("default", cpu)
+	held.ApplyPipeline("security", auth)
+
+	final := func(p *models.RunningProcessor) bool { return p == held }
+	names := make(map[string]string)
+	for _, pm := range processorMetrics(processors, final) {
+		names[pm.Name()] = pm.pipeline
+	}
+	assert.Equal(t, map[string]string{
+		"cpu_default":   "default",
+		"auth_security": "security",
+	}, names, "Should pass the metrics through the processors of their own pipeline")
+}
+
+// recordOutput records the metrics written to it.
+type recordOutput struct {
+	Label string
+
+	sync.Mutex
+	metrics []telegraf.Metric
+}
+
+func (o *recordOutput) SampleConfig() string { return "" }
+func (o *recordOutput) Description() string  { return "" }
+func (o *recordOutput) Connect() error       { return nil }
+func (o *recordOutput) Close() error         { return nil }
+func (o *recordOutput) Write(metrics []telegraf.Metric) error {
+	o.Lock()
+	defer o.Unlock()
+	o.metrics = append(o.metrics, metrics...)
+	return nil
+}
+
+func (o *recordOutput) Metrics() []telegraf.Metric {
+	o.Lock()
+	defer o.Unlock()
+	return o.metrics
+}
+
+func loadTestConfig(t *testing.T, dir string, data string) *config.Config {
+	path := filepath.Join(dir, "telegraf.conf")
+	require.NoError(t, ioutil.WriteFile(path, []byte(data), 0640))
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfig(path))
+	return c
+}
+
+func TestAgent_ReloadOutputs(t *testing.T) {
+	outputs.Add("record", func() telegraf.Output { return &recordOutput{} })
+
+	dir, err := ioutil.TempDir("", "telegraf-reload")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	agentConf := `
+[agent]
+  round_interval = false
+  flush_interval = "1h"
+`
+	c := loadTestConfig(t, dir, agentConf+`
+[[outputs.record]]
+  label = "kept"
+[[outputs.record]]
+  label = "removed"
+`)
+	require.Len(t, c.Outputs, 2)
+	kept, removed := c.Outputs[0], c.Outputs[1]
+	if removed.Output.(*recordOutput).Label == "kept" {
+		kept, removed = removed, kept
+	}
+
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	shutdown := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- a.Run(shutdown)
+	}()
+	for {
+		a.reloadMu.Lock()
+		running := a.running
+		a.reloadMu.Unlock()
+		if running {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	m1, err := metric.New("kept", nil, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	require.NoError(t, err)
+	m2, err := metric.New("removed", nil, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	require.NoError(t, err)
+	kept.AddMetric(m1)
+	removed.AddMetric(m2)
+
+	require.NoError(t, a.Reload(loadTestConfig(t, dir, agentConf+`
+[[outputs.record]]
+  label = "kept"
+[[outputs.record]]
+  label = "added"
+`)))
+
+	// the removed output is flushed, the unchanged one keeps its buffer
+	assert.Equal(t, []telegraf.Metric{m2}, removed.Output.(*recordOutput).Metrics())
+	require.Len(t, a.Config.Outputs, 2)
+	assert.Contains(t, a.Config.Outputs, kept)
+	assert.Len(t, kept.Output.(*recordOutput).Metrics(), 0)
+
+	close(shutdown)
+	require.NoError(t, <-done)
+	assert.Equal(t, []telegraf.Metric{m1}, kept.Output.(*recordOutput).Metrics())
+}
@@ -0,0 +1,250 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+var circuitStates = map[int]string{
+	models.CircuitClosed:   "closed",
+	models.CircuitOpen:     "open",
+	models.CircuitHalfOpen: "half_open",
+}
+
+// startAPI starts the HTTP API on api_listen, if set.
+func (a *Agent) startAPI() error {
+	if a.Config.Agent.APIListen == "" {
+		return nil
+	}
+	ln, err := net.Listen("tcp", a.Config.Agent.APIListen)
+	if err != nil {
+		return fmt.Errorf("unable to start API: %s", err)
+	}
+	a.api = &http.Server{Handler: a.apiHandler()}
+	log.Printf("I! Serving API on %s\n", ln.Addr())
+	go func(srv *http.Server) {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("E! API server failed: %s\n", err)
+		}
+	}(a.api)
+	return nil
+}
+
+// stopAPI stops the HTTP API if it is running.
+func (a *Agent) stopAPI() {
+	if a.api == nil {
+		return
+	}
+	if err := a.api.Close(); err != nil {
+		log.Printf("E! Error stopping API: %s\n", err)
+	}
+	a.api = nil
+}
+
+func (a *Agent) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", a.serveHealth)
+	mux.HandleFunc("/plugins", a.servePlugins)
+	mux.HandleFunc("/buffers", a.serveBuffers)
+	return mux
+}
+
+type healthResponse struct {
+	Status   string   `json:"status"`
+	Failures []string `json:"failures,omitempty"`
+}
+
+// serveHealth responds with 503 Service Unavailable when an output has been
+// failing for longer than api_output_failure_threshold, or an input takes
+// longer to gather than its interval.
+func (a *Agent) serveHealth(w http.ResponseWriter, r *http.Request) {
+	failures := a.healthFailures(time.Now())
+	if len(failures) > 0 {
+		writeJSON(w, http.StatusServiceUnavailable,
+			healthResponse{Status: "fail", Failures: failures})
+		return
+	}
+	writeJSON(w, http.StatusOK, healthResponse{Status: "pass"})
+}
+
+// healthFailures returns the reasons the agent is unhealthy at now.
+func (a *Agent) healthFailures(now time.Time) []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var failures []string
+	for _, input := range a.Config.Inputs {
+		if input.Status().Overrun {
+			failures = append(failures, fmt.Sprintf(
+				"input %s took longer to gather than its interval",
+				inputLogName(input)))
+		}
+	}
+
+	threshold := a.Config.Agent.APIOutputFailureThreshold.Duration
+	for _, o := range a.Config.Outputs {
+		since := o.Status().FailingSince
+		if !since.IsZero() && now.Sub(since) >= threshold {
+			failures = append(failures, fmt.Sprintf(
+				"output %s has been failing for %s",
+				"outputs."+o.LogName(), now.Sub(since).Truncate(time.Second)))
+		}
+	}
+	return failures
+}
+
+type inputResponse struct {
+	Name          string `json:"name"`
+	Alias         string `json:"alias,omitempty"`
+	Pipeline      string `json:"pipeline"`
+	LastGather    string `json:"last_gather,omitempty"`
+	GatherTimeNs  int64  `json:"gather_time_ns"`
+	Overrun       bool   `json:"overrun"`
+	LastError     string `json:"last_error,omitempty"`
+	LastErrorTime string `json:"last_error_time,omitempty"`
+}
+
+type pluginResponse struct {
+	Name      string   `json:"name"`
+	Pipelines []string `json:"pipelines,omitempty"`
+}
+
+type outputResponse struct {
+	Name                string   `json:"name"`
+	Alias               string   `json:"alias,omitempty"`
+	Pipelines           []string `json:"pipelines,omitempty"`
+	State               string   `json:"state"`
+	ConsecutiveFailures int      `json:"consecutive_failures"`
+	FailingSince        string   `json:"failing_since,omitempty"`
+	LastError           string   `json:"last_error,omitempty"`
+	LastErrorTime       string   `json:"last_error_time,omitempty"`
+}
+
+type pluginsResponse struct {
+	Inputs      []inputResponse  `json:"inputs"`
+	Processors  []pluginResponse `json:"processors"`
+	Aggregators []pluginResponse `json:"aggregators"`
+	Outputs     []outputResponse `json:"outputs"`
+}
+
+// servePlugins lists the running plugins along with the outcome of their
+// last gather or write.
+func (a *Agent) servePlugins(w http.ResponseWriter, r *http.Request) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	resp := pluginsResponse{
+		Inputs:      []inputResponse{},
+		Processors:  []pluginResponse{},
+		Aggregators: []pluginResponse{},
+		Outputs:     []outputResponse{},
+	}
+	for _, input := range a.Config.Inputs {
+		s := input.Status()
+		resp.Inputs = append(resp.Inputs, inputResponse{
+			Name:          input.Name(),
+			Alias:         input.Config.Alias,
+			Pipeline:      input.Pipeline(),
+			LastGather:    formatTime(s.LastGather),
+			GatherTimeNs:  s.GatherTime.Nanoseconds(),
+			Overrun:       s.Overrun,
+			LastError:     formatError(s.LastError),
+			LastErrorTime: formatTime(s.LastErrorTime),
+		})
+	}
+	for _, p := range a.Config.Processors {
+		resp.Processors = append(resp.Processors, pluginResponse{
+			Name:      "processors." + p.Name,
+			Pipelines: p.Config.Pipelines,
+		})
+	}
+	for _, agg := range a.Config.Aggregators {
+		resp.Aggregators = append(resp.Aggregators, pluginResponse{
+			Name:      agg.Name(),
+			Pipelines: agg.Config.Pipelines,
+		})
+	}
+	for _, o := range a.Config.Outputs {
+		s := o.Status()
+		resp.Outputs = append(resp.Outputs, outputResponse{
+			Name:                "outputs." + o.Name,
+			Alias:               o.Config.Alias,
+			Pipelines:           o.Config.Pipelines,
+			State:               circuitStates[s.State],
+			ConsecutiveFailures: s.ConsecutiveFailures,
+			FailingSince:        formatTime(s.FailingSince),
+			LastError:           formatError(s.LastError),
+			LastErrorTime:       formatTime(s.LastErrorTime),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type bufferResponse struct {
+	Name      string  `json:"name"`
+	Alias     string  `json:"alias,omitempty"`
+	Size      int     `json:"size"`
+	Limit     int     `json:"limit"`
+	Fill      float64 `json:"fill"`
+	DiskBytes int64   `json:"disk_bytes"`
+}
+
+// serveBuffers reports how full the buffer of each output is. Fill is the
+// ratio of the buffered metrics to the buffer limit, metrics stored in a
+// disk buffer can make it exceed 1.
+func (a *Agent) serveBuffers(w http.ResponseWriter, r *http.Request) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	resp := []bufferResponse{}
+	for _, o := range a.Config.Outputs {
+		s := o.Status()
+		b := bufferResponse{
+			Name:      "outputs." + o.Name,
+			Alias:     o.Config.Alias,
+			Size:      s.Buffered,
+			Limit:     s.BufferLimit,
+			DiskBytes: s.BufferDiskBytes,
+		}
+		if s.BufferLimit > 0 {
+			b.Fill = float64(s.Buffered) / float64(s.BufferLimit)
+		}
+		resp = append(resp, b)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("E! Error writing API response: %s\n", err)
+	}
+}
+
+func inputLogName(input *models.RunningInput) string {
+	if input.Config.Alias == "" {
+		return input.Name()
+	}
+	return input.Name() + "::" + input.Config.Alias
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func formatError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/metric"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type apiInput struct{}
+
+func (i *apiInput) SampleConfig() string                  { return "" }
+func (i *apiInput) Description() string                   { return "" }
+func (i *apiInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+type apiOutput struct {
+	fail bool
+}
+
+func (o *apiOutput) Connect() error       { return nil }
+func (o *apiOutput) Close() error         { return nil }
+func (o *apiOutput) SampleConfig() string { return "" }
+func (o *apiOutput) Description() string  { return "" }
+func (o *apiOutput) Write(metrics []telegraf.Metric) error {
+	if o.fail {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func newAPIAgent(threshold time.Duration) (*Agent, *models.RunningInput, *models.RunningOutput) {
+	c := config.NewConfig()
+	c.Agent.APIOutputFailureThreshold.Duration = threshold
+
+	input := models.NewRunningInput(&apiInput{}, &models.InputConfig{
+		Name:  "api_test",
+		Alias: "first",
+	})
+	c.Inputs = append(c.Inputs, input)
+
+	output := models.NewRunningOutput("api_test", &apiOutput{fail: true},
+		&models.OutputConfig{Name: "api_test"}, 5, 10)
+	c.Outputs = append(c.Outputs, output)
+	return &Agent{Config: c}, input, output
+}
+
+func serveAPI(t *testing.T, a *Agent, path string, v interface{}) int {
+	rec := httptest.NewRecorder()
+	a.apiHandler().ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+	return rec.Code
+}
+
+func TestAPIHealth(t *testing.T) {
+	a, input, output := newAPIAgent(time.Hour)
+
+	var resp healthResponse
+	assert.Equal(t, http.StatusOK, serveAPI(t, a, "/health", &resp))
+	assert.Equal(t, "pass", resp.Status)
+
+	input.SetGatherResult(time.Now(), 2*time.Second, true)
+	assert.Equal(t, http.StatusServiceUnavailable, serveAPI(t, a, "/health", &resp))
+	assert.Equal(t, "fail", resp.Status)
+	assert.Equal(t, []string{
+		"input inputs.api_test::first took longer to gather than its interval",
+	}, resp.Failures)
+
+	input.SetGatherResult(time.Now(), time.Second, false)
+	m, _ := metric.New("cpu", nil, map[string]interface{}{"value": 1}, time.Now())
+	output.AddMetric(m)
+	require.Error(t, output.Write())
+
+	// the output has not been failing for long enough
+	assert.Empty(t, a.healthFailures(time.Now()))
+	assert.Equal(t, []string{
+		"output outputs.api_test has been failing for 2h0m0s",
+	}, a.healthFailures(time.Now().Add(2*time.Hour)))
+}
+
+func TestAPIPlugins(t *testing.T) {
+	a, input, output := newAPIAgent(time.Hour)
+	input.SetLastError(errors.New("gather failed"))
+	m, _ := metric.New("cpu", nil, map[string]interface{}{"value": 1}, time.Now())
+	output.AddMetric(m)
+	require.Error(t, output.Write())
+
+	var resp pluginsResponse
+	assert.Equal(t, http.StatusOK, serveAPI(t, a, "/plugins", &resp))
+	require.Len(t, resp.Inputs, 1)
+	assert.Equal(t, "inputs.api_test", resp.Inputs[0].Name)
+	assert.Equal(t, "first", resp.Inputs[0].Alias)
+	assert.Equal(t, models.DefaultPipeline, resp.Inputs[0].Pipeline)
+	assert.Equal(t, "gather failed", resp.Inputs[0].LastError)
+	assert.NotEmpty(t, resp.Inputs[0].LastErrorTime)
+	assert.Empty(t, resp.Processors)
+	assert.Empty(t, resp.Aggregators)
+
+	require.Len(t, resp.Outputs, 1)
+	assert.Equal(t, "outputs.api_test", resp.Outputs[0].Name)
+	assert.Equal(t, "open", resp.Outputs[0].State)
+	assert.Equal(t, 1, resp.Outputs[0].ConsecutiveFailures)
+	assert.Equal(t, "connection refused", resp.Outputs[0].LastError)
+	assert.NotEmpty(t, resp.Outputs[0].FailingSince)
+}
+
+func TestAPIBuffers(t *testing.T) {
+	a, _, output := newAPIAgent(time.Hour)
+	for i := 0; i < 4; i++ {
+		m, _ := metric.New("cpu", nil, map[string]interface{}{"value": i}, time.Now())
+		output.AddMetric(m)
+	}
+
+	var resp []bufferResponse
+	assert.Equal(t, http.StatusOK, serveAPI(t, a, "/buffers", &resp))
+	assert.Equal(t, []bufferResponse{
+		{Name: "outputs.api_test", Size: 4, Limit: 10, Fill: 0.4},
+	}, resp)
+}
@@ -8,9 +8,11 @@ import (
 	_ "net/http/pprof" // Comment this line to disable pprof endpoint.
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/influxdata/telegraf/agent"
 	"github.com/influxdata/telegraf/internal/config"
@@ -22,6 +24,7 @@ import (
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 	_ "github.com/influxdata/telegraf/plugins/processors/all"
 	"github.com/kardianos/service"
+	"gopkg.in/fsnotify.v1"
 )
 
 var fDebug = flag.Bool("debug", false,
@@ -31,9 +34,11 @@ var pprofAddr = flag.String("pprof-addr", "",
 var fQuiet = flag.Bool("quiet", false,
 	"run in quiet mode")
 var fTest = flag.Bool("test", false, "gather metrics, print them out, and exit")
-var fConfig = flag.String("config", "", "configuration file to load")
+var fConfig = flag.String("config", "", "configuration file or http(s) URL to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
+var fWatchConfig = flag.Bool("watch-config", false,
+	"reload the config when the config file or config directory changes")
 var fVersion = flag.Bool("version", false, "display the version")
 var fSampleConfig = flag.Bool("sample-config", false,
 	"print out full sample configuration")
@@ -84,9 +89,10 @@ The commands & flags are:
   config              print out full sample configuration to stdout
   version             print the version to stdout
 
-  --config <file>     configuration file to load
+  --config <file>     configuration file or http(s) URL to load
   --test              gather metrics once, print them to stdout, and exit
   --config-directory  directory containing additional *.conf files
+  --watch-config      reload the config when the config file or directory changes
   --input-filter      filter the input plugins to enable, separator is :
   --output-filter     filter the output plugins to enable, separator is :
   --usage             print usage for a plugin, ie, 'telegraf --usage mysql'
@@ -117,6 +123,170 @@ Examples:
 
 var stop chan struct{}
 
+// loadConfig loads the config file and config directory.
+func loadConfig(inputFilters []string, outputFilters []string) (*config.Config, error) {
+	c := config.NewConfig()
+	c.OutputFilters = outputFilters
+	c.InputFilters = inputFilters
+	err := c.LoadConfig(*fConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if *fConfigDirectory != "" {
+		err = c.LoadDirectory(*fConfigDirectory)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !*fTest && len(c.Outputs) == 0 {
+		return nil, fmt.Errorf("Error: no outputs found, did you provide a valid config file?")
+	}
+	if len(c.Inputs) == 0 {
+		return nil, fmt.Errorf("Error: no inputs found, did you provide a valid config file?")
+	}
+
+	if int64(c.Agent.Interval.Duration) <= 0 {
+		return nil, fmt.Errorf("Agent interval must be positive, found %s",
+			c.Agent.Interval.Duration)
+	}
+
+	if int64(c.Agent.FlushInterval.Duration) <= 0 {
+		return nil, fmt.Errorf("Agent flush_interval must be positive; found %s",
+			c.Agent.Interval.Duration)
+	}
+	return c, nil
+}
+
+// reloadConfig applies the config to the running agent, it returns false if
+// the agent must be restarted for the config to take effect.
+func reloadConfig(
+	ag *agent.Agent,
+	inputFilters []string,
+	outputFilters []string,
+) bool {
+	log.Printf("I! Reloading Telegraf config\n")
+	c, err := loadConfig(inputFilters, outputFilters)
+	if err != nil {
+		log.Printf("E! Keeping the running config, %s\n", err)
+		return true
+	}
+
+	switch err := ag.Reload(c); err {
+	case nil:
+		log.Printf("I! Loaded outputs: %s", strings.Join(c.OutputNames(), " "))
+		log.Printf("I! Loaded inputs: %s", strings.Join(c.InputNames(), " "))
+	case agent.ErrRestartRequired:
+		log.Printf("I! Agent settings changed, restarting Telegraf\n")
+		return false
+	default:
+		log.Printf("E! Keeping the running config, %s\n", err)
+	}
+	return true
+}
+
+// watchConfig watches the config file and config directory, and sends on
+// changed once a change has settled. Watching stops when done is closed.
+func watchConfig(done chan struct{}, changed chan<- struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	// Watch the parent directory of the config file, editors often replace
+	// the file rather than writing to it.
+	var configFile, configDir string
+	if *fConfig != "" && !config.IsURL(*fConfig) {
+		configFile, err = filepath.Abs(*fConfig)
+		if err == nil {
+			err = watcher.Add(filepath.Dir(configFile))
+		}
+		if err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+	if *fConfigDirectory != "" {
+		configDir, err = filepath.Abs(*fConfigDirectory)
+		if err == nil {
+			err = filepath.Walk(configDir, func(path string, info os.FileInfo, err error) error {
+				if err != nil || !info.IsDir() {
+					return nil
+				}
+				return watcher.Add(path)
+			})
+		}
+		if err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	isConfig := func(path string) bool {
+		if path == configFile {
+			return true
+		}
+		return configDir != "" &&
+			strings.HasPrefix(path, configDir+string(filepath.Separator)) &&
+			strings.HasSuffix(path, ".conf")
+	}
+
+	go func() {
+		defer watcher.Close()
+		var settle <-chan time.Time
+		for {
+			select {
+			case <-done:
+				return
+			case event := <-watcher.Events:
+				if isConfig(filepath.Clean(event.Name)) {
+					settle = time.After(time.Second)
+				}
+			case err := <-watcher.Errors:
+				log.Printf("E! Error watching config: %s\n", err)
+			case <-settle:
+				settle = nil
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// pollConfig checks the config at url for changes on every interval, and
+// sends on changed when it differs from the loaded copy. Polling stops when
+// done is closed.
+func pollConfig(
+	url string,
+	interval time.Duration,
+	done chan struct{},
+	changed chan<- struct{},
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			ok, err := config.RemoteConfigChanged(url)
+			if err != nil {
+				log.Printf("E! Unable to check config %s for changes: %s\n", url, err)
+				continue
+			}
+			if ok {
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
 func reloadLoop(
 	stop chan struct{},
 	inputFilters []string,
@@ -130,48 +300,26 @@ func reloadLoop(
 		reload <- false
 
 		// If no other options are specified, load the config file and run.
-		c := config.NewConfig()
-		c.OutputFilters = outputFilters
-		c.InputFilters = inputFilters
-		err := c.LoadConfig(*fConfig)
+		c, err := loadConfig(inputFilters, outputFilters)
 		if err != nil {
 			log.Fatal("E! " + err.Error())
 		}
 
-		if *fConfigDirectory != "" {
-			err = c.LoadDirectory(*fConfigDirectory)
-			if err != nil {
-				log.Fatal("E! " + err.Error())
-			}
-		}
-		if !*fTest && len(c.Outputs) == 0 {
-			log.Fatalf("E! Error: no outputs found, did you provide a valid config file?")
-		}
-		if len(c.Inputs) == 0 {
-			log.Fatalf("E! Error: no inputs found, did you provide a valid config file?")
-		}
-
-		if int64(c.Agent.Interval.Duration) <= 0 {
-			log.Fatalf("E! Agent interval must be positive, found %s",
-				c.Agent.Interval.Duration)
-		}
-
-		if int64(c.Agent.FlushInterval.Duration) <= 0 {
-			log.Fatalf("E! Agent flush_interval must be positive; found %s",
-				c.Agent.Interval.Duration)
-		}
-
 		ag, err := agent.NewAgent(c)
 		if err != nil {
 			log.Fatal("E! " + err.Error())
 		}
 
 		// Setup logging
-		logger.SetupLogging(
-			ag.Config.Agent.Debug || *fDebug,
-			ag.Config.Agent.Quiet || *fQuiet,
-			ag.Config.Agent.Logfile,
-		)
+		logger.SetupLogging(logger.LogConfig{
+			Debug:               ag.Config.Agent.Debug || *fDebug,
+			Quiet:               ag.Config.Agent.Quiet || *fQuiet,
+			Logfile:             ag.Config.Agent.Logfile,
+			Format:              ag.Config.Agent.LogFormat,
+			RotationInterval:    ag.Config.Agent.LogfileRotationInterval.Duration,
+			RotationMaxSize:     ag.Config.Agent.LogfileRotationMaxSize,
+			RotationMaxArchives: ag.Config.Agent.LogfileRotationMaxArchives,
+		})
 
 		if *fTest {
 			err = ag.Test()
@@ -187,22 +335,49 @@ func reloadLoop(
 		}
 
 		shutdown := make(chan struct{})
+
+		changed := make(chan struct{}, 1)
+		if *fWatchConfig {
+			err = watchConfig(shutdown, changed)
+			if err != nil {
+				log.Printf("E! Unable to watch config for changes: %s\n", err)
+			}
+		}
+		if config.IsURL(*fConfig) && c.Agent.ConfigURLPollInterval.Duration > 0 {
+			go pollConfig(*fConfig, c.Agent.ConfigURLPollInterval.Duration,
+				shutdown, changed)
+		}
+
 		signals := make(chan os.Signal)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP)
 		go func() {
-			select {
-			case sig := <-signals:
-				if sig == os.Interrupt {
-					close(shutdown)
-				}
-				if sig == syscall.SIGHUP {
-					log.Printf("I! Reloading Telegraf config\n")
-					<-reload
-					reload <- true
+			defer signal.Stop(signals)
+			for {
+				select {
+				case sig := <-signals:
+					if sig == os.Interrupt {
+						close(shutdown)
+						return
+					}
+					if sig == syscall.SIGHUP && !reloadConfig(ag, inputFilters, outputFilters) {
+						<-reload
+						reload <- true
+						close(shutdown)
+						return
+					}
+				case <-changed:
+					if !reloadConfig(ag, inputFilters, outputFilters) {
+						<-reload
+						reload <- true
+						close(shutdown)
+						return
+					}
+				case <-stop:
 					close(shutdown)
+					return
+				case <-shutdown:
+					return
 				}
-			case <-stop:
-				close(shutdown)
 			}
 		}()
 
@@ -16,6 +16,9 @@ var (
 type Buffer struct {
 	buf chan telegraf.Metric
 
+	// onDrop is called with each metric evicted from a full buffer.
+	onDrop func(telegraf.Metric)
+
 	mu sync.Mutex
 }
 
@@ -28,6 +31,13 @@ func NewBuffer(size int) *Buffer {
 	}
 }
 
+// SetDropHandler sets a function to be called with each metric dropped
+// because the buffer is full, before the metric is rejected. It must be set
+// before metrics are added.
+func (b *Buffer) SetDropHandler(fn func(telegraf.Metric)) {
+	b.onDrop = fn
+}
+
 // IsEmpty returns true if Buffer is empty.
 func (b *Buffer) IsEmpty() bool {
 	return len(b.buf) == 0
@@ -47,7 +57,13 @@ func (b *Buffer) Add(metrics ...telegraf.Metric) {
 		default:
 			b.mu.Lock()
 			MetricsDropped.Incr(1)
-			<-b.buf
+			dropped := <-b.buf
+			if b.onDrop != nil {
+				b.onDrop(dropped)
+			}
+			// The metric was not delivered, make sure the input does
+			// not acknowledge it.
+			dropped.Reject()
 			b.buf <- metrics[i]
 			b.mu.Unlock()
 		}
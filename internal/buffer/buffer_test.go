@@ -4,9 +4,11 @@ import (
 	"testing"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var metricList = []telegraf.Metric{
@@ -70,6 +72,40 @@ func TestDroppingMetrics(t *testing.T) {
 	assert.Equal(t, int64(15), MetricsWritten.Get())
 }
 
+func TestDropHandler(t *testing.T) {
+	b := NewBuffer(5)
+	var dropped []telegraf.Metric
+	b.SetDropHandler(func(m telegraf.Metric) {
+		dropped = append(dropped, m)
+	})
+
+	b.Add(metricList...)
+	assert.Len(t, dropped, 0)
+
+	// the oldest metrics are handed to the handler as they are evicted
+	b.Add(metricList[:2]...)
+	assert.Equal(t, metricList[:2], dropped)
+}
+
+func TestDroppingTrackingMetrics(t *testing.T) {
+	b := NewBuffer(1)
+
+	var delivered []telegraf.DeliveryInfo
+	notify := func(info telegraf.DeliveryInfo) {
+		delivered = append(delivered, info)
+	}
+
+	m1, _ := metric.WithTracking(metricList[0].Copy(), notify)
+	m2, _ := metric.WithTracking(metricList[1].Copy(), notify)
+	b.Add(m1)
+	assert.Len(t, delivered, 0)
+
+	// the evicted metric is rejected
+	b.Add(m2)
+	require.Len(t, delivered, 1)
+	assert.False(t, delivered[0].Delivered())
+}
+
 func TestGettingBatches(t *testing.T) {
 	b := NewBuffer(20)
 	MetricsDropped.Set(0)
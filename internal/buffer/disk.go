@@ -0,0 +1,485 @@
+package buffer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+const (
+	// FsyncAlways syncs every append to stable storage before returning.
+	FsyncAlways = "always"
+	// FsyncNever leaves flushing of appended data up to the operating system.
+	FsyncNever = "never"
+
+	// defaultSegmentSize is the size at which the active segment is rotated.
+	defaultSegmentSize = 1 << 20
+
+	segmentExt = ".seg"
+
+	// cursorFile records how far into the oldest segment metrics have been
+	// committed, so they are not written again after a restart.
+	cursorFile = "cursor"
+)
+
+// DiskBuffer is a persistent queue of metrics. Metrics are appended to
+// segment files in line protocol, each line prefixed with the value type of
+// the metric, and segments are removed from disk once every metric they
+// contain has been committed.
+type DiskBuffer struct {
+	dir         string
+	maxSize     int64
+	segmentSize int64
+	fsync       bool
+
+	// segments are ordered from oldest to newest, the last segment is the
+	// one being appended to.
+	segments []*segment
+	nextID   uint64
+	head     *os.File
+
+	size  int64
+	count int
+
+	// read cursor into segments[0]
+	offset   int64
+	consumed int
+
+	// cursor after the last call to Peek, applied by Commit
+	pending *cursor
+
+	mu sync.Mutex
+}
+
+type segment struct {
+	id    uint64
+	path  string
+	size  int64
+	count int
+}
+
+type cursor struct {
+	seg      int
+	offset   int64
+	consumed int
+	lines    int
+}
+
+// NewDiskBuffer returns a DiskBuffer stored in dir, loading any segments
+// left behind by a previous run.
+//   maxSize is the maximum number of bytes kept on disk, when exceeded the
+//   oldest segments are dropped. A maxSize of 0 means unlimited.
+//   fsync is one of FsyncAlways or FsyncNever, defaults to FsyncAlways.
+func NewDiskBuffer(dir string, maxSize int64, fsync string) (*DiskBuffer, error) {
+	b := &DiskBuffer{
+		dir:         dir,
+		maxSize:     maxSize,
+		segmentSize: defaultSegmentSize,
+	}
+
+	switch fsync {
+	case "", FsyncAlways:
+		b.fsync = true
+	case FsyncNever:
+		b.fsync = false
+	default:
+		return nil, fmt.Errorf("invalid buffer fsync policy: %s", fsync)
+	}
+
+	if maxSize > 0 && maxSize/4 < b.segmentSize {
+		b.segmentSize = maxSize / 4
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// load scans the buffer directory for existing segments.
+func (b *DiskBuffer) load() error {
+	files, err := ioutil.ReadDir(b.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, fi := range files {
+		if fi.IsDir() || filepath.Ext(fi.Name()) != segmentExt {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(fi.Name(), segmentExt), 16, 64)
+		if err != nil {
+			continue
+		}
+
+		seg := &segment{
+			id:   id,
+			path: filepath.Join(b.dir, fi.Name()),
+			size: fi.Size(),
+		}
+		seg.count, err = countLines(seg.path)
+		if err != nil {
+			return err
+		}
+
+		b.segments = append(b.segments, seg)
+		b.size += seg.size
+		b.count += seg.count
+	}
+
+	sort.Slice(b.segments, func(i, j int) bool {
+		return b.segments[i].id < b.segments[j].id
+	})
+	if len(b.segments) > 0 {
+		b.nextID = b.segments[len(b.segments)-1].id + 1
+	}
+	return b.loadCursor()
+}
+
+// loadCursor restores the read cursor saved by the last Commit. A cursor
+// that does not refer to the oldest segment is stale and ignored.
+func (b *DiskBuffer) loadCursor() error {
+	data, err := ioutil.ReadFile(filepath.Join(b.dir, cursorFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var id uint64
+	var offset int64
+	var consumed int
+	_, err = fmt.Sscanf(string(data), "%x %d %d", &id, &offset, &consumed)
+	if err != nil {
+		log.Printf("W! Ignoring unreadable buffer cursor in %s: %s", b.dir, err)
+		return nil
+	}
+
+	if len(b.segments) == 0 || b.segments[0].id != id {
+		return nil
+	}
+	seg := b.segments[0]
+	if offset < 0 || offset > seg.size || consumed < 0 || consumed > seg.count {
+		return nil
+	}
+	b.offset = offset
+	b.consumed = consumed
+	b.count -= consumed
+	return nil
+}
+
+// saveCursor persists the read cursor into the oldest segment.
+func (b *DiskBuffer) saveCursor() error {
+	path := filepath.Join(b.dir, cursorFile)
+	if len(b.segments) == 0 || b.offset == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "%x %d %d\n", b.segments[0].id, b.offset, b.consumed)
+	if err == nil && b.fsync {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Len returns the number of metrics stored in the buffer.
+func (b *DiskBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count
+}
+
+// Size returns the number of bytes used on disk.
+func (b *DiskBuffer) Size() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// Append writes metrics to the end of the buffer.
+func (b *DiskBuffer) Append(metrics ...telegraf.Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.head == nil {
+		if err := b.openHead(); err != nil {
+			return err
+		}
+	}
+
+	var buf []byte
+	for _, m := range metrics {
+		buf = strconv.AppendInt(buf, int64(m.Type()), 10)
+		buf = append(buf, ' ')
+		buf = append(buf, m.Serialize()...)
+	}
+
+	n, err := b.head.Write(buf)
+	seg := b.segments[len(b.segments)-1]
+	seg.size += int64(n)
+	b.size += int64(n)
+	if err != nil {
+		return err
+	}
+	seg.count += len(metrics)
+	b.count += len(metrics)
+
+	if b.fsync {
+		if err := b.head.Sync(); err != nil {
+			return err
+		}
+	}
+
+	if seg.size >= b.segmentSize {
+		b.closeHead()
+	}
+
+	b.enforceMaxSize()
+	return nil
+}
+
+// Peek returns up to batchSize metrics from the front of the buffer without
+// removing them. Call Commit once the returned metrics have been handled.
+func (b *DiskBuffer) Peek(batchSize int) ([]telegraf.Metric, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := &cursor{offset: b.offset, consumed: b.consumed}
+	metrics := make([]telegraf.Metric, 0, batchSize)
+	for c.seg < len(b.segments) && c.lines < batchSize {
+		seg := b.segments[c.seg]
+		if c.offset >= seg.size {
+			c.seg++
+			c.offset = 0
+			c.consumed = 0
+			continue
+		}
+
+		f, err := os.Open(seg.path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Seek(c.offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		r := bufio.NewReader(f)
+		for c.offset < seg.size && c.lines < batchSize {
+			line, err := r.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				f.Close()
+				return nil, err
+			}
+			c.offset += int64(len(line))
+			c.consumed++
+			c.lines++
+
+			ms, perr := parseLine(line)
+			if perr != nil {
+				log.Printf("W! Skipping unreadable metric in buffer segment %s: %s",
+					seg.path, perr)
+			}
+			metrics = append(metrics, ms...)
+
+			if err == io.EOF {
+				break
+			}
+		}
+		f.Close()
+	}
+
+	b.pending = c
+	return metrics, nil
+}
+
+// Commit removes the metrics returned by the last call to Peek from the
+// buffer.
+func (b *DiskBuffer) Commit() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.pending
+	if c == nil {
+		return nil
+	}
+	b.pending = nil
+
+	// Every segment was read, including the one still open for writing.
+	if c.seg >= len(b.segments) && b.head != nil {
+		b.closeHead()
+	}
+
+	for i := 0; i < c.seg; i++ {
+		if err := b.removeFront(); err != nil {
+			return err
+		}
+	}
+	b.offset = c.offset
+	b.consumed = c.consumed
+	b.count -= c.lines
+
+	// Remove the final segment once it has been fully read, unless it is
+	// still open for writing.
+	if len(b.segments) > 0 && b.offset >= b.segments[0].size {
+		if len(b.segments) == 1 && b.head != nil {
+			b.closeHead()
+		}
+		if err := b.removeFront(); err != nil {
+			return err
+		}
+	}
+	return b.saveCursor()
+}
+
+// Close closes the active segment file.
+func (b *DiskBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.head == nil {
+		return nil
+	}
+	err := b.head.Close()
+	b.head = nil
+	return err
+}
+
+// enforceMaxSize drops the oldest segments until the buffer fits within
+// maxSize. The active segment is never dropped.
+func (b *DiskBuffer) enforceMaxSize() {
+	if b.maxSize <= 0 {
+		return
+	}
+	for b.size > b.maxSize && len(b.segments) > 1 {
+		seg := b.segments[0]
+		dropped := seg.count - b.consumed
+		b.count -= dropped
+		MetricsDropped.Incr(int64(dropped))
+		if err := b.removeFront(); err != nil {
+			log.Printf("E! Unable to remove buffer segment %s: %s", seg.path, err)
+			return
+		}
+		// any uncommitted Peek refers to the old segment layout.
+		b.pending = nil
+	}
+}
+
+// removeFront deletes the oldest segment and resets the read cursor.
+func (b *DiskBuffer) removeFront() error {
+	seg := b.segments[0]
+	b.segments = b.segments[1:]
+	b.size -= seg.size
+	b.offset = 0
+	b.consumed = 0
+	if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (b *DiskBuffer) openHead() error {
+	path := filepath.Join(b.dir, fmt.Sprintf("%016x%s", b.nextID, segmentExt))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	b.segments = append(b.segments, &segment{id: b.nextID, path: path})
+	b.nextID++
+	b.head = f
+	return nil
+}
+
+func (b *DiskBuffer) closeHead() {
+	if err := b.head.Close(); err != nil {
+		log.Printf("E! Unable to close buffer segment: %s", err)
+	}
+	b.head = nil
+}
+
+// parseLine parses a line written by Append, restoring the value type that
+// line protocol does not carry.
+func parseLine(line []byte) ([]telegraf.Metric, error) {
+	i := bytes.IndexByte(line, ' ')
+	if i < 0 {
+		return nil, fmt.Errorf("missing value type")
+	}
+	tp, err := strconv.Atoi(string(line[:i]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value type: %s", err)
+	}
+
+	ms, err := metric.Parse(line[i+1:])
+	if err != nil {
+		return nil, err
+	}
+	for j, m := range ms {
+		if m.Type() == telegraf.ValueType(tp) {
+			continue
+		}
+		typed, err := metric.New(m.Name(), m.Tags(), m.Fields(), m.Time(),
+			telegraf.ValueType(tp))
+		if err != nil {
+			return nil, err
+		}
+		ms[j] = typed
+	}
+	return ms, nil
+}
+
+func countLines(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var n int
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			n++
+		}
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
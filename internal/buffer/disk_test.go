@@ -0,0 +1,152 @@
+package buffer
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiskBuffer(t *testing.T, maxSize int64) (*DiskBuffer, string) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	b, err := NewDiskBuffer(dir, maxSize, FsyncNever)
+	require.NoError(t, err)
+	return b, dir
+}
+
+func metricStrings(metrics []telegraf.Metric) []string {
+	var out []string
+	for _, m := range metrics {
+		out = append(out, m.String())
+	}
+	return out
+}
+
+func TestDiskBufferAppendPeekCommit(t *testing.T) {
+	b, dir := newTestDiskBuffer(t, 0)
+	defer os.RemoveAll(dir)
+	defer b.Close()
+
+	require.NoError(t, b.Append(metricList...))
+	assert.Equal(t, 5, b.Len())
+	assert.True(t, b.Size() > 0)
+
+	batch, err := b.Peek(3)
+	require.NoError(t, err)
+	assert.Equal(t, metricStrings(metricList[:3]), metricStrings(batch))
+
+	// Peek without a Commit returns the same metrics again.
+	batch, err = b.Peek(3)
+	require.NoError(t, err)
+	assert.Equal(t, metricStrings(metricList[:3]), metricStrings(batch))
+
+	require.NoError(t, b.Commit())
+	assert.Equal(t, 2, b.Len())
+
+	batch, err = b.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, metricStrings(metricList[3:]), metricStrings(batch))
+	require.NoError(t, b.Commit())
+	assert.Equal(t, 0, b.Len())
+	assert.Equal(t, int64(0), b.Size())
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestDiskBufferReload(t *testing.T) {
+	b, dir := newTestDiskBuffer(t, 0)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, b.Append(metricList...))
+	_, err := b.Peek(2)
+	require.NoError(t, err)
+	require.NoError(t, b.Commit())
+	require.NoError(t, b.Close())
+
+	// Committed metrics are not replayed after a restart.
+	b, err = NewDiskBuffer(dir, 0, FsyncAlways)
+	require.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, 3, b.Len())
+
+	require.NoError(t, b.Append(testutil.TestMetric(1, "mymetric6")))
+	batch, err := b.Peek(10)
+	require.NoError(t, err)
+	assert.Len(t, batch, 4)
+	assert.Equal(t, metricStrings(metricList[2:]), metricStrings(batch[:3]))
+	assert.Equal(t, "mymetric6", batch[3].Name())
+}
+
+func TestDiskBufferAppendAfterCommitAll(t *testing.T) {
+	b, dir := newTestDiskBuffer(t, 0)
+	defer os.RemoveAll(dir)
+	defer b.Close()
+
+	require.NoError(t, b.Append(metricList[:2]...))
+	batch, err := b.Peek(10)
+	require.NoError(t, err)
+	assert.Len(t, batch, 2)
+	require.NoError(t, b.Commit())
+	assert.Equal(t, 0, b.Len())
+
+	require.NoError(t, b.Append(metricList[2:]...))
+	assert.Equal(t, 3, b.Len())
+	batch, err = b.Peek(10)
+	require.NoError(t, err)
+	assert.Equal(t, metricStrings(metricList[2:]), metricStrings(batch))
+}
+
+func TestDiskBufferMaxSize(t *testing.T) {
+	m := testutil.TestMetric(1, "mymetric")
+	// the serialized metric and its value type prefix
+	size := int64(len(m.Serialize()) + 2)
+
+	// each segment fits two metrics
+	b, dir := newTestDiskBuffer(t, size*8)
+	defer os.RemoveAll(dir)
+	defer b.Close()
+	MetricsDropped.Set(0)
+
+	for i := 0; i < 12; i++ {
+		require.NoError(t, b.Append(m))
+	}
+	assert.True(t, b.Size() <= size*8)
+	assert.Equal(t, 8, b.Len())
+	assert.Equal(t, int64(4), MetricsDropped.Get())
+}
+
+func TestDiskBufferValueType(t *testing.T) {
+	b, dir := newTestDiskBuffer(t, 0)
+	defer os.RemoveAll(dir)
+	defer b.Close()
+
+	m, err := metric.New("hist",
+		map[string]string{},
+		map[string]interface{}{"le_10": 2.0},
+		time.Unix(0, 0),
+		telegraf.Histogram)
+	require.NoError(t, err)
+	require.NoError(t, b.Append(m, metricList[0]))
+
+	batch, err := b.Peek(10)
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+	assert.Equal(t, telegraf.Histogram, batch[0].Type())
+	assert.Equal(t, m.String(), batch[0].String())
+	assert.Equal(t, telegraf.Untyped, batch[1].Type())
+}
+
+func TestDiskBufferInvalidFsync(t *testing.T) {
+	_, err := NewDiskBuffer(os.TempDir(), 0, "sometimes")
+	assert.Error(t, err)
+}
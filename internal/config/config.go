@@ -2,8 +2,11 @@ package config
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"math"
@@ -19,6 +22,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/outputs"
@@ -61,6 +65,9 @@ type Config struct {
 	Aggregators []*models.RunningAggregator
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors models.RunningProcessors
+
+	// fingerprints holds a digest of the configuration of each plugin
+	fingerprints map[interface{}]string
 }
 
 func NewConfig() *Config {
@@ -70,6 +77,10 @@ func NewConfig() *Config {
 			Interval:      internal.Duration{Duration: 10 * time.Second},
 			RoundInterval: true,
 			FlushInterval: internal.Duration{Duration: 10 * time.Second},
+
+			LogfileRotationMaxArchives: 5,
+
+			APIOutputFailureThreshold: internal.Duration{Duration: 5 * time.Minute},
 		},
 
 		Tags:          make(map[string]string),
@@ -78,10 +89,18 @@ func NewConfig() *Config {
 		Processors:    make([]*models.RunningProcessor, 0),
 		InputFilters:  make([]string, 0),
 		OutputFilters: make([]string, 0),
+		fingerprints:  make(map[interface{}]string),
 	}
 	return c
 }
 
+// Fingerprint returns a digest of the configuration a plugin was loaded
+// from, plugins with the same fingerprint have identical configurations.
+// plugin is one of the running plugins of the Config.
+func (c *Config) Fingerprint(plugin interface{}) string {
+	return c.fingerprints[plugin]
+}
+
 type AgentConfig struct {
 	// Interval at which to gather information
 	Interval internal.Duration
@@ -140,10 +159,38 @@ type AgentConfig struct {
 	// Logfile specifies the file to send logs to
 	Logfile string
 
+	// LogFormat is the format of the log messages: text, logfmt or json.
+	LogFormat string
+
+	// LogfileRotationInterval rotates the logfile after it has been written
+	// to for this long. Rotation by time is disabled when zero.
+	LogfileRotationInterval internal.Duration
+
+	// LogfileRotationMaxSize rotates the logfile before it grows larger than
+	// this many bytes. Rotation by size is disabled when zero.
+	LogfileRotationMaxSize int64
+
+	// LogfileRotationMaxArchives is the number of rotated logfiles to keep,
+	// -1 keeps all of them.
+	LogfileRotationMaxArchives int
+
 	// Quiet is the option for running in quiet mode
 	Quiet        bool
 	Hostname     string
 	OmitHostname bool
+
+	// ConfigURLPollInterval is the interval at which a config loaded from an
+	// http(s) URL is checked for changes, which are then reloaded. Polling is
+	// disabled when zero.
+	ConfigURLPollInterval internal.Duration
+
+	// APIListen is the address of the HTTP API reporting the health and
+	// state of the agent. The API is disabled when empty.
+	APIListen string `toml:"api_listen"`
+
+	// APIOutputFailureThreshold is how long an output may keep failing
+	// before the agent reports itself unhealthy.
+	APIOutputFailureThreshold internal.Duration `toml:"api_output_failure_threshold"`
 }
 
 // Inputs returns a list of strings of the configured inputs.
@@ -251,12 +298,35 @@ var header = `# Telegraf Configuration
   quiet = false
   ## Specify the log file name. The empty string means to log to stderr.
   logfile = ""
+  ## Format of the log messages, one of "text", "logfmt" or "json".
+  # log_format = "text"
+
+  ## Rotate the logfile after it has been written to for this long, "0s"
+  ## disables rotation by time.
+  # logfile_rotation_interval = "0s"
+  ## Rotate the logfile before it grows larger than this many bytes, 0
+  ## disables rotation by size.
+  # logfile_rotation_max_size = 0
+  ## Number of rotated logfiles to keep, -1 keeps all of them.
+  # logfile_rotation_max_archives = 5
 
   ## Override default hostname, if empty use os.Hostname()
   hostname = ""
   ## If set to true, do no set the "host" tag in the telegraf agent.
   omit_hostname = false
 
+  ## When the config is loaded from an http(s) URL, check it for changes on
+  ## this interval and reload it when it changes. "0s" disables polling.
+  # config_url_poll_interval = "0s"
+
+  ## Address of the HTTP API reporting the health of the agent on /health,
+  ## the running plugins on /plugins and the output buffers on /buffers.
+  ## The API is disabled when empty.
+  # api_listen = "localhost:8099"
+  ## /health fails once an output has kept failing for this long, or while
+  ## an input takes longer to gather than its interval.
+  # api_output_failure_threshold = "5m"
+
 
 ###############################################################################
 #                            OUTPUT PLUGINS                                   #
@@ -546,6 +616,10 @@ func getDefaultConfigPath() (string, error) {
 	if runtime.GOOS == "windows" {
 		etcfile = `C:\Program Files\Telegraf\telegraf.conf`
 	}
+	if IsURL(envfile) {
+		log.Printf("I! Using config URL: %s", envfile)
+		return envfile, nil
+	}
 	for _, path := range []string{envfile, homefile, etcfile} {
 		if _, err := os.Stat(path); err == nil {
 			log.Printf("I! Using config file: %s", path)
@@ -699,13 +773,24 @@ func escapeEnv(value string) string {
 	return envVarEscaper.Replace(value)
 }
 
-// parseFile loads a TOML configuration from a provided path and
-// returns the AST produced from the TOML parser. When loading the file, it
-// will find environment variables and replace them.
+// parseFile loads a TOML configuration from a provided path or http(s) URL
+// and returns the AST produced from the TOML parser. When loading the file,
+// it will find environment variables and replace them.
 func parseFile(fpath string) (*ast.Table, error) {
-	contents, err := ioutil.ReadFile(fpath)
-	if err != nil {
-		return nil, err
+	var contents []byte
+	var remote *remoteConfig
+	var err error
+	if IsURL(fpath) {
+		remote, err = fetchConfig(fpath)
+		if err != nil {
+			return nil, err
+		}
+		contents = remote.body
+	} else {
+		contents, err = ioutil.ReadFile(fpath)
+		if err != nil {
+			return nil, err
+		}
 	}
 	// ugh windows why
 	contents = trimBOM(contents)
@@ -719,7 +804,14 @@ func parseFile(fpath string) (*ast.Table, error) {
 		}
 	}
 
-	return toml.Parse(contents)
+	tbl, err := toml.Parse(contents)
+	if err != nil {
+		return nil, err
+	}
+	if remote != nil {
+		storeGoodConfig(fpath, remote)
+	}
+	return tbl, nil
 }
 
 func (c *Config) addAggregator(name string, table *ast.Table) error {
@@ -728,6 +820,7 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested aggregator: %s", name)
 	}
 	aggregator := creator()
+	id := fingerprint(name, table)
 
 	conf, err := buildAggregator(name, table)
 	if err != nil {
@@ -737,8 +830,13 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 	if err := toml.UnmarshalTable(table, aggregator); err != nil {
 		return err
 	}
+	if err := setLogger(aggregator, "aggregators."+name, "", ""); err != nil {
+		return err
+	}
 
-	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
+	ra := models.NewRunningAggregator(aggregator, conf)
+	c.fingerprints[ra] = id
+	c.Aggregators = append(c.Aggregators, ra)
 	return nil
 }
 
@@ -748,6 +846,7 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested processor: %s", name)
 	}
 	processor := creator()
+	id := fingerprint(name, table)
 
 	processorConfig, err := buildProcessor(name, table)
 	if err != nil {
@@ -757,6 +856,14 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 	if err := toml.UnmarshalTable(table, processor); err != nil {
 		return err
 	}
+	if err := setLogger(processor, "processors."+name, "", ""); err != nil {
+		return err
+	}
+	if i, ok := processor.(telegraf.Initializer); ok {
+		if err := i.Init(); err != nil {
+			return fmt.Errorf("processors.%s: %s", name, err)
+		}
+	}
 
 	rf := &models.RunningProcessor{
 		Name:      name,
@@ -764,6 +871,7 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		Config:    processorConfig,
 	}
 
+	c.fingerprints[rf] = id
 	c.Processors = append(c.Processors, rf)
 	return nil
 }
@@ -777,6 +885,7 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested output: %s", name)
 	}
 	output := creator()
+	id := fingerprint(name, table)
 
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
@@ -797,9 +906,14 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 	if err := toml.UnmarshalTable(table, output); err != nil {
 		return err
 	}
+	err = setLogger(output, "outputs."+name, outputConfig.Alias, "")
+	if err != nil {
+		return err
+	}
 
 	ro := models.NewRunningOutput(name, output, outputConfig,
 		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
+	c.fingerprints[ro] = id
 	c.Outputs = append(c.Outputs, ro)
 	return nil
 }
@@ -818,6 +932,7 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested input: %s", name)
 	}
 	input := creator()
+	id := fingerprint(name, table)
 
 	// If the input has a SetParser function, then this means it can accept
 	// arbitrary types of input, so build the parser and set it.
@@ -838,12 +953,66 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 	if err := toml.UnmarshalTable(table, input); err != nil {
 		return err
 	}
+	err = setLogger(input, "inputs."+name,
+		pluginConfig.Alias, pluginConfig.LogLevel)
+	if err != nil {
+		return err
+	}
 
 	rp := models.NewRunningInput(input, pluginConfig)
+	c.fingerprints[rp] = id
 	c.Inputs = append(c.Inputs, rp)
 	return nil
 }
 
+// setLogger gives the plugin a logger tagged with its name and alias, if it
+// has a Log field. level overrides the agent log level unless empty.
+func setLogger(plugin interface{}, name, alias, level string) error {
+	l, err := logger.New(name, alias, level)
+	if err != nil {
+		return fmt.Errorf("%s: %s", name, err)
+	}
+	models.SetLoggerOnPlugin(plugin, l)
+	return nil
+}
+
+// fingerprint returns a digest of a plugin's name and table. It must be
+// computed before the table is consumed by the build functions, and is used
+// to tell which plugins changed when the configuration is reloaded.
+func fingerprint(name string, tbl *ast.Table) string {
+	h := sha256.New()
+	io.WriteString(h, name)
+	writeTable(h, tbl)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeTable writes the fields of tbl to w in a stable order.
+func writeTable(w io.Writer, tbl *ast.Table) {
+	keys := make([]string, 0, len(tbl.Fields))
+	for key := range tbl.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(w, "\n%s=", key)
+		switch v := tbl.Fields[key].(type) {
+		case *ast.KeyValue:
+			io.WriteString(w, v.Value.Source())
+		case *ast.Table:
+			io.WriteString(w, "{")
+			writeTable(w, v)
+			io.WriteString(w, "}")
+		case []*ast.Table:
+			for _, t := range v {
+				io.WriteString(w, "[")
+				writeTable(w, t)
+				io.WriteString(w, "]")
+			}
+		}
+	}
+}
+
 // buildAggregator parses Aggregator specific items from the ast.Table,
 // builds the filter and returns a
 // models.AggregatorConfig to be inserted into models.RunningAggregator
@@ -933,6 +1102,8 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 		}
 	}
 
+	conf.Pipelines = buildPipelines(tbl)
+
 	delete(tbl.Fields, "period")
 	delete(tbl.Fields, "delay")
 	delete(tbl.Fields, "drop_original")
@@ -973,6 +1144,8 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 		}
 	}
 
+	conf.Pipelines = buildPipelines(tbl)
+
 	delete(tbl.Fields, "order")
 	var err error
 	conf.Filter, err = buildFilter(tbl)
@@ -982,6 +1155,25 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 	return conf, nil
 }
 
+// buildPipelines returns the pipelines a processor, aggregator or output takes
+// part in, and removes them from the table.
+func buildPipelines(tbl *ast.Table) []string {
+	var pipelines []string
+	if node, ok := tbl.Fields["pipelines"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						pipelines = append(pipelines, str.Value)
+					}
+				}
+			}
+		}
+	}
+	delete(tbl.Fields, "pipelines")
+	return pipelines
+}
+
 // buildFilter builds a Filter
 // (tagpass/tagdrop/namepass/namedrop/fieldpass/fielddrop) to
 // be inserted into the models.OutputConfig/models.InputConfig
@@ -1170,11 +1362,38 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["pipeline"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.Pipeline = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["alias"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.Alias = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.LogLevel = str.Value
+			}
+		}
+	}
+
 	delete(tbl.Fields, "name_prefix")
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "interval")
 	delete(tbl.Fields, "tags")
+	delete(tbl.Fields, "pipeline")
+	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	var err error
 	cp.Filter, err = buildFilter(tbl)
 	if err != nil {
@@ -1236,6 +1455,50 @@ func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["json_string_fields"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.JSONStringFields = append(c.JSONStringFields, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_name_key"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONNameKey = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_query"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONQuery = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_time_key"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONTimeKey = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_time_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONTimeFormat = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["data_type"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1313,12 +1576,169 @@ func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["csv_header_row_count"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := strconv.Atoi(integer.Value)
+				if err != nil {
+					return nil, err
+				}
+				c.CSVHeaderRowCount = v
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_skip_rows"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := strconv.Atoi(integer.Value)
+				if err != nil {
+					return nil, err
+				}
+				c.CSVSkipRows = v
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_delimiter"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVDelimiter = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_comment"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVComment = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_trim_space"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.CSVTrimSpace, err = strconv.ParseBool(b.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_column_names"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.CSVColumnNames = append(c.CSVColumnNames, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_column_types"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.CSVColumnTypes = append(c.CSVColumnTypes, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_tag_columns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.CSVTagColumns = append(c.CSVTagColumns, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_measurement_column"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVMeasurementColumn = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_timestamp_column"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVTimestampColumn = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_patterns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.GrokPatterns = append(c.GrokPatterns, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_custom_patterns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.GrokCustomPatterns = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_custom_pattern_files"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.GrokCustomPatternFiles = append(c.GrokCustomPatternFiles, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_timezone"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.GrokTimezone = str.Value
+			}
+		}
+	}
+
 	c.MetricName = name
 
 	delete(tbl.Fields, "data_format")
 	delete(tbl.Fields, "separator")
 	delete(tbl.Fields, "templates")
 	delete(tbl.Fields, "tag_keys")
+	delete(tbl.Fields, "json_string_fields")
+	delete(tbl.Fields, "json_name_key")
+	delete(tbl.Fields, "json_query")
+	delete(tbl.Fields, "json_time_key")
+	delete(tbl.Fields, "json_time_format")
 	delete(tbl.Fields, "data_type")
 	delete(tbl.Fields, "collectd_auth_file")
 	delete(tbl.Fields, "collectd_security_level")
@@ -1328,6 +1748,21 @@ func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
 	delete(tbl.Fields, "dropwizard_time_format")
 	delete(tbl.Fields, "dropwizard_tags_path")
 	delete(tbl.Fields, "dropwizard_tag_paths")
+	delete(tbl.Fields, "csv_header_row_count")
+	delete(tbl.Fields, "csv_skip_rows")
+	delete(tbl.Fields, "csv_delimiter")
+	delete(tbl.Fields, "csv_comment")
+	delete(tbl.Fields, "csv_trim_space")
+	delete(tbl.Fields, "csv_column_names")
+	delete(tbl.Fields, "csv_column_types")
+	delete(tbl.Fields, "csv_tag_columns")
+	delete(tbl.Fields, "csv_measurement_column")
+	delete(tbl.Fields, "csv_timestamp_column")
+	delete(tbl.Fields, "csv_timestamp_format")
+	delete(tbl.Fields, "grok_patterns")
+	delete(tbl.Fields, "grok_custom_patterns")
+	delete(tbl.Fields, "grok_custom_pattern_files")
+	delete(tbl.Fields, "grok_timezone")
 
 	return parsers.NewParser(c)
 }
@@ -1336,7 +1771,9 @@ func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
 // a serializers.Serializer object, and creates it, which can then be added onto
 // an Output object.
 func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error) {
-	c := &serializers.Config{TimestampUnits: time.Duration(1 * time.Second)}
+	c := &serializers.Config{
+		TimestampUnits: time.Duration(1 * time.Second),
+	}
 
 	if node, ok := tbl.Fields["data_format"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
@@ -1366,10 +1803,38 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["templates"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.Templates = append(c.Templates, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["graphite_tag_support"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.GraphiteTagSupport, err = strconv.ParseBool(b.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["json_timestamp_units"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
 				timestampVal, err := time.ParseDuration(str.Value)
+				if err != nil {
+					// allow the units without a number, such as "ms"
+					timestampVal, err = time.ParseDuration("1" + str.Value)
+				}
 				if err != nil {
 					return nil, fmt.Errorf("Unable to parse json_timestamp_units as a duration, %s", err)
 				}
@@ -1382,10 +1847,73 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["json_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_nested"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				nested, err := strconv.ParseBool(b.Value)
+				if err != nil {
+					return nil, err
+				}
+				c.JSONFlat = !nested
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["prometheus_export_timestamp"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.PrometheusExportTimestamp, err = strconv.ParseBool(b.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["wavefront_use_strict"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.WavefrontUseStrict, err = strconv.ParseBool(b.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["wavefront_source_override"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.WavefrontSourceOverride = append(c.WavefrontSourceOverride, str.Value)
+					}
+				}
+			}
+		}
+	}
+
 	delete(tbl.Fields, "data_format")
 	delete(tbl.Fields, "prefix")
 	delete(tbl.Fields, "template")
+	delete(tbl.Fields, "templates")
+	delete(tbl.Fields, "graphite_tag_support")
 	delete(tbl.Fields, "json_timestamp_units")
+	delete(tbl.Fields, "json_timestamp_format")
+	delete(tbl.Fields, "json_nested")
+	delete(tbl.Fields, "prometheus_export_timestamp")
+	delete(tbl.Fields, "wavefront_use_strict")
+	delete(tbl.Fields, "wavefront_source_override")
 	return serializers.NewSerializer(c)
 }
 
@@ -1409,5 +1937,140 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 	if len(oc.Filter.FieldPass) > 0 {
 		oc.Filter.NamePass = oc.Filter.FieldPass
 	}
+
+	if node, ok := tbl.Fields["buffer_directory"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.BufferDirectory = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["buffer_max_size"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				oc.BufferMaxSize, err = strconv.ParseInt(integer.Value, 10, 64)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["alias"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.Alias = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["dead_letter"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.DeadLetter = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["buffer_fsync"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.BufferFsync = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["flush_interval"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.FlushInterval, err = time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["flush_jitter"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.FlushJitter, err = time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["write_workers"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				oc.WriteWorkers, err = strconv.Atoi(integer.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["retry_initial_backoff"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.RetryInitialBackoff, err = time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["retry_max_backoff"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.RetryMaxBackoff, err = time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["retry_jitter"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.RetryJitter, err = time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["retry_max_retries"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				oc.RetryMaxRetries, err = strconv.Atoi(integer.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	oc.Pipelines = buildPipelines(tbl)
+
+	delete(tbl.Fields, "buffer_directory")
+	delete(tbl.Fields, "buffer_max_size")
+	delete(tbl.Fields, "buffer_fsync")
+	delete(tbl.Fields, "flush_interval")
+	delete(tbl.Fields, "flush_jitter")
+	delete(tbl.Fields, "write_workers")
+	delete(tbl.Fields, "retry_initial_backoff")
+	delete(tbl.Fields, "retry_max_backoff")
+	delete(tbl.Fields, "retry_jitter")
+	delete(tbl.Fields, "retry_max_retries")
+	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "dead_letter")
 	return oc, nil
 }
@@ -174,3 +174,23 @@ func TestConfig_LoadDirectory(t *testing.T) {
 	assert.Equal(t, pConfig, c.Inputs[3].Config,
 		"Merged Testdata did not produce correct procstat metadata.")
 }
+
+func TestConfig_Fingerprint(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/single_plugin.toml")
+	assert.NoError(t, err)
+	err = c.LoadDirectory("./testdata/subconfig")
+	assert.NoError(t, err)
+
+	reloaded := NewConfig()
+	err = reloaded.LoadConfig("./testdata/single_plugin.toml")
+	assert.NoError(t, err)
+
+	assert.NotEmpty(t, c.Fingerprint(c.Inputs[0]))
+	assert.Equal(t, c.Fingerprint(c.Inputs[0]),
+		reloaded.Fingerprint(reloaded.Inputs[0]))
+	assert.NotEqual(t, c.Fingerprint(c.Inputs[0]), c.Fingerprint(c.Inputs[1]))
+	// memcached with a different server
+	assert.NotEqual(t, c.Fingerprint(c.Inputs[0]), c.Fingerprint(c.Inputs[2]))
+	assert.Empty(t, c.Fingerprint(reloaded.Inputs[0]))
+}
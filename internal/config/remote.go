@@ -0,0 +1,157 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// Environment variables used when fetching a config over HTTP.
+const (
+	// ConfigTokenEnv holds a bearer token sent with the request.
+	ConfigTokenEnv = "TELEGRAF_CONFIG_TOKEN"
+	// ConfigTLSCAEnv holds the path of a CA file to verify the server with.
+	ConfigTLSCAEnv = "TELEGRAF_CONFIG_TLS_CA"
+	// ConfigTLSCertEnv and ConfigTLSKeyEnv hold the paths of a client
+	// certificate and key.
+	ConfigTLSCertEnv = "TELEGRAF_CONFIG_TLS_CERT"
+	ConfigTLSKeyEnv  = "TELEGRAF_CONFIG_TLS_KEY"
+	// ConfigInsecureSkipVerifyEnv disables verification of the server
+	// certificate when set to "true".
+	ConfigInsecureSkipVerifyEnv = "TELEGRAF_CONFIG_INSECURE_SKIP_VERIFY"
+)
+
+const remoteConfigTimeout = 30 * time.Second
+
+// remoteConfig is a copy of a config document fetched over HTTP.
+type remoteConfig struct {
+	body         []byte
+	etag         string
+	lastModified string
+}
+
+var (
+	remoteMu sync.Mutex
+	// remoteConfigs holds the last good copy of each remote config.
+	remoteConfigs = make(map[string]*remoteConfig)
+)
+
+// IsURL returns true if the config path is an http(s) URL.
+func IsURL(path string) bool {
+	return strings.HasPrefix(path, "http://") ||
+		strings.HasPrefix(path, "https://")
+}
+
+func lastGoodConfig(url string) *remoteConfig {
+	remoteMu.Lock()
+	defer remoteMu.Unlock()
+	return remoteConfigs[url]
+}
+
+func storeGoodConfig(url string, rc *remoteConfig) {
+	remoteMu.Lock()
+	defer remoteMu.Unlock()
+	remoteConfigs[url] = rc
+}
+
+// fetchConfig fetches the config document at url. If the request fails the
+// last good copy is returned instead, when there is one.
+func fetchConfig(url string) (*remoteConfig, error) {
+	cached := lastGoodConfig(url)
+	rc, err := fetchRemoteConfig(url, cached)
+	if err != nil {
+		if cached == nil {
+			return nil, err
+		}
+		log.Printf("W! Unable to fetch config from %s, using the last good "+
+			"copy: %s\n", url, err)
+		return cached, nil
+	}
+	return rc, nil
+}
+
+// RemoteConfigChanged fetches the config document at url and reports whether
+// it differs from the last good copy.
+func RemoteConfigChanged(url string) (bool, error) {
+	cached := lastGoodConfig(url)
+	rc, err := fetchRemoteConfig(url, cached)
+	if err != nil {
+		return false, err
+	}
+	if cached != nil && bytes.Equal(cached.body, rc.body) {
+		// keep the validators of the latest response.
+		storeGoodConfig(url, rc)
+		return false, nil
+	}
+	return true, nil
+}
+
+// fetchRemoteConfig requests the config document at url, using the
+// validators of cached to make a conditional request.
+func fetchRemoteConfig(url string, cached *remoteConfig) (*remoteConfig, error) {
+	tlsCfg, err := internal.GetTLSConfig(
+		os.Getenv(ConfigTLSCertEnv),
+		os.Getenv(ConfigTLSKeyEnv),
+		os.Getenv(ConfigTLSCAEnv),
+		os.Getenv(ConfigInsecureSkipVerifyEnv) == "true",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: remoteConfigTimeout,
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv(ConfigTokenEnv); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if cached != nil {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("fetching config from %s returned status %s",
+			url, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &remoteConfig{
+		body:         body,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_LoadURL(t *testing.T) {
+	os.Setenv(ConfigTokenEnv, "secret")
+	defer os.Unsetenv(ConfigTokenEnv)
+
+	var requests, notModified int
+	body := "[global_tags]\n  dc = \"us-east-1\"\n"
+	fail := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		etag := fmt.Sprintf("%q", body)
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, body)
+	}))
+	defer ts.Close()
+
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig(ts.URL))
+	assert.Equal(t, map[string]string{"dc": "us-east-1"}, c.Tags)
+
+	changed, err := RemoteConfigChanged(ts.URL)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, 1, notModified)
+
+	body = "[global_tags]\n  dc = \"us-west-1\"\n"
+	changed, err = RemoteConfigChanged(ts.URL)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	c = NewConfig()
+	require.NoError(t, c.LoadConfig(ts.URL))
+	assert.Equal(t, map[string]string{"dc": "us-west-1"}, c.Tags)
+
+	// The last good copy is used when the fetch fails.
+	fail = true
+	c = NewConfig()
+	require.NoError(t, c.LoadConfig(ts.URL))
+	assert.Equal(t, map[string]string{"dc": "us-west-1"}, c.Tags)
+
+	_, err = RemoteConfigChanged(ts.URL)
+	assert.Error(t, err)
+	assert.Equal(t, 6, requests)
+}
+
+func TestConfig_LoadURLError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	c := NewConfig()
+	assert.Error(t, c.LoadConfig(ts.URL))
+}
+
+func TestConfig_LoadURLPollInterval(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "[agent]\n  config_url_poll_interval = \"5m\"\n")
+	}))
+	defer ts.Close()
+
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig(ts.URL))
+	assert.Equal(t, 5*time.Minute, c.Agent.ConfigURLPollInterval.Duration)
+}
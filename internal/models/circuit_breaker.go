@@ -0,0 +1,147 @@
+package models
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// States of the circuit breaker of an output, as reported by the state field
+// of the write measurement.
+const (
+	// CircuitClosed is the normal state, batches are written as they come.
+	CircuitClosed = iota
+	// CircuitOpen means writes are paused until the backoff expires.
+	CircuitOpen
+	// CircuitHalfOpen means a single batch is being written to find out if
+	// the output has recovered.
+	CircuitHalfOpen
+)
+
+// DEFAULT_RETRY_MAX_BACKOFF is the upper bound of the backoff when
+// retry_initial_backoff is set without retry_max_backoff.
+const DEFAULT_RETRY_MAX_BACKOFF = 5 * time.Minute
+
+// circuitBreaker pauses writes to an output after a failure, backing off
+// exponentially between attempts.
+type circuitBreaker struct {
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	jitter         time.Duration
+
+	state    int
+	failures int
+	// attempts counts the failures since the last success or dropped batch.
+	attempts int
+	backoff  time.Duration
+	retryAt  time.Time
+	// failingSince is the time of the first failure since the last success.
+	failingSince time.Time
+
+	now func() time.Time
+	sync.Mutex
+}
+
+func newCircuitBreaker(initial, max, jitter time.Duration) *circuitBreaker {
+	if max == 0 {
+		max = DEFAULT_RETRY_MAX_BACKOFF
+	}
+	if max < initial {
+		max = initial
+	}
+	return &circuitBreaker{
+		initialBackoff: initial,
+		maxBackoff:     max,
+		jitter:         jitter,
+		now:            time.Now,
+	}
+}
+
+// Ready returns true if a write may be attempted.
+func (cb *circuitBreaker) Ready() bool {
+	cb.Lock()
+	defer cb.Unlock()
+	return cb.state != CircuitOpen || !cb.now().Before(cb.retryAt)
+}
+
+// Allow returns true if a batch may be written now. Once the backoff expires
+// a single caller is let through to probe the output, others are refused
+// until it reports back.
+func (cb *circuitBreaker) Allow() bool {
+	cb.Lock()
+	defer cb.Unlock()
+	switch cb.state {
+	case CircuitOpen:
+		if cb.now().Before(cb.retryAt) {
+			return false
+		}
+		cb.state = CircuitHalfOpen
+		return true
+	case CircuitHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// Success closes the circuit and resets the backoff.
+func (cb *circuitBreaker) Success() {
+	cb.Lock()
+	defer cb.Unlock()
+	cb.state = CircuitClosed
+	cb.failures = 0
+	cb.attempts = 0
+	cb.backoff = 0
+	cb.failingSince = time.Time{}
+}
+
+// ResetAttempts restarts the count of failed attempts, once the batch that
+// kept failing has been dropped.
+func (cb *circuitBreaker) ResetAttempts() {
+	cb.Lock()
+	defer cb.Unlock()
+	cb.attempts = 0
+}
+
+// Failure opens the circuit, doubling the backoff up to the maximum. It
+// returns the number of failed attempts of the current batch.
+func (cb *circuitBreaker) Failure() int {
+	cb.Lock()
+	defer cb.Unlock()
+	cb.failures++
+	cb.attempts++
+	if cb.failures == 1 {
+		cb.failingSince = cb.now()
+	}
+	if cb.backoff == 0 {
+		cb.backoff = cb.initialBackoff
+	} else {
+		cb.backoff *= 2
+	}
+	if cb.backoff > cb.maxBackoff {
+		cb.backoff = cb.maxBackoff
+	}
+
+	wait := cb.backoff
+	if cb.jitter > 0 {
+		wait += time.Duration(rand.Int63n(int64(cb.jitter)))
+	}
+	cb.retryAt = cb.now().Add(wait)
+	cb.state = CircuitOpen
+	return cb.attempts
+}
+
+// State returns the current state and the number of consecutive failures.
+func (cb *circuitBreaker) State() (int, int) {
+	cb.Lock()
+	defer cb.Unlock()
+	return cb.state, cb.failures
+}
+
+// FailingSince returns the time of the first failure since the last success,
+// or the zero time if the last write succeeded.
+func (cb *circuitBreaker) FailingSince() time.Time {
+	cb.Lock()
+	defer cb.Unlock()
+	return cb.failingSince
+}
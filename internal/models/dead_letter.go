@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Reasons for passing a metric to a dead letter output, set as the
+// dead_letter_reason tag.
+const (
+	// DeadLetterBufferFull is used for metrics evicted from a full buffer.
+	DeadLetterBufferFull = "buffer_full"
+	// DeadLetterRejected is used for batches the output refused as invalid.
+	DeadLetterRejected = "rejected"
+	// DeadLetterRetriesExhausted is used for batches that failed more than
+	// retry_max_retries times.
+	DeadLetterRetriesExhausted = "retries_exhausted"
+)
+
+// LinkDeadLetters points each output at the output named by its dead_letter
+// option, and marks the outputs used that way as dead letter sinks.
+func LinkDeadLetters(outputs []*RunningOutput) error {
+	sinks := make(map[*RunningOutput]bool)
+	for _, o := range outputs {
+		if o.Config.DeadLetter == "" {
+			o.SetDeadLetter(nil)
+			continue
+		}
+
+		var target *RunningOutput
+		for _, dl := range outputs {
+			if dl == o ||
+				(dl.Config.Alias != o.Config.DeadLetter && dl.Name != o.Config.DeadLetter) {
+				continue
+			}
+			if target != nil {
+				return fmt.Errorf("output %s: dead_letter %q matches more than "+
+					"one output, set an alias to tell them apart",
+					o.LogName(), o.Config.DeadLetter)
+			}
+			target = dl
+		}
+
+		if target == nil {
+			return fmt.Errorf("output %s: dead_letter output %q not found",
+				o.LogName(), o.Config.DeadLetter)
+		}
+		if target.Config.DeadLetter != "" {
+			return fmt.Errorf("output %s: dead_letter output %s cannot have a "+
+				"dead_letter of its own", o.LogName(), target.LogName())
+		}
+		o.SetDeadLetter(target)
+		sinks[target] = true
+	}
+
+	for _, o := range outputs {
+		o.DeadLetterSink = sinks[o]
+	}
+	return nil
+}
+
+// LogName returns the name of the output, including its alias if set.
+func (ro *RunningOutput) LogName() string {
+	if ro.Config.Alias == "" {
+		return ro.Name
+	}
+	return ro.Name + "::" + ro.Config.Alias
+}
+
+// SetDeadLetter sets the output receiving the metrics dropped by ro.
+func (ro *RunningOutput) SetDeadLetter(dl *RunningOutput) {
+	ro.deadLetterMu.Lock()
+	defer ro.deadLetterMu.Unlock()
+	ro.deadLetter = dl
+}
+
+// sendDeadLetter passes a copy of a dropped metric to the dead letter output,
+// tagged with the reason it was dropped and the output that dropped it. The
+// copy is not tracked, the caller remains responsible for m.
+func (ro *RunningOutput) sendDeadLetter(m telegraf.Metric, reason string, err error) {
+	ro.deadLetterMu.Lock()
+	dl := ro.deadLetter
+	ro.deadLetterMu.Unlock()
+	if dl == nil {
+		return
+	}
+
+	tags := m.Tags()
+	tags["dead_letter_reason"] = reason
+	tags["dead_letter_output"] = ro.LogName()
+	fields := m.Fields()
+	if err != nil {
+		fields["dead_letter_error"] = err.Error()
+	}
+
+	dm, merr := metric.New(m.Name(), tags, fields, m.Time(), m.Type())
+	if merr != nil {
+		log.Printf("E! Output [%s] unable to create dead letter metric: %s\n",
+			ro.Name, merr)
+		return
+	}
+	dl.AddMetric(dm)
+}
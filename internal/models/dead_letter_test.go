@@ -0,0 +1,116 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDeadLetterOutput(name, alias, deadLetter string) *RunningOutput {
+	conf := &OutputConfig{
+		Name:       name,
+		Alias:      alias,
+		DeadLetter: deadLetter,
+	}
+	return NewRunningOutput(name, &mockOutput{}, conf, 5, 5)
+}
+
+func TestLinkDeadLetters(t *testing.T) {
+	influx := newDeadLetterOutput("influxdb", "", "dlq")
+	file := newDeadLetterOutput("file", "", "")
+	dlq := newDeadLetterOutput("file", "dlq", "")
+	require.NoError(t, LinkDeadLetters([]*RunningOutput{influx, file, dlq}))
+	assert.Equal(t, dlq, influx.deadLetter)
+	assert.True(t, dlq.DeadLetterSink)
+	assert.False(t, file.DeadLetterSink)
+	assert.False(t, influx.DeadLetterSink)
+
+	// relinking clears outputs no longer used as dead letter outputs
+	influx.Config.DeadLetter = ""
+	require.NoError(t, LinkDeadLetters([]*RunningOutput{influx, file, dlq}))
+	assert.Nil(t, influx.deadLetter)
+	assert.False(t, dlq.DeadLetterSink)
+}
+
+func TestLinkDeadLettersErrors(t *testing.T) {
+	var tests = []struct {
+		name    string
+		outputs []*RunningOutput
+	}{
+		{
+			name: "not found",
+			outputs: []*RunningOutput{
+				newDeadLetterOutput("influxdb", "", "file"),
+			},
+		},
+		{
+			name: "ambiguous",
+			outputs: []*RunningOutput{
+				newDeadLetterOutput("influxdb", "", "file"),
+				newDeadLetterOutput("file", "", ""),
+				newDeadLetterOutput("file", "dlq", ""),
+			},
+		},
+		{
+			name: "chained",
+			outputs: []*RunningOutput{
+				newDeadLetterOutput("influxdb", "", "file"),
+				newDeadLetterOutput("file", "", "kafka"),
+				newDeadLetterOutput("kafka", "", ""),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, LinkDeadLetters(tt.outputs))
+		})
+	}
+}
+
+// Verify that rejected batches are passed on to the dead letter output.
+func TestDeadLetterRejected(t *testing.T) {
+	m := &classifyingOutput{}
+	m.failWrite = true
+	m.permanent = true
+	ro := NewRunningOutput("influxdb", m, &OutputConfig{DeadLetter: "file"}, 5, 100)
+	dlm := &mockOutput{}
+	dl := NewRunningOutput("file", dlm, &OutputConfig{}, 5, 100)
+	require.NoError(t, LinkDeadLetters([]*RunningOutput{ro, dl}))
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	require.NoError(t, dl.Write())
+
+	require.Len(t, dlm.Metrics(), 5)
+	for i, metric := range dlm.Metrics() {
+		assert.Equal(t, first5[i].Name(), metric.Name())
+		assert.Equal(t, DeadLetterRejected, metric.Tags()["dead_letter_reason"])
+		assert.Equal(t, "influxdb", metric.Tags()["dead_letter_output"])
+		assert.Equal(t, "Failed Write!", metric.Fields()["dead_letter_error"])
+	}
+}
+
+// Verify that metrics evicted from a full buffer are passed on to the dead
+// letter output.
+func TestDeadLetterBufferFull(t *testing.T) {
+	ro := NewRunningOutput("influxdb", &mockOutput{}, &OutputConfig{DeadLetter: "file"}, 5, 5)
+	dlm := &mockOutput{}
+	dl := NewRunningOutput("file", dlm, &OutputConfig{}, 5, 100)
+	require.NoError(t, LinkDeadLetters([]*RunningOutput{ro, dl}))
+
+	for _, metric := range append(first5, next5[:2]...) {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, dl.Write())
+
+	require.Len(t, dlm.Metrics(), 2)
+	for i, metric := range dlm.Metrics() {
+		assert.Equal(t, first5[i].Name(), metric.Name())
+		assert.Equal(t, DeadLetterBufferFull, metric.Tags()["dead_letter_reason"])
+		assert.NotContains(t, metric.Fields(), "dead_letter_error")
+	}
+}
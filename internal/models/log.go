@@ -0,0 +1,23 @@
+package models
+
+import (
+	"reflect"
+
+	"github.com/influxdata/telegraf"
+)
+
+var loggerType = reflect.TypeOf((*telegraf.Logger)(nil)).Elem()
+
+// SetLoggerOnPlugin sets the Log field of the plugin, if it has an exported
+// field of that name with the telegraf.Logger type.
+func SetLoggerOnPlugin(plugin interface{}, l telegraf.Logger) {
+	v := reflect.ValueOf(plugin)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	field := v.Elem().FieldByName("Log")
+	if !field.IsValid() || !field.CanSet() || field.Type() != loggerType {
+		return
+	}
+	field.Set(reflect.ValueOf(l))
+}
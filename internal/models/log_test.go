@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type loggingPlugin struct {
+	Log telegraf.Logger `toml:"-"`
+}
+
+type otherLogPlugin struct {
+	Log string
+}
+
+func TestSetLoggerOnPlugin(t *testing.T) {
+	l := testutil.Logger{Name: "inputs.test"}
+
+	p := &loggingPlugin{}
+	SetLoggerOnPlugin(p, l)
+	assert.Equal(t, l, p.Log)
+
+	// plugins without a telegraf.Logger field are left alone
+	o := &otherLogPlugin{Log: "unchanged"}
+	SetLoggerOnPlugin(o, l)
+	assert.Equal(t, "unchanged", o.Log)
+	SetLoggerOnPlugin(&mockOutput{}, l)
+}
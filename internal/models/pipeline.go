@@ -0,0 +1,54 @@
+package models
+
+// DefaultPipeline is the pipeline of plugins that do not name one.
+const DefaultPipeline = "default"
+
+// inPipeline returns true if pipeline is one of pipelines, an empty list
+// meaning the default pipeline only.
+func inPipeline(pipelines []string, pipeline string) bool {
+	if pipeline == "" {
+		pipeline = DefaultPipeline
+	}
+	if len(pipelines) == 0 {
+		return pipeline == DefaultPipeline
+	}
+	for _, p := range pipelines {
+		if p == pipeline {
+			return true
+		}
+	}
+	return false
+}
+
+// Pipeline returns the pipeline the metrics of the input are sent to.
+func (r *RunningInput) Pipeline() string {
+	if r.Config.Pipeline == "" {
+		return DefaultPipeline
+	}
+	return r.Config.Pipeline
+}
+
+// Pipeline returns the pipeline the metrics of the aggregator are sent to,
+// the first of the pipelines it takes part in.
+func (r *RunningAggregator) Pipeline() string {
+	if len(r.Config.Pipelines) == 0 {
+		return DefaultPipeline
+	}
+	return r.Config.Pipelines[0]
+}
+
+// InPipeline returns true if the aggregator receives the metrics of pipeline.
+func (r *RunningAggregator) InPipeline(pipeline string) bool {
+	return inPipeline(r.Config.Pipelines, pipeline)
+}
+
+// InPipeline returns true if the processor applies to the metrics of
+// pipeline.
+func (rp *RunningProcessor) InPipeline(pipeline string) bool {
+	return inPipeline(rp.Config.Pipelines, pipeline)
+}
+
+// InPipeline returns true if the output receives the metrics of pipeline.
+func (ro *RunningOutput) InPipeline(pipeline string) bool {
+	return inPipeline(ro.Config.Pipelines, pipeline)
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelines(t *testing.T) {
+	input := &RunningInput{Config: &InputConfig{}}
+	assert.Equal(t, DefaultPipeline, input.Pipeline())
+	input.Config.Pipeline = "security"
+	assert.Equal(t, "security", input.Pipeline())
+
+	// plugins without pipelines only take part in the default one
+	rp := &RunningProcessor{Config: &ProcessorConfig{}}
+	assert.True(t, rp.InPipeline(DefaultPipeline))
+	assert.False(t, rp.InPipeline("security"))
+
+	agg := &RunningAggregator{Config: &AggregatorConfig{
+		Pipelines: []string{"security", DefaultPipeline},
+	}}
+	assert.True(t, agg.InPipeline(DefaultPipeline))
+	assert.True(t, agg.InPipeline("security"))
+	assert.False(t, agg.InPipeline("audit"))
+	assert.Equal(t, "security", agg.Pipeline())
+}
@@ -42,6 +42,9 @@ type AggregatorConfig struct {
 
 	Period time.Duration
 	Delay  time.Duration
+
+	// Pipelines the aggregator takes part in.
+	Pipelines []string
 }
 
 func (r *RunningAggregator) Name() string {
@@ -81,6 +84,10 @@ func (r *RunningAggregator) MakeMetric(
 // Before applying to the plugin, it will run any defined filters on the metric.
 // Apply returns true if the original metric should be dropped.
 func (r *RunningAggregator) Add(in telegraf.Metric) bool {
+	// The aggregator only keeps the values of the metric, so it is done
+	// with regard to delivery tracking.
+	defer in.Drop()
+
 	if r.Config.Filter.IsActive() {
 		// check if the aggregator should apply this metric
 		name := in.Name()
@@ -18,6 +18,8 @@ type RunningInput struct {
 	defaultTags map[string]string
 
 	MetricsGathered selfstat.Stat
+
+	status inputStatus
 }
 
 func NewRunningInput(
@@ -44,6 +46,12 @@ type InputConfig struct {
 	Tags              map[string]string
 	Filter            Filter
 	Interval          time.Duration
+	// Pipeline is the pipeline the metrics of the input are sent to.
+	Pipeline string
+	// Alias tells apart the log messages of inputs with the same name.
+	Alias string
+	// LogLevel overrides the agent log level for the input.
+	LogLevel string
 }
 
 func (r *RunningInput) Name() string {
@@ -1,13 +1,14 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/buffer"
-	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -19,6 +20,10 @@ const (
 	DEFAULT_METRIC_BUFFER_LIMIT = 10000
 )
 
+// errBackoff is returned by write while the circuit breaker holds off
+// writes to the output.
+var errBackoff = errors.New("output is backing off after failed writes")
+
 // RunningOutput contains the output configuration
 type RunningOutput struct {
 	Name              string
@@ -32,9 +37,43 @@ type RunningOutput struct {
 	BufferSize      selfstat.Stat
 	BufferLimit     selfstat.Stat
 	WriteTime       selfstat.Stat
+	MetricsDropped  selfstat.Stat
+
+	// CircuitState and ConsecutiveFailures report the circuit breaker.
+	CircuitState        selfstat.Stat
+	ConsecutiveFailures selfstat.Stat
+
+	// Only registered when a disk buffer is configured.
+	BufferDiskBytes selfstat.Stat
+	MetricsReplayed selfstat.Stat
+
+	// BatchReady receives a value when a full batch of metrics is waiting to
+	// be written.
+	BatchReady chan struct{}
+
+	// DeadLetterSink is true if the output is the dead letter output of
+	// another output, it then receives no other metrics.
+	DeadLetterSink bool
 
 	metrics     *buffer.Buffer
 	failMetrics *buffer.Buffer
+	diskMetrics *buffer.DiskBuffer
+	// diskMu guards diskMetrics against AddMetric, as the disk buffer may
+	// be opened while metrics are being added.
+	diskMu sync.Mutex
+
+	// workers is the number of batches written concurrently.
+	workers int
+
+	breaker *circuitBreaker
+
+	// deadLetter receives the metrics dropped by this output.
+	deadLetter   *RunningOutput
+	deadLetterMu sync.Mutex
+
+	lastError     error
+	lastErrorTime time.Time
+	lastErrorMu   sync.Mutex
 
 	// Guards against concurrent calls to the Output as described in #3009
 	sync.Mutex
@@ -53,14 +92,30 @@ func NewRunningOutput(
 	if batchSize == 0 {
 		batchSize = DEFAULT_METRIC_BATCH_SIZE
 	}
+	workers := 1
+	if conf.WriteWorkers > 1 {
+		if co, ok := output.(telegraf.ConcurrentOutput); ok && co.SupportsConcurrentWrites() {
+			workers = conf.WriteWorkers
+		} else {
+			log.Printf("W! Output [%s] does not support concurrent writes, "+
+				"ignoring write_workers\n", name)
+		}
+	}
 	ro := &RunningOutput{
 		Name:              name,
-		metrics:           buffer.NewBuffer(batchSize),
+		BatchReady:        make(chan struct{}, 1),
+		metrics:           buffer.NewBuffer(bufferLimit),
 		failMetrics:       buffer.NewBuffer(bufferLimit),
+		workers:           workers,
 		Output:            output,
 		Config:            conf,
 		MetricBufferLimit: bufferLimit,
 		MetricBatchSize:   batchSize,
+		breaker: newCircuitBreaker(
+			conf.RetryInitialBackoff,
+			conf.RetryMaxBackoff,
+			conf.RetryJitter,
+		),
 		MetricsWritten: selfstat.Register(
 			"write",
 			"metrics_written",
@@ -86,50 +141,183 @@ func NewRunningOutput(
 			"write_time_ns",
 			map[string]string{"output": name},
 		),
+		MetricsDropped: selfstat.Register(
+			"write",
+			"metrics_dropped",
+			map[string]string{"output": name},
+		),
+		CircuitState: selfstat.Register(
+			"write",
+			"state",
+			map[string]string{"output": name},
+		),
+		ConsecutiveFailures: selfstat.Register(
+			"write",
+			"consecutive_failures",
+			map[string]string{"output": name},
+		),
 	}
+	ro.metrics.SetDropHandler(ro.evicted)
+	ro.failMetrics.SetDropHandler(ro.evicted)
 	ro.BufferLimit.Set(int64(ro.MetricBufferLimit))
+	ro.CircuitState.Set(CircuitClosed)
+	ro.ConsecutiveFailures.Set(0)
 	return ro
 }
 
-// AddMetric adds a metric to the output. This function can also write cached
-// points if FlushBufferWhenFull is true.
+// OpenDiskBuffer opens the on-disk buffer configured for the output, if any.
+// Once opened, metrics that fail to be written are stored on disk rather
+// than in memory, and metrics left on disk by a previous run are written
+// before any new metrics.  Opening an already open buffer is a no-op.
+func (ro *RunningOutput) OpenDiskBuffer() error {
+	ro.Lock()
+	defer ro.Unlock()
+
+	if ro.Config.BufferDirectory == "" || ro.diskMetrics != nil {
+		return nil
+	}
+
+	db, err := buffer.NewDiskBuffer(ro.Config.BufferDirectory,
+		ro.Config.BufferMaxSize, ro.Config.BufferFsync)
+	if err != nil {
+		return fmt.Errorf("unable to open buffer for output %s: %s", ro.Name, err)
+	}
+
+	ro.BufferDiskBytes = selfstat.Register(
+		"write",
+		"buffer_disk_bytes",
+		map[string]string{"output": ro.Name},
+	)
+	ro.MetricsReplayed = selfstat.Register(
+		"write",
+		"metrics_replayed",
+		map[string]string{"output": ro.Name},
+	)
+	ro.BufferDiskBytes.Set(db.Size())
+
+	ro.diskMu.Lock()
+	ro.diskMetrics = db
+	ro.diskMu.Unlock()
+
+	if n := db.Len(); n > 0 {
+		log.Printf("I! Output [%s] found %d buffered metrics in %s\n",
+			ro.Name, n, ro.Config.BufferDirectory)
+	}
+	return nil
+}
+
+// AddMetric adds a metric to the output. BatchReady is signalled once a full
+// batch of metrics is buffered.
 func (ro *RunningOutput) AddMetric(m telegraf.Metric) {
 	if m == nil {
 		return
 	}
 	// Filter any tagexclude/taginclude parameters before adding metric
 	if ro.Config.Filter.IsActive() {
-		// In order to filter out tags, we need to create a new metric, since
-		// metrics are immutable once created.
 		name := m.Name()
 		tags := m.Tags()
 		fields := m.Fields()
-		t := m.Time()
 		if ok := ro.Config.Filter.Apply(name, fields, tags); !ok {
 			ro.MetricsFiltered.Incr(1)
+			m.Drop()
 			return
 		}
-		// error is not possible if creating from another metric, so ignore.
-		m, _ = metric.New(name, tags, fields, t)
+		// Remove the excluded tags and fields in place so that the metric
+		// keeps any delivery tracking.
+		for key := range m.Tags() {
+			if _, ok := tags[key]; !ok {
+				m.RemoveTag(key)
+			}
+		}
+		for key := range m.Fields() {
+			if _, ok := fields[key]; !ok {
+				m.RemoveField(key)
+			}
+		}
+	}
+
+	if ro.spill(m) {
+		return
 	}
 
 	ro.metrics.Add(m)
-	if ro.metrics.Len() == ro.MetricBatchSize {
-		batch := ro.metrics.Batch(ro.MetricBatchSize)
-		err := ro.write(batch)
-		if err != nil {
-			ro.failMetrics.Add(batch...)
+	if ro.metrics.Len() >= ro.MetricBatchSize {
+		select {
+		case ro.BatchReady <- struct{}{}:
+		default:
 		}
 	}
 }
 
+// spill stores the metric in the disk buffer instead of memory while the
+// output is failing, that is while the disk buffer holds metrics waiting to
+// be replayed, or when the memory buffer is full. The buffered metrics are
+// moved to disk first to keep the metrics in order.
+func (ro *RunningOutput) spill(m telegraf.Metric) bool {
+	ro.diskMu.Lock()
+	db := ro.diskMetrics
+	ro.diskMu.Unlock()
+	if db == nil {
+		return false
+	}
+	if db.Len() == 0 && ro.metrics.Len() < ro.MetricBufferLimit {
+		return false
+	}
+
+	for ro.metrics.Len() > 0 {
+		ro.addFailed(ro.metrics.Batch(ro.MetricBatchSize))
+	}
+	ro.addFailed([]telegraf.Metric{m})
+	return true
+}
+
+// WriteBatch writes a single batch of metrics to the output. Nothing is
+// written while earlier failed writes are pending, these are retried by
+// Write to preserve the order of metrics.
+func (ro *RunningOutput) WriteBatch() error {
+	ro.Lock()
+	defer ro.Unlock()
+
+	if !ro.failMetrics.IsEmpty() ||
+		(ro.diskMetrics != nil && ro.diskMetrics.Len() > 0) {
+		return nil
+	}
+
+	batch := ro.metrics.Batch(ro.MetricBatchSize)
+	err := ro.write(batch)
+	if err != nil {
+		ro.addFailed(batch)
+	}
+	if err == errBackoff {
+		return nil
+	}
+	return err
+}
+
 // Write writes all cached points to this output.
 func (ro *RunningOutput) Write() error {
+	ro.Lock()
+	defer ro.Unlock()
+
+	if !ro.breaker.Ready() {
+		log.Printf("D! Output [%s] backing off after failed writes, "+
+			"skipping write\n", ro.Name)
+		return nil
+	}
+
 	nFails, nMetrics := ro.failMetrics.Len(), ro.metrics.Len()
-	ro.BufferSize.Set(int64(nFails + nMetrics))
+	nDisk := 0
+	if ro.diskMetrics != nil {
+		nDisk = ro.diskMetrics.Len()
+	}
+	ro.BufferSize.Set(int64(nFails + nMetrics + nDisk))
 	log.Printf("D! Output [%s] buffer fullness: %d / %d metrics. ",
-		ro.Name, nFails+nMetrics, ro.MetricBufferLimit)
+		ro.Name, nFails+nMetrics+nDisk, ro.MetricBufferLimit)
 	var err error
+	// Metrics stored on disk are the oldest, so write them first.
+	if nDisk > 0 {
+		err = ro.replay()
+	}
 	if !ro.failMetrics.IsEmpty() {
 		// how many batches of failed writes we need to write.
 		nBatches := nFails/ro.MetricBatchSize + 1
@@ -154,41 +342,243 @@ func (ro *RunningOutput) Write() error {
 		}
 	}
 
-	batch := ro.metrics.Batch(ro.MetricBatchSize)
 	// see comment above about not trying to write to an already failed output.
 	// if ro.failMetrics is empty then err will always be nil at this point.
 	if err == nil {
-		err = ro.write(batch)
+		err = ro.writeMetrics()
 	}
 
-	if err != nil {
-		ro.failMetrics.Add(batch...)
-		return err
+	// Move the remaining metrics to disk, rather than dropping them once the
+	// memory buffer is full.
+	if err != nil && ro.diskMetrics != nil {
+		for ro.metrics.Len() > 0 {
+			ro.addFailed(ro.metrics.Batch(ro.MetricBatchSize))
+		}
+	}
+	if err == errBackoff {
+		return nil
+	}
+	return err
+}
+
+// writeMetrics writes the buffered metrics in batches, with up to workers
+// batches being written concurrently. No more batches are written after a
+// write fails.
+func (ro *RunningOutput) writeMetrics() error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var err error
+	var batches [][]telegraf.Metric
+	failed := make(map[int]bool)
+	sem := make(chan struct{}, ro.workers)
+
+	for ro.metrics.Len() > 0 {
+		batch := ro.metrics.Batch(ro.MetricBatchSize)
+		i := len(batches)
+		batches = append(batches, batch)
+		sem <- struct{}{}
+
+		mu.Lock()
+		if err != nil {
+			failed[i] = true
+			mu.Unlock()
+			<-sem
+			break
+		}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func(i int, batch []telegraf.Metric) {
+			defer wg.Done()
+			if werr := ro.write(batch); werr != nil {
+				mu.Lock()
+				failed[i] = true
+				// report the write failure rather than the backoff it caused
+				if err == nil || err == errBackoff {
+					err = werr
+				}
+				mu.Unlock()
+			}
+			<-sem
+		}(i, batch)
+	}
+
+	wg.Wait()
+
+	// keep the order of failed writes, whatever order they completed in
+	for i, batch := range batches {
+		if failed[i] {
+			ro.addFailed(batch)
+		}
+	}
+	return err
+}
+
+// addFailed stores a batch that could not be written so that it can be
+// retried on the next write.
+func (ro *RunningOutput) addFailed(batch []telegraf.Metric) {
+	if ro.diskMetrics != nil {
+		err := ro.diskMetrics.Append(batch...)
+		ro.BufferDiskBytes.Set(ro.diskMetrics.Size())
+		if err == nil {
+			// The metrics are safely stored and will be replayed.
+			for _, m := range batch {
+				m.Accept()
+			}
+			return
+		}
+		log.Printf("E! Output [%s] unable to write to disk buffer, "+
+			"keeping metrics in memory: %s\n", ro.Name, err)
+	}
+	ro.failMetrics.Add(batch...)
+}
+
+// replay writes the metrics stored in the disk buffer, stopping at the first
+// failed write.
+func (ro *RunningOutput) replay() error {
+	defer func() {
+		ro.BufferDiskBytes.Set(ro.diskMetrics.Size())
+	}()
+
+	for ro.diskMetrics.Len() > 0 {
+		batch, err := ro.diskMetrics.Peek(ro.MetricBatchSize)
+		if err != nil {
+			return err
+		}
+		if err := ro.write(batch); err != nil {
+			return err
+		}
+		if err := ro.diskMetrics.Commit(); err != nil {
+			return err
+		}
+		ro.MetricsReplayed.Incr(int64(len(batch)))
 	}
 	return nil
 }
 
+// Close closes the output and releases the disk buffer.
+func (ro *RunningOutput) Close() error {
+	err := ro.Output.Close()
+	if ro.diskMetrics != nil {
+		if derr := ro.diskMetrics.Close(); derr != nil {
+			log.Printf("E! Output [%s] unable to close disk buffer: %s\n",
+				ro.Name, derr)
+		}
+	}
+	return err
+}
+
+// write writes a batch of metrics to the output. A batch the output rejects
+// as permanently bad, or that has failed more than RetryMaxRetries times, is
+// dropped and nil is returned so that it is not retried.
 func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 	nMetrics := len(metrics)
 	if nMetrics == 0 {
 		return nil
 	}
-	ro.Lock()
-	defer ro.Unlock()
+	if !ro.breaker.Allow() {
+		return errBackoff
+	}
+	defer ro.updateCircuitStats()
+
 	start := time.Now()
 	err := ro.Output.Write(metrics)
 	elapsed := time.Since(start)
-	if err == nil {
+	switch {
+	case err == nil:
+		ro.breaker.Success()
 		log.Printf("D! Output [%s] wrote batch of %d metrics in %s\n",
 			ro.Name, nMetrics, elapsed)
 		ro.MetricsWritten.Incr(int64(nMetrics))
 		ro.WriteTime.Incr(elapsed.Nanoseconds())
+		for _, m := range metrics {
+			m.Accept()
+		}
+	case !ro.isRetryable(err):
+		// The output is reachable, it just refuses this batch.
+		ro.breaker.Success()
+		ro.setLastError(err)
+		ro.drop(metrics, DeadLetterRejected, err)
+		return nil
+	default:
+		ro.setLastError(err)
+		attempts := ro.breaker.Failure()
+		if ro.Config.RetryMaxRetries > 0 && attempts > ro.Config.RetryMaxRetries {
+			ro.breaker.ResetAttempts()
+			ro.drop(metrics, DeadLetterRetriesExhausted, err)
+			return nil
+		}
 	}
 	return err
 }
 
+func (ro *RunningOutput) isRetryable(err error) bool {
+	if ec, ok := ro.Output.(telegraf.ErrorClassifier); ok {
+		return ec.IsRetryable(err)
+	}
+	return true
+}
+
+// drop discards a batch of metrics that will not be written, passing them
+// on to the dead letter output if there is one.
+func (ro *RunningOutput) drop(metrics []telegraf.Metric, reason string, err error) {
+	log.Printf("E! Output [%s] dropped batch of %d metrics, %s: %s\n",
+		ro.Name, len(metrics), reason, err)
+	ro.MetricsDropped.Incr(int64(len(metrics)))
+	for _, m := range metrics {
+		ro.sendDeadLetter(m, reason, err)
+		m.Reject()
+	}
+}
+
+// evicted is called with the metrics dropped from a full buffer.
+func (ro *RunningOutput) evicted(m telegraf.Metric) {
+	ro.sendDeadLetter(m, DeadLetterBufferFull, nil)
+}
+
+func (ro *RunningOutput) updateCircuitStats() {
+	state, failures := ro.breaker.State()
+	ro.CircuitState.Set(int64(state))
+	ro.ConsecutiveFailures.Set(int64(failures))
+}
+
 // OutputConfig containing name and filter
 type OutputConfig struct {
 	Name   string
 	Filter Filter
+
+	// Alias names the output when referred to by the dead_letter option of
+	// another output.
+	Alias string
+	// DeadLetter is the name or alias of the output that receives the
+	// metrics this output drops.
+	DeadLetter string
+
+	// Pipelines the output takes part in.
+	Pipelines []string
+
+	// BufferDirectory enables the on-disk buffer for failed writes when set.
+	BufferDirectory string
+	// BufferMaxSize is the maximum size in bytes of the on-disk buffer.
+	BufferMaxSize int64
+	// BufferFsync is the fsync policy of the on-disk buffer.
+	BufferFsync string
+
+	// FlushInterval and FlushJitter override the agent settings when set.
+	FlushInterval time.Duration
+	FlushJitter   time.Duration
+
+	// WriteWorkers is the number of batches written concurrently, it only
+	// applies to outputs that support concurrent writes.
+	WriteWorkers int
+
+	// RetryInitialBackoff is how long writes are paused after a failure, the
+	// pause doubles with each consecutive failure up to RetryMaxBackoff. A
+	// random duration up to RetryJitter is added to each pause.
+	RetryInitialBackoff time.Duration
+	RetryMaxBackoff     time.Duration
+	RetryJitter         time.Duration
+	// RetryMaxRetries is the number of times a failed batch is retried
+	// before it is dropped, 0 retries forever.
+	RetryMaxRetries int
 }
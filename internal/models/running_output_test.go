@@ -2,10 +2,15 @@ package models
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -248,8 +253,7 @@ func TestRunningOutputDefault(t *testing.T) {
 	assert.Len(t, m.Metrics(), 10)
 }
 
-// Test that running output doesn't flush until it's full when
-// FlushBufferWhenFull is set.
+// Test that running output signals a ready batch once it's full.
 func TestRunningOutputFlushWhenFull(t *testing.T) {
 	conf := &OutputConfig{
 		Filter: Filter{},
@@ -262,23 +266,26 @@ func TestRunningOutputFlushWhenFull(t *testing.T) {
 	for _, metric := range first5 {
 		ro.AddMetric(metric)
 	}
-	// no flush yet
-	assert.Len(t, m.Metrics(), 0)
+	// no batch ready yet
+	assert.Len(t, ro.BatchReady, 0)
 
 	// add one more metric
 	ro.AddMetric(next5[0])
-	// now it flushed
+	// now a batch is ready
+	require.Len(t, ro.BatchReady, 1)
+	<-ro.BatchReady
+	err := ro.WriteBatch()
+	assert.NoError(t, err)
 	assert.Len(t, m.Metrics(), 6)
 
 	// add one more metric and write it manually
 	ro.AddMetric(next5[1])
-	err := ro.Write()
+	err = ro.Write()
 	assert.NoError(t, err)
 	assert.Len(t, m.Metrics(), 7)
 }
 
-// Test that running output doesn't flush until it's full when
-// FlushBufferWhenFull is set, twice.
+// Test that running output writes a batch at a time once it's full.
 func TestRunningOutputMultiFlushWhenFull(t *testing.T) {
 	conf := &OutputConfig{
 		Filter: Filter{},
@@ -294,7 +301,11 @@ func TestRunningOutputMultiFlushWhenFull(t *testing.T) {
 	for _, metric := range next5 {
 		ro.AddMetric(metric)
 	}
+	assert.Len(t, ro.BatchReady, 1)
+
 	// flushed twice
+	assert.NoError(t, ro.WriteBatch())
+	assert.NoError(t, ro.WriteBatch())
 	assert.Len(t, m.Metrics(), 8)
 }
 
@@ -479,6 +490,356 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+// Verify that failed writes are persisted to disk and written by a new
+// RunningOutput using the same buffer directory.
+func TestRunningOutputDiskBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-output-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &OutputConfig{
+		Filter:          Filter{},
+		BufferDirectory: dir,
+		BufferFsync:     "never",
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 100)
+	require.NoError(t, ro.OpenDiskBuffer())
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	err = ro.Write()
+	require.Error(t, err)
+	assert.Len(t, m.Metrics(), 0)
+	assert.True(t, ro.BufferDiskBytes.Get() > 0)
+	require.NoError(t, ro.Close())
+
+	// Simulate a restart
+	m = &mockOutput{}
+	ro = NewRunningOutput("test", m, conf, 5, 100)
+	require.NoError(t, ro.OpenDiskBuffer())
+	defer ro.Close()
+
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+	err = ro.Write()
+	require.NoError(t, err)
+
+	// Verify that 10 metrics were written in order
+	expected := append(first5, next5...)
+	require.Len(t, m.Metrics(), 10)
+	for i, metric := range m.Metrics() {
+		assert.Equal(t, expected[i].String(), metric.String())
+	}
+	// new metrics are queued on disk behind the ones left by the last run
+	assert.Equal(t, int64(10), ro.MetricsReplayed.Get())
+	assert.Equal(t, int64(0), ro.BufferDiskBytes.Get())
+}
+
+// Verify that metrics go to the disk buffer as they are added once the
+// output is failing or the memory buffer is full.
+func TestRunningOutputDiskBufferSpill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-output-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &OutputConfig{
+		Filter:          Filter{},
+		BufferDirectory: dir,
+		BufferFsync:     "never",
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 10, 5)
+	require.NoError(t, ro.OpenDiskBuffer())
+	defer ro.Close()
+
+	// the memory buffer is full
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	assert.Equal(t, 5, ro.metrics.Len())
+	ro.AddMetric(next5[0])
+	assert.Equal(t, 0, ro.metrics.Len())
+	assert.Equal(t, 6, ro.diskMetrics.Len())
+
+	// the output is failing
+	ro.AddMetric(next5[1])
+	assert.Equal(t, 0, ro.metrics.Len())
+	assert.Equal(t, 7, ro.diskMetrics.Len())
+
+	require.NoError(t, ro.Write())
+	expected := append(first5, next5[:2]...)
+	require.Len(t, m.Metrics(), 7)
+	for i, metric := range m.Metrics() {
+		assert.Equal(t, expected[i].String(), metric.String())
+	}
+
+	ro.AddMetric(next5[2])
+	assert.Equal(t, 1, ro.metrics.Len())
+	assert.Equal(t, 0, ro.diskMetrics.Len())
+}
+
+// Verify that tracked metrics are resolved once written or filtered.
+func TestRunningOutputTrackingDelivery(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{
+			NameDrop: []string{"metric2"},
+		},
+	}
+	assert.NoError(t, conf.Filter.Compile())
+
+	var delivered []telegraf.DeliveryInfo
+	notify := func(info telegraf.DeliveryInfo) {
+		delivered = append(delivered, info)
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	m1, _ := metric.WithTracking(first5[0].Copy(), notify)
+	m2, _ := metric.WithTracking(first5[1].Copy(), notify)
+	ro.AddMetric(m1)
+	ro.AddMetric(m2)
+	// the filtered metric is resolved immediately
+	require.Len(t, delivered, 1)
+
+	m.failWrite = true
+	require.Error(t, ro.Write())
+	require.Len(t, delivered, 1)
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	require.Len(t, delivered, 2)
+	assert.True(t, delivered[1].Delivered())
+}
+
+// Verify that batches are written concurrently by outputs supporting it,
+// and that failed writes hold back WriteBatch until they are retried.
+func TestRunningOutputWriteWorkers(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:       Filter{},
+		WriteWorkers: 4,
+	}
+
+	m := &concurrentOutput{}
+	ro := NewRunningOutput("test", m, conf, 1, 100)
+	for _, metric := range append(first5, next5...) {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 10)
+	assert.True(t, m.maxActive > 1)
+
+	// outputs that do not support concurrent writes ignore write_workers
+	ro = NewRunningOutput("test", &mockOutput{}, conf, 1, 100)
+	assert.Equal(t, 1, ro.workers)
+}
+
+// Verify that batches failing concurrently are retried in order, whatever
+// order their writes completed in.
+func TestRunningOutputWriteWorkersFailOrder(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:       Filter{},
+		WriteWorkers: 5,
+	}
+
+	m := &reverseOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 1, 100)
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+	assert.Equal(t, first5, ro.failMetrics.Batch(5))
+}
+
+func TestRunningOutputWriteBatchAfterFail(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 100)
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.WriteBatch())
+
+	m.failWrite = false
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+	// the failed batch must be written first
+	require.NoError(t, ro.WriteBatch())
+	assert.Len(t, m.Metrics(), 0)
+
+	require.NoError(t, ro.Write())
+	assert.Equal(t, append(first5, next5...), m.Metrics())
+}
+
+// Verify that batches the output rejects as permanent are dropped instead of
+// being retried.
+func TestRunningOutputRejectedBatch(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	var delivered []telegraf.DeliveryInfo
+	notify := func(info telegraf.DeliveryInfo) {
+		delivered = append(delivered, info)
+	}
+
+	m := &classifyingOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("rejected", m, conf, 5, 100)
+	tm, _ := metric.WithTracking(first5[0].Copy(), notify)
+	ro.AddMetric(tm)
+	for _, metric := range first5[1:] {
+		ro.AddMetric(metric)
+	}
+
+	m.permanent = true
+	require.NoError(t, ro.Write())
+	assert.Equal(t, int64(5), ro.MetricsDropped.Get())
+	assert.Equal(t, int64(CircuitClosed), ro.CircuitState.Get())
+	require.Len(t, delivered, 1)
+	assert.False(t, delivered[0].Delivered())
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 0)
+}
+
+// Verify that writes are paused while backing off after a failure.
+func TestRunningOutputRetryBackoff(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:              Filter{},
+		RetryInitialBackoff: time.Minute,
+		RetryMaxBackoff:     3 * time.Minute,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("backoff", m, conf, 5, 100)
+	now := time.Now()
+	ro.breaker.now = func() time.Time { return now }
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	require.Error(t, ro.Write())
+	assert.Equal(t, int64(CircuitOpen), ro.CircuitState.Get())
+	assert.Equal(t, int64(1), ro.ConsecutiveFailures.Get())
+
+	// no write is attempted until the backoff expires
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 0)
+
+	m.failWrite = true
+	now = now.Add(time.Minute)
+	require.Error(t, ro.Write())
+	assert.Equal(t, int64(2), ro.ConsecutiveFailures.Get())
+
+	// the backoff doubles after each failure, up to the maximum
+	m.failWrite = false
+	now = now.Add(time.Minute)
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 0)
+
+	now = now.Add(time.Minute)
+	require.NoError(t, ro.Write())
+	assert.Equal(t, first5, m.Metrics())
+	assert.Equal(t, int64(CircuitClosed), ro.CircuitState.Get())
+	assert.Equal(t, int64(0), ro.ConsecutiveFailures.Get())
+}
+
+// Verify that a batch is dropped once it has used up its retries.
+func TestRunningOutputRetryMaxRetries(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:          Filter{},
+		RetryMaxRetries: 2,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("retries", m, conf, 5, 100)
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	require.Error(t, ro.Write())
+	require.Error(t, ro.Write())
+	require.NoError(t, ro.Write())
+	assert.Equal(t, int64(5), ro.MetricsDropped.Get())
+	assert.Equal(t, int64(3), ro.ConsecutiveFailures.Get())
+
+	m.failWrite = false
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	assert.Equal(t, next5, m.Metrics())
+}
+
+type classifyingOutput struct {
+	mockOutput
+
+	// if true, failed writes are reported as permanent
+	permanent bool
+}
+
+func (m *classifyingOutput) IsRetryable(err error) bool {
+	return !m.permanent
+}
+
+type concurrentOutput struct {
+	mockOutput
+
+	active    int32
+	maxActive int32
+}
+
+func (m *concurrentOutput) SupportsConcurrentWrites() bool {
+	return true
+}
+
+func (m *concurrentOutput) Write(metrics []telegraf.Metric) error {
+	n := atomic.AddInt32(&m.active, 1)
+	defer atomic.AddInt32(&m.active, -1)
+	for {
+		max := atomic.LoadInt32(&m.maxActive)
+		if n <= max || atomic.CompareAndSwapInt32(&m.maxActive, max, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return m.mockOutput.Write(metrics)
+}
+
+// reverseOutput completes concurrent writes of the metrics in first5 in
+// reverse order.
+type reverseOutput struct {
+	mockOutput
+}
+
+func (m *reverseOutput) SupportsConcurrentWrites() bool {
+	return true
+}
+
+func (m *reverseOutput) Write(metrics []telegraf.Metric) error {
+	name := metrics[0].Name()
+	n := int(name[len(name)-1] - '0')
+	time.Sleep(time.Duration(6-n) * 5 * time.Millisecond)
+	return m.mockOutput.Write(metrics)
+}
+
 type mockOutput struct {
 	sync.Mutex
 
@@ -25,12 +25,21 @@ type ProcessorConfig struct {
 	Name   string
 	Order  int64
 	Filter Filter
+
+	// Pipelines the processor takes part in.
+	Pipelines []string
 }
 
 func (rp *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	return rp.ApplyPipeline(DefaultPipeline, in...)
+}
+
+// ApplyPipeline applies the processor to the metrics of the pipeline.
+func (rp *RunningProcessor) ApplyPipeline(pipeline string, in ...telegraf.Metric) []telegraf.Metric {
 	rp.Lock()
 	defer rp.Unlock()
 
+	fp, flushing := rp.Processor.(telegraf.FlushingProcessor)
 	ret := []telegraf.Metric{}
 
 	for _, metric := range in {
@@ -44,8 +53,26 @@ func (rp *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
 		}
 		// This metric should pass through the filter, so call the filter Apply
 		// function and append results to the output slice.
-		ret = append(ret, rp.Processor.Apply(metric)...)
+		if flushing {
+			ret = append(ret, fp.ApplyPipeline(pipeline, metric)...)
+		} else {
+			ret = append(ret, rp.Processor.Apply(metric)...)
+		}
 	}
 
 	return ret
 }
+
+// Flush returns the metrics held by the processor which are due to be passed
+// on, by pipeline, if it holds on to metrics. All of them are due when final
+// is set.
+func (rp *RunningProcessor) Flush(final bool) map[string][]telegraf.Metric {
+	p, ok := rp.Processor.(telegraf.FlushingProcessor)
+	if !ok {
+		return nil
+	}
+
+	rp.Lock()
+	defer rp.Unlock()
+	return p.Flush(final)
+}
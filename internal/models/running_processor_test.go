@@ -115,3 +115,40 @@ func TestRunningProcessor_DroppedMetric(t *testing.T) {
 	}
 	assert.Equal(t, expectedNames, actualNames)
 }
+
+// TestFlushingProcessor holds on to the metrics of each pipeline until it is
+// flushed.
+type TestFlushingProcessor struct {
+	TestProcessor
+	held map[string][]telegraf.Metric
+}
+
+func (f *TestFlushingProcessor) ApplyPipeline(pipeline string, in ...telegraf.Metric) []telegraf.Metric {
+	f.held[pipeline] = append(f.held[pipeline], in...)
+	return nil
+}
+
+func (f *TestFlushingProcessor) Flush(final bool) map[string][]telegraf.Metric {
+	if !final {
+		return nil
+	}
+	out := f.held
+	f.held = make(map[string][]telegraf.Metric)
+	return out
+}
+
+func TestRunningProcessor_Flush(t *testing.T) {
+	rfp := NewTestRunningProcessor()
+	rfp.Apply(testutil.TestMetric(1, "foo"))
+	assert.Len(t, rfp.Flush(true), 0, "Should not flush a processor holding no metrics")
+
+	rfp.Processor = &TestFlushingProcessor{held: make(map[string][]telegraf.Metric)}
+	assert.Len(t, rfp.Apply(testutil.TestMetric(1, "foo")), 0)
+	assert.Len(t, rfp.ApplyPipeline("security", testutil.TestMetric(1, "bar")), 0)
+	assert.Len(t, rfp.Flush(false), 0)
+
+	flushed := rfp.Flush(true)
+	assert.Len(t, flushed, 2)
+	assert.Equal(t, "foo", flushed[DefaultPipeline][0].Name())
+	assert.Equal(t, "bar", flushed["security"][0].Name())
+}
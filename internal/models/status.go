@@ -0,0 +1,106 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// InputStatus is the state of an input, as reported by the agent API.
+type InputStatus struct {
+	// LastGather is the start time of the last completed gather.
+	LastGather time.Time
+	// GatherTime is how long the last completed gather took.
+	GatherTime time.Duration
+	// Overrun is true if the last gather took longer than the interval of
+	// the input, or the current gather has already done so.
+	Overrun bool
+
+	LastError     error
+	LastErrorTime time.Time
+}
+
+// inputStatus guards the InputStatus of a RunningInput, which is updated by
+// the gatherer and read by the agent API.
+type inputStatus struct {
+	InputStatus
+	sync.Mutex
+}
+
+// SetGatherResult records a completed gather.
+func (r *RunningInput) SetGatherResult(start time.Time, elapsed time.Duration, overrun bool) {
+	r.status.Lock()
+	defer r.status.Unlock()
+	r.status.LastGather = start
+	r.status.GatherTime = elapsed
+	r.status.Overrun = overrun
+}
+
+// SetOverrun records that the current gather is taking longer than the
+// interval of the input.
+func (r *RunningInput) SetOverrun() {
+	r.status.Lock()
+	defer r.status.Unlock()
+	r.status.Overrun = true
+}
+
+// SetLastError records an error reported by the input.
+func (r *RunningInput) SetLastError(err error) {
+	r.status.Lock()
+	defer r.status.Unlock()
+	r.status.LastError = err
+	r.status.LastErrorTime = time.Now()
+}
+
+// Status returns the state of the input.
+func (r *RunningInput) Status() InputStatus {
+	r.status.Lock()
+	defer r.status.Unlock()
+	return r.status.InputStatus
+}
+
+// OutputStatus is the state of an output, as reported by the agent API.
+type OutputStatus struct {
+	// State is the state of the circuit breaker, one of CircuitClosed,
+	// CircuitOpen or CircuitHalfOpen.
+	State               int
+	ConsecutiveFailures int
+	// FailingSince is the time of the first failed write since the last
+	// successful one, zero if the last write succeeded.
+	FailingSince time.Time
+
+	LastError     error
+	LastErrorTime time.Time
+
+	// Buffered is the number of metrics waiting to be written, including
+	// those stored in the disk buffer.
+	Buffered        int
+	BufferLimit     int
+	BufferDiskBytes int64
+}
+
+// setLastError records an error returned by the output.
+func (ro *RunningOutput) setLastError(err error) {
+	ro.lastErrorMu.Lock()
+	defer ro.lastErrorMu.Unlock()
+	ro.lastError = err
+	ro.lastErrorTime = time.Now()
+}
+
+// Status returns the state of the output.
+func (ro *RunningOutput) Status() OutputStatus {
+	s := OutputStatus{
+		FailingSince: ro.breaker.FailingSince(),
+		Buffered:     ro.metrics.Len() + ro.failMetrics.Len(),
+		BufferLimit:  ro.MetricBufferLimit,
+	}
+	s.State, s.ConsecutiveFailures = ro.breaker.State()
+	if ro.diskMetrics != nil {
+		s.Buffered += ro.diskMetrics.Len()
+		s.BufferDiskBytes = ro.diskMetrics.Size()
+	}
+
+	ro.lastErrorMu.Lock()
+	s.LastError, s.LastErrorTime = ro.lastError, ro.lastErrorTime
+	ro.lastErrorMu.Unlock()
+	return s
+}
@@ -23,6 +23,12 @@ func (e *Engine) Apply(line string) (string, map[string]string, string, error) {
 	return e.matcher.match(line).Apply(line, e.joiner)
 }
 
+// Match returns the template selected for the given line, the default
+// template if no filter matches it.
+func (e *Engine) Match(line string) *Template {
+	return e.matcher.match(line)
+}
+
 // NewEngine creates a new templating engine
 func NewEngine(joiner string, defaultTemplate *Template, templates []string) (*Engine, error) {
 	engine := Engine{
@@ -77,6 +77,16 @@ func (t *Template) Apply(line string, joiner string) (string, map[string]string,
 	return strings.Join(measurement, joiner), outtags, strings.Join(field, joiner), nil
 }
 
+// Parts returns the parts of the template pattern.
+func (t *Template) Parts() []string {
+	return t.parts
+}
+
+// DefaultTags returns the extra tags following the template pattern.
+func (t *Template) DefaultTags() map[string]string {
+	return t.defaultTags
+}
+
 func NewDefaultTemplateWithPattern(pattern string) (*Template, error) {
 	return NewTemplate(DefaultSeparator, pattern, nil)
 }
@@ -0,0 +1,27 @@
+package telegraf
+
+// Logger writes log messages on behalf of a plugin. Plugins receive one by
+// declaring an exported field:
+//
+//   Log telegraf.Logger `toml:"-"`
+//
+// Messages are tagged with the plugin name and alias, and filtered on the
+// log level of the plugin.
+type Logger interface {
+	// Errorf logs an error message, formatted as with fmt.Printf.
+	Errorf(format string, args ...interface{})
+	// Error logs an error message, formatted as with fmt.Print.
+	Error(args ...interface{})
+	// Warnf logs a warning message, formatted as with fmt.Printf.
+	Warnf(format string, args ...interface{})
+	// Warn logs a warning message, formatted as with fmt.Print.
+	Warn(args ...interface{})
+	// Infof logs an information message, formatted as with fmt.Printf.
+	Infof(format string, args ...interface{})
+	// Info logs an information message, formatted as with fmt.Print.
+	Info(args ...interface{})
+	// Debugf logs a debug message, formatted as with fmt.Printf.
+	Debugf(format string, args ...interface{})
+	// Debug logs a debug message, formatted as with fmt.Print.
+	Debug(args ...interface{})
+}
@@ -1,69 +1,330 @@
 package logger
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/wlog"
 )
 
+// Log formats supported by SetupLogging.
+const (
+	FormatText   = "text"
+	FormatLogfmt = "logfmt"
+	FormatJSON   = "json"
+)
+
 var prefixRegex = regexp.MustCompile("^[DIWE]!")
 
+var levelNames = map[wlog.Level]string{
+	wlog.DEBUG: "debug",
+	wlog.INFO:  "info",
+	wlog.WARN:  "warn",
+	wlog.ERROR: "error",
+}
+
+// LogConfig contains the logging settings of the agent.
+type LogConfig struct {
+	// Debug sets the log level to DEBUG.
+	Debug bool
+	// Quiet sets the log level to ERROR.
+	Quiet bool
+	// Logfile directs the logging output to a file. Empty string is
+	// interpreted as stderr. If there is an error opening the file the
+	// logger will fallback to stderr.
+	Logfile string
+	// Format is one of FormatText, FormatLogfmt or FormatJSON, defaults to
+	// FormatText.
+	Format string
+
+	// RotationInterval rotates the logfile once it has been written to for
+	// this long, 0 disables time based rotation.
+	RotationInterval time.Duration
+	// RotationMaxSize rotates the logfile before it grows larger than this
+	// many bytes, 0 disables size based rotation.
+	RotationMaxSize int64
+	// RotationMaxArchives is the number of rotated logfiles kept, -1 keeps
+	// all of them.
+	RotationMaxArchives int
+}
+
+// record is a single log message.
+type record struct {
+	time   time.Time
+	level  wlog.Level
+	plugin string
+	alias  string
+	msg    string
+}
+
+// handler formats log records and writes them out.
+type handler struct {
+	w      io.Writer
+	format string
+	mu     sync.Mutex
+}
+
+func (h *handler) write(r *record) error {
+	var buf bytes.Buffer
+	switch h.format {
+	case FormatLogfmt:
+		writeLogfmt(&buf, r)
+	case FormatJSON:
+		writeJSON(&buf, r)
+	default:
+		writeText(&buf, r)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+var (
+	mu      sync.RWMutex
+	current = &handler{w: os.Stderr, format: FormatText}
+	closer  io.Closer
+)
+
+func currentHandler() *handler {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
 // newTelegrafWriter returns a logging-wrapped writer.
 func newTelegrafWriter(w io.Writer) io.Writer {
 	return &telegrafLog{
-		writer: wlog.NewWriter(w),
+		h: &handler{w: w, format: FormatText},
 	}
 }
 
+// telegrafLog is the output of the standard logger, it parses the level
+// prefix of each message and drops those below the log level.
 type telegrafLog struct {
-	writer io.Writer
+	h *handler
 }
 
 func (t *telegrafLog) Write(b []byte) (n int, err error) {
-	var line []byte
-	if !prefixRegex.Match(b) {
-		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" I! "), b...)
-	} else {
-		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" "), b...)
+	r := &record{
+		time:  time.Now(),
+		level: wlog.INFO,
+		msg:   strings.TrimSuffix(string(b), "\n"),
 	}
-	return t.writer.Write(line)
+	if prefixRegex.Match(b) {
+		r.level = wlog.Levels[b[0]]
+		r.msg = strings.TrimPrefix(r.msg[2:], " ")
+	}
+	if r.level < wlog.LogLevel() {
+		return len(b), nil
+	}
+	return len(b), t.h.write(r)
 }
 
 // SetupLogging configures the logging output.
-//   debug   will set the log level to DEBUG
-//   quiet   will set the log level to ERROR
-//   logfile will direct the logging output to a file. Empty string is
-//           interpreted as stderr. If there is an error opening the file the
-//           logger will fallback to stderr.
-func SetupLogging(debug, quiet bool, logfile string) {
+func SetupLogging(config LogConfig) {
 	log.SetFlags(0)
-	if debug {
-		wlog.SetLevel(wlog.DEBUG)
-	}
-	if quiet {
+	switch {
+	case config.Quiet:
 		wlog.SetLevel(wlog.ERROR)
+	case config.Debug:
+		wlog.SetLevel(wlog.DEBUG)
+	default:
+		wlog.SetLevel(wlog.INFO)
 	}
 
-	var oFile *os.File
-	if logfile != "" {
-		if _, err := os.Stat(logfile); os.IsNotExist(err) {
-			if oFile, err = os.Create(logfile); err != nil {
-				log.Printf("E! Unable to create %s (%s), using stderr", logfile, err)
-				oFile = os.Stderr
-			}
+	var w io.Writer = os.Stderr
+	var c io.Closer
+	if config.Logfile != "" {
+		rw, err := newRotatingWriter(config.Logfile, config.RotationInterval,
+			config.RotationMaxSize, config.RotationMaxArchives)
+		if err != nil {
+			log.Printf("E! Unable to open %s (%s), using stderr", config.Logfile, err)
 		} else {
-			if oFile, err = os.OpenFile(logfile, os.O_APPEND|os.O_WRONLY, os.ModeAppend); err != nil {
-				log.Printf("E! Unable to append to %s (%s), using stderr", logfile, err)
-				oFile = os.Stderr
-			}
+			w, c = rw, rw
+		}
+	}
+
+	switch config.Format {
+	case "", FormatText, FormatLogfmt, FormatJSON:
+	default:
+		log.Printf("E! Unknown log format %q, using %q", config.Format, FormatText)
+		config.Format = FormatText
+	}
+
+	h := &handler{w: w, format: config.Format}
+	log.SetOutput(&telegrafLog{h: h})
+
+	mu.Lock()
+	prev := closer
+	current, closer = h, c
+	mu.Unlock()
+	if prev != nil {
+		prev.Close()
+	}
+}
+
+func writeText(buf *bytes.Buffer, r *record) {
+	buf.WriteString(r.time.UTC().Format(time.RFC3339))
+	buf.WriteByte(' ')
+	buf.WriteByte(wlog.ReverseLevels[r.level])
+	buf.WriteString("! ")
+	if r.plugin != "" {
+		buf.WriteByte('[')
+		buf.WriteString(r.plugin)
+		if r.alias != "" {
+			buf.WriteString("::")
+			buf.WriteString(r.alias)
 		}
-	} else {
-		oFile = os.Stderr
+		buf.WriteString("] ")
+	}
+	buf.WriteString(r.msg)
+	buf.WriteByte('\n')
+}
+
+func writeLogfmt(buf *bytes.Buffer, r *record) {
+	writeLogfmtPair(buf, "ts", r.time.UTC().Format(time.RFC3339))
+	buf.WriteByte(' ')
+	writeLogfmtPair(buf, "level", levelNames[r.level])
+	if r.plugin != "" {
+		buf.WriteByte(' ')
+		writeLogfmtPair(buf, "plugin", r.plugin)
+	}
+	if r.alias != "" {
+		buf.WriteByte(' ')
+		writeLogfmtPair(buf, "alias", r.alias)
 	}
+	buf.WriteByte(' ')
+	writeLogfmtPair(buf, "msg", r.msg)
+	buf.WriteByte('\n')
+}
+
+func writeLogfmtPair(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	if value == "" || strings.ContainsAny(value, " =\"\\") ||
+		strings.IndexFunc(value, isControl) >= 0 {
+		value = strconv.Quote(value)
+	}
+	buf.WriteString(value)
+}
+
+func isControl(r rune) bool {
+	return r < ' ' || r == 0x7f
+}
+
+func writeJSON(buf *bytes.Buffer, r *record) {
+	buf.WriteByte('{')
+	writeJSONPair(buf, "ts", r.time.UTC().Format(time.RFC3339))
+	buf.WriteByte(',')
+	writeJSONPair(buf, "level", levelNames[r.level])
+	if r.plugin != "" {
+		buf.WriteByte(',')
+		writeJSONPair(buf, "plugin", r.plugin)
+	}
+	if r.alias != "" {
+		buf.WriteByte(',')
+		writeJSONPair(buf, "alias", r.alias)
+	}
+	buf.WriteByte(',')
+	writeJSONPair(buf, "msg", r.msg)
+	buf.WriteString("}\n")
+}
+
+func writeJSONPair(buf *bytes.Buffer, key, value string) {
+	k, _ := json.Marshal(key)
+	v, _ := json.Marshal(value)
+	buf.Write(k)
+	buf.WriteByte(':')
+	buf.Write(v)
+}
+
+// pluginLogger is the telegraf.Logger given to plugins.
+type pluginLogger struct {
+	plugin string
+	alias  string
+	// level overrides the agent log level when set.
+	level wlog.Level
+}
+
+// New returns a logger for a plugin, which tags its messages with the plugin
+// name and alias. level overrides the agent log level for the plugin unless
+// empty.
+func New(plugin, alias, level string) (telegraf.Logger, error) {
+	l := &pluginLogger{plugin: plugin, alias: alias}
+	if level != "" {
+		l.level = wlog.StringToLevel[strings.ToUpper(level)]
+		if l.level == 0 {
+			return nil, fmt.Errorf("invalid log level: %q", level)
+		}
+	}
+	return l, nil
+}
+
+func (l *pluginLogger) log(level wlog.Level, msg string) {
+	min := l.level
+	if min == 0 {
+		min = wlog.LogLevel()
+	}
+	if level < min {
+		return
+	}
+	currentHandler().write(&record{
+		time:   time.Now(),
+		level:  level,
+		plugin: l.plugin,
+		alias:  l.alias,
+		msg:    msg,
+	})
+}
+
+// Errorf logs an error message, formatted as with fmt.Printf.
+func (l *pluginLogger) Errorf(format string, args ...interface{}) {
+	l.log(wlog.ERROR, fmt.Sprintf(format, args...))
+}
+
+// Error logs an error message, formatted as with fmt.Print.
+func (l *pluginLogger) Error(args ...interface{}) {
+	l.log(wlog.ERROR, fmt.Sprint(args...))
+}
+
+// Warnf logs a warning message, formatted as with fmt.Printf.
+func (l *pluginLogger) Warnf(format string, args ...interface{}) {
+	l.log(wlog.WARN, fmt.Sprintf(format, args...))
+}
+
+// Warn logs a warning message, formatted as with fmt.Print.
+func (l *pluginLogger) Warn(args ...interface{}) {
+	l.log(wlog.WARN, fmt.Sprint(args...))
+}
+
+// Infof logs an information message, formatted as with fmt.Printf.
+func (l *pluginLogger) Infof(format string, args ...interface{}) {
+	l.log(wlog.INFO, fmt.Sprintf(format, args...))
+}
+
+// Info logs an information message, formatted as with fmt.Print.
+func (l *pluginLogger) Info(args ...interface{}) {
+	l.log(wlog.INFO, fmt.Sprint(args...))
+}
+
+// Debugf logs a debug message, formatted as with fmt.Printf.
+func (l *pluginLogger) Debugf(format string, args ...interface{}) {
+	l.log(wlog.DEBUG, fmt.Sprintf(format, args...))
+}
 
-	log.SetOutput(newTelegrafWriter(oFile))
+// Debug logs a debug message, formatted as with fmt.Print.
+func (l *pluginLogger) Debug(args ...interface{}) {
+	l.log(wlog.DEBUG, fmt.Sprint(args...))
 }
@@ -15,7 +15,7 @@ func TestWriteLogToFile(t *testing.T) {
 	assert.NoError(t, err)
 	defer func() { os.Remove(tmpfile.Name()) }()
 
-	SetupLogging(false, false, tmpfile.Name())
+	SetupLogging(LogConfig{Logfile: tmpfile.Name()})
 	log.Printf("I! TEST")
 	log.Printf("D! TEST") // <- should be ignored
 
@@ -29,7 +29,7 @@ func TestDebugWriteLogToFile(t *testing.T) {
 	assert.NoError(t, err)
 	defer func() { os.Remove(tmpfile.Name()) }()
 
-	SetupLogging(true, false, tmpfile.Name())
+	SetupLogging(LogConfig{Debug: true, Logfile: tmpfile.Name()})
 	log.Printf("D! TEST")
 
 	f, err := ioutil.ReadFile(tmpfile.Name())
@@ -42,7 +42,7 @@ func TestErrorWriteLogToFile(t *testing.T) {
 	assert.NoError(t, err)
 	defer func() { os.Remove(tmpfile.Name()) }()
 
-	SetupLogging(false, true, tmpfile.Name())
+	SetupLogging(LogConfig{Quiet: true, Logfile: tmpfile.Name()})
 	log.Printf("E! TEST")
 	log.Printf("I! TEST") // <- should be ignored
 
@@ -56,7 +56,7 @@ func TestAddDefaultLogLevel(t *testing.T) {
 	assert.NoError(t, err)
 	defer func() { os.Remove(tmpfile.Name()) }()
 
-	SetupLogging(true, false, tmpfile.Name())
+	SetupLogging(LogConfig{Debug: true, Logfile: tmpfile.Name()})
 	log.Printf("TEST")
 
 	f, err := ioutil.ReadFile(tmpfile.Name())
@@ -64,6 +64,63 @@ func TestAddDefaultLogLevel(t *testing.T) {
 	assert.Equal(t, f[19:], []byte("Z I! TEST\n"))
 }
 
+func TestLogFormats(t *testing.T) {
+	var tests = []struct {
+		format   string
+		expected string
+	}{
+		{
+			format:   FormatText,
+			expected: "Z W! [inputs.cpu::host1] TEST \"quoted\"\n",
+		},
+		{
+			format:   FormatLogfmt,
+			expected: `Z level=warn plugin=inputs.cpu alias=host1 msg="TEST \"quoted\""` + "\n",
+		},
+		{
+			format:   FormatJSON,
+			expected: `Z","level":"warn","plugin":"inputs.cpu","alias":"host1","msg":"TEST \"quoted\""}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			tmpfile, err := ioutil.TempFile("", "")
+			assert.NoError(t, err)
+			defer func() { os.Remove(tmpfile.Name()) }()
+
+			SetupLogging(LogConfig{Logfile: tmpfile.Name(), Format: tt.format})
+			l, err := New("inputs.cpu", "host1", "")
+			assert.NoError(t, err)
+			l.Warnf("TEST %q", "quoted")
+			l.Debug("TEST") // <- should be ignored
+
+			f, err := ioutil.ReadFile(tmpfile.Name())
+			assert.NoError(t, err)
+			i := bytes.Index(f, []byte("Z"))
+			assert.Equal(t, tt.expected, string(f[i:]))
+		})
+	}
+}
+
+func TestPluginLogLevel(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	assert.NoError(t, err)
+	defer func() { os.Remove(tmpfile.Name()) }()
+
+	SetupLogging(LogConfig{Logfile: tmpfile.Name()})
+	l, err := New("inputs.cpu", "", "debug")
+	assert.NoError(t, err)
+	l.Debug("TEST")
+
+	f, err := ioutil.ReadFile(tmpfile.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, f[19:], []byte("Z D! [inputs.cpu] TEST\n"))
+
+	_, err = New("inputs.cpu", "", "verbose")
+	assert.Error(t, err)
+}
+
 func BenchmarkTelegrafLogWrite(b *testing.B) {
 	var msg = []byte("test")
 	var buf bytes.Buffer
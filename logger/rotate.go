@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// archiveTimeFormat is appended to the name of rotated logfiles, it sorts in
+// the order the files were rotated.
+const archiveTimeFormat = "2006-01-02T15-04-05.000000000"
+
+// rotatingWriter writes to a logfile, moving it aside once it has been open
+// for longer than interval or would grow larger than maxSize.
+type rotatingWriter struct {
+	path        string
+	interval    time.Duration
+	maxSize     int64
+	maxArchives int
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	now func() time.Time
+	mu  sync.Mutex
+}
+
+func newRotatingWriter(
+	path string,
+	interval time.Duration,
+	maxSize int64,
+	maxArchives int,
+) (*rotatingWriter, error) {
+	w := &rotatingWriter{
+		path:        path,
+		interval:    interval,
+		maxSize:     maxSize,
+		maxArchives: maxArchives,
+		now:         time.Now,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.needsRotation(len(p)) {
+		if err := w.rotate(); err != nil {
+			// there is no logger left to report to
+			fmt.Fprintf(os.Stderr, "E! Unable to rotate %s: %s\n", w.path, err)
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the logfile.
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+func (w *rotatingWriter) needsRotation(n int) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.maxSize > 0 && w.size+int64(n) > w.maxSize {
+		return true
+	}
+	return w.interval > 0 && w.now().Sub(w.openedAt) >= w.interval
+}
+
+func (w *rotatingWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file = f
+	w.size = fi.Size()
+	w.openedAt = w.now()
+	return nil
+}
+
+// rotate moves the logfile aside, opens a new one and removes the oldest
+// archives.
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	archive := w.path + "." + w.now().UTC().Format(archiveTimeFormat)
+	renameErr := os.Rename(w.path, archive)
+	// keep logging to the same file if it could not be moved
+	if err := w.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+	return w.removeArchives()
+}
+
+func (w *rotatingWriter) removeArchives() error {
+	if w.maxArchives < 0 {
+		return nil
+	}
+	matches, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return err
+	}
+	var archives []string
+	for _, path := range matches {
+		suffix := strings.TrimPrefix(path, w.path+".")
+		if _, err := time.Parse(archiveTimeFormat, suffix); err == nil {
+			archives = append(archives, path)
+		}
+	}
+	sort.Strings(archives)
+	for len(archives) > w.maxArchives {
+		if err := os.Remove(archives[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		archives = archives[1:]
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateBySize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "telegraf.log")
+	w, err := newRotatingWriter(path, 0, 10, 2)
+	require.NoError(t, err)
+	defer w.Close()
+
+	now := time.Now()
+	w.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	for i := 0; i < 4; i++ {
+		_, err = w.Write([]byte("12345678\n"))
+		require.NoError(t, err)
+	}
+
+	archives, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	assert.Len(t, archives, 2)
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "12345678\n", string(b))
+}
+
+func TestRotateByInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "telegraf.log")
+	w, err := newRotatingWriter(path, time.Hour, 0, -1)
+	require.NoError(t, err)
+	defer w.Close()
+
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	_, err = w.Write([]byte("first\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("second\n"))
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	_, err = w.Write([]byte("third\n"))
+	require.NoError(t, err)
+
+	archives, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	require.Len(t, archives, 1)
+	b, err := ioutil.ReadFile(archives[0])
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(b))
+}
@@ -0,0 +1,163 @@
+package metric
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Bucket is a bucket of a histogram, with the cumulative count of the values
+// less than or equal to its upper bound.
+type Bucket struct {
+	UpperBound float64
+	Count      uint64
+}
+
+// Quantile is a quantile of a summary and its value.
+type Quantile struct {
+	Quantile float64
+	Value    float64
+}
+
+// Distribution is the value of a telegraf.Histogram or telegraf.Summary
+// metric. The fields of these metrics are named after the bucket upper bounds
+// or the quantiles, such as "0.5" or "+Inf", along with the "sum" and "count"
+// of the observed values, as parsed by the prometheus data format.
+type Distribution struct {
+	Sum   float64
+	Count uint64
+	// Buckets of a histogram, sorted by upper bound.
+	Buckets []Bucket
+	// Quantiles of a summary, sorted by quantile.
+	Quantiles []Quantile
+}
+
+// DecodeDistribution decodes the fields of a histogram or summary metric, it
+// returns false for metrics of other types. Non numeric fields and fields
+// that are not named after a number are ignored.
+func DecodeDistribution(m telegraf.Metric) (*Distribution, bool) {
+	if m.Type() != telegraf.Histogram && m.Type() != telegraf.Summary {
+		return nil, false
+	}
+
+	d := &Distribution{}
+	for k, v := range m.Fields() {
+		var value float64
+		switch v := v.(type) {
+		case int64:
+			value = float64(v)
+		case uint64:
+			value = float64(v)
+		case float64:
+			value = v
+		default:
+			continue
+		}
+
+		switch k {
+		case "sum":
+			d.Sum = value
+		case "count":
+			d.Count = uint64(value)
+		default:
+			bound, err := strconv.ParseFloat(k, 64)
+			if err != nil {
+				continue
+			}
+			if m.Type() == telegraf.Histogram {
+				d.Buckets = append(d.Buckets, Bucket{
+					UpperBound: bound,
+					Count:      uint64(value),
+				})
+			} else {
+				d.Quantiles = append(d.Quantiles, Quantile{
+					Quantile: bound,
+					Value:    value,
+				})
+			}
+		}
+	}
+
+	sort.Slice(d.Buckets, func(i, j int) bool {
+		return d.Buckets[i].UpperBound < d.Buckets[j].UpperBound
+	})
+	sort.Slice(d.Quantiles, func(i, j int) bool {
+		return d.Quantiles[i].Quantile < d.Quantiles[j].Quantile
+	})
+	return d, true
+}
+
+// Fields returns the fields of a metric holding the distribution, which
+// DecodeDistribution decodes.
+func (d *Distribution) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(d.Buckets)+len(d.Quantiles)+2)
+	for _, b := range d.Buckets {
+		fields[FormatBound(b.UpperBound)] = float64(b.Count)
+	}
+	for _, q := range d.Quantiles {
+		fields[FormatBound(q.Quantile)] = q.Value
+	}
+	fields["sum"] = d.Sum
+	fields["count"] = float64(d.Count)
+	return fields
+}
+
+// FormatBound formats a bucket upper bound or a quantile as the name of its
+// field, the infinite upper bound being "+Inf".
+func FormatBound(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// DistributionDeltas turns cumulative distributions, such as those of the
+// histogram aggregator or scraped from Prometheus, into the values observed
+// since the previous distribution of the same series. The zero value is
+// ready to use.
+type DistributionDeltas struct {
+	mu   sync.Mutex
+	last map[uint64]*Distribution
+}
+
+// Delta returns the change of the distribution d of metric m since the last
+// call for the series of m. It returns false for the first distribution of a
+// series, which only records the starting point. When the count went down
+// the source was restarted, and d is returned as is. Quantiles are not
+// cumulative and are returned unchanged.
+func (t *DistributionDeltas) Delta(m telegraf.Metric, d *Distribution) (*Distribution, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.last == nil {
+		t.last = make(map[uint64]*Distribution)
+	}
+	id := m.HashID()
+	prev, ok := t.last[id]
+	t.last[id] = d
+	if !ok {
+		return nil, false
+	}
+
+	prevBuckets := make(map[float64]uint64, len(prev.Buckets))
+	for _, b := range prev.Buckets {
+		prevBuckets[b.UpperBound] = b.Count
+	}
+	delta := &Distribution{
+		Sum:       d.Sum - prev.Sum,
+		Count:     d.Count - prev.Count,
+		Quantiles: d.Quantiles,
+	}
+	if d.Count < prev.Count {
+		return d, true
+	}
+	for _, b := range d.Buckets {
+		if b.Count < prevBuckets[b.UpperBound] {
+			return d, true
+		}
+		delta.Buckets = append(delta.Buckets, Bucket{
+			UpperBound: b.UpperBound,
+			Count:      b.Count - prevBuckets[b.UpperBound],
+		})
+	}
+	return delta, true
+}
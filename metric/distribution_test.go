@@ -0,0 +1,119 @@
+package metric
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeDistributionHistogram(t *testing.T) {
+	m, err := New("request_latency", nil,
+		map[string]interface{}{
+			"1":     float64(18),
+			"0.1":   float64(10),
+			"+Inf":  int64(20),
+			"sum":   float64(7.25),
+			"count": float64(20),
+			"state": "ok",
+		},
+		time.Unix(0, 0), telegraf.Histogram)
+	require.NoError(t, err)
+
+	d, ok := DecodeDistribution(m)
+	require.True(t, ok)
+	assert.Equal(t, float64(7.25), d.Sum)
+	assert.Equal(t, uint64(20), d.Count)
+	assert.Equal(t, []Bucket{
+		{UpperBound: 0.1, Count: 10},
+		{UpperBound: 1, Count: 18},
+		{UpperBound: math.Inf(1), Count: 20},
+	}, d.Buckets)
+	assert.Nil(t, d.Quantiles)
+
+	assert.Equal(t, map[string]interface{}{
+		"0.1":   float64(10),
+		"1":     float64(18),
+		"+Inf":  float64(20),
+		"sum":   float64(7.25),
+		"count": float64(20),
+	}, d.Fields())
+}
+
+func TestDecodeDistributionSummary(t *testing.T) {
+	m, err := New("rpc_duration", nil,
+		map[string]interface{}{
+			"0.99":  float64(0.2),
+			"0.5":   float64(0.1),
+			"sum":   float64(17),
+			"count": int64(120),
+		},
+		time.Unix(0, 0), telegraf.Summary)
+	require.NoError(t, err)
+
+	d, ok := DecodeDistribution(m)
+	require.True(t, ok)
+	assert.Equal(t, float64(17), d.Sum)
+	assert.Equal(t, uint64(120), d.Count)
+	assert.Equal(t, []Quantile{
+		{Quantile: 0.5, Value: 0.1},
+		{Quantile: 0.99, Value: 0.2},
+	}, d.Quantiles)
+	assert.Nil(t, d.Buckets)
+}
+
+func TestDecodeDistributionUntyped(t *testing.T) {
+	m, err := New("cpu", nil,
+		map[string]interface{}{"0.5": float64(1)},
+		time.Unix(0, 0))
+	require.NoError(t, err)
+
+	_, ok := DecodeDistribution(m)
+	assert.False(t, ok)
+}
+
+func TestDistributionDeltas(t *testing.T) {
+	newHistogram := func(host string, counts ...float64) telegraf.Metric {
+		m, err := New("latency", map[string]string{"host": host},
+			map[string]interface{}{
+				"1":     counts[0],
+				"+Inf":  counts[1],
+				"sum":   counts[2],
+				"count": counts[1],
+			},
+			time.Unix(0, 0), telegraf.Histogram)
+		require.NoError(t, err)
+		return m
+	}
+	delta := func(deltas *DistributionDeltas, m telegraf.Metric) (*Distribution, bool) {
+		d, _ := DecodeDistribution(m)
+		return deltas.Delta(m, d)
+	}
+
+	var deltas DistributionDeltas
+	_, ok := delta(&deltas, newHistogram("a", 2, 3, 10))
+	assert.False(t, ok)
+	_, ok = delta(&deltas, newHistogram("b", 1, 1, 1))
+	assert.False(t, ok)
+
+	d, ok := delta(&deltas, newHistogram("a", 5, 7, 25))
+	require.True(t, ok)
+	assert.Equal(t, &Distribution{
+		Sum:   15,
+		Count: 4,
+		Buckets: []Bucket{
+			{UpperBound: 1, Count: 3},
+			{UpperBound: math.Inf(1), Count: 4},
+		},
+	}, d)
+
+	// a lower count is a restart of the source
+	d, ok = delta(&deltas, newHistogram("a", 1, 2, 4))
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), d.Count)
+	assert.Equal(t, float64(4), d.Sum)
+}
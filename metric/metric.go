@@ -447,7 +447,7 @@ func (m *metric) AddTag(key, value string) {
 }
 
 func (m *metric) HasTag(key string) bool {
-	i := bytes.Index(m.tags, []byte(escape(key, "tagkey")+"="))
+	i := m.indexTag(key)
 	if i == -1 {
 		return false
 	}
@@ -457,7 +457,7 @@ func (m *metric) HasTag(key string) bool {
 func (m *metric) RemoveTag(key string) {
 	m.hashID = 0
 
-	i := bytes.Index(m.tags, []byte(escape(key, "tagkey")+"="))
+	i := m.indexTag(key)
 	if i == -1 {
 		return
 	}
@@ -471,13 +471,40 @@ func (m *metric) RemoveTag(key string) {
 	return
 }
 
+// indexTag returns the index of the tag with the given key in the tags, the
+// index being that of the key following the comma. Returns -1 if not found.
+func (m *metric) indexTag(key string) int {
+	k := []byte(escape(key, "tagkey") + "=")
+	i := 0
+	for i < len(m.tags) {
+		// skip the comma preceding each tag
+		i++
+		if bytes.HasPrefix(m.tags[i:], k) {
+			return i
+		}
+		j := indexUnescapedByte(m.tags[i:], ',')
+		if j == -1 {
+			break
+		}
+		i += j
+	}
+	return -1
+}
+
+// AddField adds a field to the metric, replacing the field with the same key
+// if there is one.
 func (m *metric) AddField(key string, value interface{}) {
-	m.fields = append(m.fields, ',')
+	if i, j := m.indexField(key); i != -1 {
+		m.fields = cutField(m.fields, i, j)
+	}
+	if len(m.fields) > 0 {
+		m.fields = append(m.fields, ',')
+	}
 	m.fields = appendField(m.fields, key, value)
 }
 
 func (m *metric) HasField(key string) bool {
-	i := bytes.Index(m.fields, []byte(escape(key, "tagkey")+"="))
+	i, _ := m.indexField(key)
 	if i == -1 {
 		return false
 	}
@@ -485,20 +512,12 @@ func (m *metric) HasField(key string) bool {
 }
 
 func (m *metric) RemoveField(key string) error {
-	i := bytes.Index(m.fields, []byte(escape(key, "tagkey")+"="))
+	i, j := m.indexField(key)
 	if i == -1 {
 		return nil
 	}
 
-	var tmp []byte
-	if i != 0 {
-		tmp = m.fields[0 : i-1]
-	}
-	j := indexUnescapedByte(m.fields[i:], ',')
-	if j != -1 {
-		tmp = append(tmp, m.fields[i+j:]...)
-	}
-
+	tmp := cutField(m.fields, i, j)
 	if len(tmp) == 0 {
 		return fmt.Errorf("Metric cannot remove final field: %s", m.fields)
 	}
@@ -507,6 +526,58 @@ func (m *metric) RemoveField(key string) error {
 	return nil
 }
 
+// indexField returns the start and end index of the field with the given key
+// in the fields, the end index being that of the following comma or the end
+// of the fields. Returns -1, -1 if not found.
+func (m *metric) indexField(key string) (int, int) {
+	k := []byte(escape(key, "tagkey") + "=")
+	i := 0
+	for i < len(m.fields) {
+		j := i + fieldLen(m.fields[i:])
+		if bytes.HasPrefix(m.fields[i:j], k) {
+			return i, j
+		}
+		// skip the comma following the field
+		i = j + 1
+	}
+	return -1, -1
+}
+
+// fieldLen returns the length of the first field in buf, string field values
+// being allowed to contain commas.
+func fieldLen(buf []byte) int {
+	i := indexUnescapedByte(buf, '=')
+	if i != -1 && i+1 < len(buf) && buf[i+1] == '"' {
+		j := indexUnescapedByteBackslashEscaping(buf[i+2:], '"')
+		if j != -1 {
+			return i + j + 3
+		}
+		return len(buf)
+	}
+	j := indexUnescapedByte(buf, ',')
+	if j == -1 {
+		return len(buf)
+	}
+	return j
+}
+
+// cutField removes the field between the indexes i and j from the fields,
+// along with the comma separating it from the other fields.
+func cutField(fields []byte, i, j int) []byte {
+	if i == 0 {
+		if j < len(fields) {
+			j++
+		}
+		return append(fields[:0], fields[j:]...)
+	}
+	return append(fields[:i-1], fields[j:]...)
+}
+
+// Accept, Reject and Drop are no-ops for metrics that are not tracked.
+func (m *metric) Accept() {}
+func (m *metric) Reject() {}
+func (m *metric) Drop()   {}
+
 func (m *metric) Copy() telegraf.Metric {
 	return copyWith(m.name, m.tags, m.fields, m.t)
 }
@@ -244,6 +244,49 @@ func TestNewMetric_FieldModifiers(t *testing.T) {
 	assert.False(t, m.HasField("value"))
 }
 
+func TestAddFieldReplaces(t *testing.T) {
+	now := time.Now()
+	fields := map[string]interface{}{
+		"value": float64(1),
+	}
+	m, err := New("cpu", nil, fields, now)
+	assert.NoError(t, err)
+
+	m.AddField("value", float64(2))
+	m.AddField("request", "/search?a=1,b=2")
+	m.AddField("request", "/search")
+	assert.Equal(t, map[string]interface{}{
+		"value":   float64(2),
+		"request": "/search",
+	}, m.Fields())
+	assert.Equal(t, `cpu value=2,request="/search" `+fmt.Sprint(now.UnixNano())+"\n", m.String())
+
+	assert.NoError(t, m.RemoveField("value"))
+	assert.Equal(t, `cpu request="/search" `+fmt.Sprint(now.UnixNano())+"\n", m.String())
+}
+
+func TestKeysMatchWholeKeys(t *testing.T) {
+	now := time.Now()
+	tags := map[string]string{
+		"resp_code": "200",
+	}
+	fields := map[string]interface{}{
+		"request": "/a,code=1",
+		"time":    float64(1),
+	}
+	m, err := New("http", tags, fields, now)
+	assert.NoError(t, err)
+
+	assert.False(t, m.HasTag("code"))
+	m.RemoveTag("code")
+	assert.Equal(t, map[string]string{"resp_code": "200"}, m.Tags())
+
+	assert.False(t, m.HasField("code"))
+	assert.False(t, m.HasField("est"))
+	assert.NoError(t, m.RemoveField("code"))
+	assert.Equal(t, fields, m.Fields())
+}
+
 func TestNewMetric_Fields(t *testing.T) {
 	now := time.Now()
 	tags := map[string]string{
@@ -0,0 +1,156 @@
+package metric
+
+import (
+	"sync/atomic"
+
+	"github.com/influxdata/telegraf"
+)
+
+// NotifyFunc is called when a tracking metric is done being processed with
+// the tracking information.
+type NotifyFunc func(track telegraf.DeliveryInfo)
+
+// WithTracking adds tracking to the metric and registers the notify function
+// to be called when processing is complete.
+func WithTracking(metric telegraf.Metric, fn NotifyFunc) (telegraf.Metric, telegraf.TrackingID) {
+	return newTrackingMetric(metric, fn)
+}
+
+// WithGroupTracking adds tracking to the metrics and registers the notify
+// function to be called when processing is complete.
+func WithGroupTracking(metrics []telegraf.Metric, fn NotifyFunc) ([]telegraf.Metric, telegraf.TrackingID) {
+	return newTrackingMetricGroup(metrics, fn)
+}
+
+// WithTrackingOf returns metric sharing the tracking of from, so that the
+// group of from is not delivered until metric has been processed too. It is
+// used for metrics created in place of a tracked metric. The metric is
+// returned as is if from is not tracked.
+func WithTrackingOf(metric telegraf.Metric, from telegraf.Metric) telegraf.Metric {
+	tm, ok := from.(*trackingMetric)
+	if !ok {
+		return metric
+	}
+	tm.d.incr()
+	return &trackingMetric{Metric: metric, d: tm.d}
+}
+
+var lastID uint64
+
+func newTrackingID() telegraf.TrackingID {
+	return telegraf.TrackingID(atomic.AddUint64(&lastID, 1))
+}
+
+type trackingData struct {
+	id          telegraf.TrackingID
+	rc          int32
+	acceptCount int32
+	rejectCount int32
+	notify      NotifyFunc
+}
+
+func (d *trackingData) incr() {
+	atomic.AddInt32(&d.rc, 1)
+}
+
+func (d *trackingData) decr() int32 {
+	return atomic.AddInt32(&d.rc, -1)
+}
+
+func (d *trackingData) accept() {
+	atomic.AddInt32(&d.acceptCount, 1)
+}
+
+func (d *trackingData) reject() {
+	atomic.AddInt32(&d.rejectCount, 1)
+}
+
+type trackingMetric struct {
+	telegraf.Metric
+	d *trackingData
+}
+
+func newTrackingMetric(metric telegraf.Metric, fn NotifyFunc) (telegraf.Metric, telegraf.TrackingID) {
+	m := &trackingMetric{
+		Metric: metric,
+		d: &trackingData{
+			id:     newTrackingID(),
+			rc:     1,
+			notify: fn,
+		},
+	}
+	return m, m.d.id
+}
+
+func newTrackingMetricGroup(group []telegraf.Metric, fn NotifyFunc) ([]telegraf.Metric, telegraf.TrackingID) {
+	d := &trackingData{
+		id:     newTrackingID(),
+		rc:     int32(len(group)),
+		notify: fn,
+	}
+
+	out := make([]telegraf.Metric, 0, len(group))
+	for _, m := range group {
+		out = append(out, &trackingMetric{Metric: m, d: d})
+	}
+
+	// An empty group has nothing left to process.
+	if len(group) == 0 {
+		d.notify(&deliveryInfo{id: d.id, accepted: 0, rejected: 0})
+	}
+	return out, d.id
+}
+
+// Copy returns a copy of the metric that shares tracking with the original;
+// the group is not delivered until both have been processed.
+func (m *trackingMetric) Copy() telegraf.Metric {
+	m.d.incr()
+	return &trackingMetric{
+		Metric: m.Metric.Copy(),
+		d:      m.d,
+	}
+}
+
+func (m *trackingMetric) Accept() {
+	m.d.accept()
+	m.decr()
+}
+
+func (m *trackingMetric) Reject() {
+	m.d.reject()
+	m.decr()
+}
+
+func (m *trackingMetric) Drop() {
+	m.decr()
+}
+
+func (m *trackingMetric) decr() {
+	v := m.d.decr()
+	if v < 0 {
+		panic("negative refcount")
+	}
+
+	if v == 0 {
+		m.d.notify(&deliveryInfo{
+			id:       m.d.id,
+			accepted: int(atomic.LoadInt32(&m.d.acceptCount)),
+			rejected: int(atomic.LoadInt32(&m.d.rejectCount)),
+		})
+	}
+}
+
+type deliveryInfo struct {
+	id       telegraf.TrackingID
+	accepted int
+	rejected int
+}
+
+func (r *deliveryInfo) ID() telegraf.TrackingID {
+	return r.id
+}
+
+// Delivered is true unless a metric in the group was rejected.
+func (r *deliveryInfo) Delivered() bool {
+	return r.rejected == 0
+}
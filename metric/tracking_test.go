@@ -0,0 +1,131 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+
+	"github.com/stretchr/testify/require"
+)
+
+type deliveries struct {
+	Info map[telegraf.TrackingID]telegraf.DeliveryInfo
+}
+
+func (d *deliveries) onDelivery(info telegraf.DeliveryInfo) {
+	d.Info[info.ID()] = info
+}
+
+func newDeliveries() *deliveries {
+	return &deliveries{
+		Info: make(map[telegraf.TrackingID]telegraf.DeliveryInfo),
+	}
+}
+
+func mustMetric(name string) telegraf.Metric {
+	m, err := New(name,
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0))
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestTracking(t *testing.T) {
+	tests := []struct {
+		name      string
+		actions   func(m telegraf.Metric)
+		delivered bool
+	}{
+		{
+			name: "accept",
+			actions: func(m telegraf.Metric) {
+				m.Accept()
+			},
+			delivered: true,
+		},
+		{
+			name: "reject",
+			actions: func(m telegraf.Metric) {
+				m.Reject()
+			},
+			delivered: false,
+		},
+		{
+			name: "drop",
+			actions: func(m telegraf.Metric) {
+				m.Drop()
+			},
+			delivered: true,
+		},
+		{
+			name: "copy accept",
+			actions: func(m telegraf.Metric) {
+				m2 := m.Copy()
+				m.Accept()
+				m2.Accept()
+			},
+			delivered: true,
+		},
+		{
+			name: "copy reject",
+			actions: func(m telegraf.Metric) {
+				m2 := m.Copy()
+				m.Accept()
+				m2.Reject()
+			},
+			delivered: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDeliveries()
+			m, id := WithTracking(mustMetric("cpu"), d.onDelivery)
+
+			tt.actions(m)
+
+			require.Len(t, d.Info, 1)
+			require.Equal(t, tt.delivered, d.Info[id].Delivered())
+		})
+	}
+}
+
+func TestTrackingOf(t *testing.T) {
+	d := newDeliveries()
+	m, id := WithTracking(mustMetric("cpu"), d.onDelivery)
+	n := WithTrackingOf(mustMetric("mem"), m)
+
+	m.Drop()
+	require.Len(t, d.Info, 0)
+
+	n.Reject()
+	require.Len(t, d.Info, 1)
+	require.False(t, d.Info[id].Delivered())
+
+	untracked := mustMetric("cpu")
+	require.True(t, untracked == WithTrackingOf(untracked, mustMetric("mem")))
+}
+
+func TestGroupTracking(t *testing.T) {
+	d := newDeliveries()
+	group := []telegraf.Metric{mustMetric("cpu"), mustMetric("mem")}
+	metrics, id := WithGroupTracking(group, d.onDelivery)
+	require.Len(t, metrics, 2)
+
+	metrics[0].Accept()
+	require.Len(t, d.Info, 0)
+
+	metrics[1].Drop()
+	require.Len(t, d.Info, 1)
+	require.True(t, d.Info[id].Delivered())
+}
+
+func TestGroupTrackingEmpty(t *testing.T) {
+	d := newDeliveries()
+	_, id := WithGroupTracking(nil, d.onDelivery)
+	require.Len(t, d.Info, 1)
+	require.True(t, d.Info[id].Delivered())
+}
@@ -29,3 +29,22 @@ type ServiceOutput interface {
 	// Stop the "service" that will provide an Output
 	Stop()
 }
+
+// ConcurrentOutput is an Output that can write batches of metrics from
+// several goroutines at once.
+type ConcurrentOutput interface {
+	Output
+	// SupportsConcurrentWrites returns true if Write may be called
+	// concurrently.
+	SupportsConcurrentWrites() bool
+}
+
+// ErrorClassifier is an Output that can tell apart errors worth retrying
+// from errors that will recur no matter how often a batch is written, such
+// as the server rejecting malformed data.
+type ErrorClassifier interface {
+	Output
+	// IsRetryable returns false if the batch that caused err should be
+	// dropped rather than retried.
+	IsRetryable(err error) bool
+}
@@ -1,10 +1,12 @@
 package histogram
 
 import (
+	"math"
 	"sort"
 	"strconv"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 )
 
@@ -16,7 +18,8 @@ const bucketInf = "+Inf"
 
 // HistogramAggregator is aggregator with histogram configs and particular histograms for defined metrics
 type HistogramAggregator struct {
-	Configs []config `toml:"config"`
+	Configs        []config `toml:"config"`
+	TypedHistogram bool     `toml:"typed_histogram"`
 
 	buckets bucketsByMetrics
 	cache   map[uint64]metricHistogramCollection
@@ -41,6 +44,7 @@ type buckets []float64
 // metricHistogramCollection aggregates the histogram data
 type metricHistogramCollection struct {
 	histogramCollection map[string]counts
+	sums                map[string]float64
 	name                string
 	tags                map[string]string
 }
@@ -72,6 +76,12 @@ var sampleConfig = `
   ## aggregator and will not get sent to the output plugins.
   drop_original = false
 
+  ## If true, each field is emitted as a single metric of the histogram type
+  ## named "<measurement>_<field>", with a field per bucket upper bound along
+  ## with the sum and count of the values, rather than as a "<field>_bucket"
+  ## field of a metric per bucket with the "le" tag.
+  # typed_histogram = false
+
   ## Example config that aggregates all fields of the metric.
   # [[aggregators.histogram.config]]
   #   ## The set of buckets.
@@ -120,6 +130,7 @@ func (h *HistogramAggregator) Add(in telegraf.Metric) {
 			name:                in.Name(),
 			tags:                in.Tags(),
 			histogramCollection: make(map[string]counts),
+			sums:                make(map[string]float64),
 		}
 	}
 
@@ -132,6 +143,7 @@ func (h *HistogramAggregator) Add(in telegraf.Metric) {
 			if value, ok := convert(value); ok {
 				index := sort.SearchFloat64s(buckets, value)
 				agr.histogramCollection[field][index]++
+				agr.sums[field] += value
 			}
 		}
 	}
@@ -141,6 +153,11 @@ func (h *HistogramAggregator) Add(in telegraf.Metric) {
 
 // Push returns histogram values for metrics
 func (h *HistogramAggregator) Push(acc telegraf.Accumulator) {
+	if h.TypedHistogram {
+		h.pushTyped(acc)
+		return
+	}
+
 	metricsWithGroupedFields := []groupedByCountFields{}
 
 	for _, aggregate := range h.cache {
@@ -154,6 +171,23 @@ func (h *HistogramAggregator) Push(acc telegraf.Accumulator) {
 	}
 }
 
+// pushTyped returns a histogram metric for each field of the metrics
+func (h *HistogramAggregator) pushTyped(acc telegraf.Accumulator) {
+	for _, aggregate := range h.cache {
+		for field, counts := range aggregate.histogramCollection {
+			d := &metric.Distribution{Sum: aggregate.sums[field]}
+			for index, bucket := range h.getBuckets(aggregate.name, field) {
+				d.Count += uint64(counts[index])
+				d.Buckets = append(d.Buckets, metric.Bucket{UpperBound: bucket, Count: d.Count})
+			}
+			d.Count += uint64(counts[len(counts)-1])
+			d.Buckets = append(d.Buckets, metric.Bucket{UpperBound: math.Inf(1), Count: d.Count})
+
+			acc.AddHistogram(aggregate.name+"_"+field, d.Fields(), copyTags(aggregate.tags))
+		}
+	}
+}
+
 // groupFieldsByBuckets groups fields by metric buckets which are represented as tags
 func (h *HistogramAggregator) groupFieldsByBuckets(
 	metricsWithGroupedFields *[]groupedByCountFields,
@@ -170,6 +170,32 @@ func TestWrongBucketsOrder(t *testing.T) {
 	histogram.Add(firstMetric2)
 }
 
+// TestHistogramTyped tests the histogram typed metric of each field
+func TestHistogramTyped(t *testing.T) {
+	var cfg []config
+	cfg = append(cfg, config{Metric: "first_metric_name", Fields: []string{"a"}, Buckets: []float64{0.0, 10.0, 20.0}})
+	histogram := NewTestHistogram(cfg).(*HistogramAggregator)
+	histogram.TypedHistogram = true
+
+	acc := &testutil.Accumulator{}
+
+	histogram.Add(firstMetric1)
+	histogram.Add(firstMetric2)
+	histogram.Push(acc)
+
+	assert.Len(t, acc.Metrics, 1)
+	acc.AssertContainsTaggedFields(t, "first_metric_name_a",
+		map[string]interface{}{
+			"0":     float64(0),
+			"10":    float64(0),
+			"20":    float64(2),
+			"+Inf":  float64(2),
+			"sum":   float64(15.3) + float64(15.9),
+			"count": float64(2),
+		},
+		map[string]string{"tag_name": "tag_value"})
+}
+
 // assertContainsTaggedField is help functions to test histogram data
 func assertContainsTaggedField(t *testing.T, acc *testutil.Accumulator, metricName string, fields map[string]interface{}, le string) {
 	acc.Lock()
@@ -40,11 +40,18 @@ type AMQPConsumer struct {
 	// Use SSL but skip chain & host verification
 	InsecureSkipVerify bool
 
+	// Maximum number of messages read but not yet written to the outputs
+	MaxUndeliveredMessages int `toml:"max_undelivered_messages"`
+
 	parser parsers.Parser
 	conn   *amqp.Connection
 	wg     *sync.WaitGroup
+	acc    telegraf.TrackingAccumulator
 }
 
+type empty struct{}
+type semaphore chan empty
+
 type externalAuth struct{}
 
 func (a *externalAuth) Mechanism() string {
@@ -57,6 +64,8 @@ func (a *externalAuth) Response() string {
 const (
 	DefaultAuthMethod    = "PLAIN"
 	DefaultPrefetchCount = 50
+
+	DefaultMaxUndeliveredMessages = 1000
 )
 
 func (a *AMQPConsumer) SampleConfig() string {
@@ -73,6 +82,12 @@ func (a *AMQPConsumer) SampleConfig() string {
   ## Maximum number of messages server should give to the worker.
   prefetch_count = 50
 
+  ## Maximum number of messages read but not yet written to an output.
+  ## Messages are only acknowledged once the metrics created from them have
+  ## been delivered. This should be no larger than prefetch_count, otherwise
+  ## prefetch_count limits the messages in flight.
+  # max_undelivered_messages = 1000
+
   ## Auth method. PLAIN and EXTERNAL are supported
   ## Using EXTERNAL requires enabling the rabbitmq_auth_mechanism_ssl plugin as
   ## described here: https://www.rabbitmq.com/plugins.html
@@ -135,6 +150,11 @@ func (a *AMQPConsumer) Start(acc telegraf.Accumulator) error {
 		return err
 	}
 
+	if a.MaxUndeliveredMessages <= 0 {
+		a.MaxUndeliveredMessages = DefaultMaxUndeliveredMessages
+	}
+	a.acc = acc.WithTracking(a.MaxUndeliveredMessages)
+
 	msgs, err := a.connect(amqpConf)
 	if err != nil {
 		return err
@@ -142,7 +162,7 @@ func (a *AMQPConsumer) Start(acc telegraf.Accumulator) error {
 
 	a.wg = &sync.WaitGroup{}
 	a.wg.Add(1)
-	go a.process(msgs, acc)
+	go a.process(msgs)
 
 	go func() {
 		err := <-a.conn.NotifyClose(make(chan *amqp.Error))
@@ -160,7 +180,7 @@ func (a *AMQPConsumer) Start(acc telegraf.Accumulator) error {
 			}
 
 			a.wg.Add(1)
-			go a.process(msgs, acc)
+			go a.process(msgs)
 			break
 		}
 	}()
@@ -243,21 +263,60 @@ func (a *AMQPConsumer) connect(amqpConf *amqp.Config) (<-chan amqp.Delivery, err
 }
 
 // Read messages from queue and add them to the Accumulator
-func (a *AMQPConsumer) process(msgs <-chan amqp.Delivery, acc telegraf.Accumulator) {
+func (a *AMQPConsumer) process(msgs <-chan amqp.Delivery) {
 	defer a.wg.Done()
-	for d := range msgs {
-		metrics, err := a.parser.Parse(d.Body)
-		if err != nil {
-			log.Printf("E! %v: error parsing metric - %v", err, string(d.Body))
-		} else {
-			for _, m := range metrics {
-				acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+
+	sem := make(semaphore, a.MaxUndeliveredMessages)
+	deliveries := make(map[telegraf.TrackingID]amqp.Delivery)
+	for {
+		select {
+		case track := <-a.acc.Delivered():
+			a.onDelivery(track, deliveries, sem)
+		case sem <- empty{}:
+			select {
+			case track := <-a.acc.Delivered():
+				<-sem
+				a.onDelivery(track, deliveries, sem)
+			case d, ok := <-msgs:
+				if !ok {
+					log.Printf("I! AMQP consumer queue closed")
+					return
+				}
+				metrics, err := a.parser.Parse(d.Body)
+				if err != nil {
+					log.Printf("E! %v: error parsing metric - %v", err, string(d.Body))
+					<-sem
+					d.Ack(false)
+					continue
+				}
+				id := a.acc.AddTrackingMetricGroup(metrics)
+				deliveries[id] = d
 			}
 		}
+	}
+}
+
+// onDelivery acknowledges the message once its metrics have been written,
+// or rejects it if they could not be.
+func (a *AMQPConsumer) onDelivery(
+	track telegraf.DeliveryInfo,
+	deliveries map[telegraf.TrackingID]amqp.Delivery,
+	sem semaphore,
+) {
+	d, ok := deliveries[track.ID()]
+	if !ok {
+		// delivery from a previous connection, the server will redeliver
+		// the message.
+		return
+	}
+	delete(deliveries, track.ID())
+	<-sem
 
+	if track.Delivered() {
 		d.Ack(false)
+	} else {
+		d.Reject(false)
 	}
-	log.Printf("I! AMQP consumer queue closed")
 }
 
 func (a *AMQPConsumer) Stop() {
@@ -273,8 +332,9 @@ func (a *AMQPConsumer) Stop() {
 func init() {
 	inputs.Add("amqp_consumer", func() telegraf.Input {
 		return &AMQPConsumer{
-			AuthMethod:    DefaultAuthMethod,
-			PrefetchCount: DefaultPrefetchCount,
+			AuthMethod:             DefaultAuthMethod,
+			PrefetchCount:          DefaultPrefetchCount,
+			MaxUndeliveredMessages: DefaultMaxUndeliveredMessages,
 		}
 	})
 }
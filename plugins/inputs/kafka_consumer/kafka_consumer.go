@@ -42,6 +42,9 @@ type Kafka struct {
 	// TODO remove PointBuffer, legacy support
 	PointBuffer int
 
+	// Maximum number of messages read but not yet written to the outputs
+	MaxUndeliveredMessages int `toml:"max_undelivered_messages"`
+
 	Offset string
 	parser parsers.Parser
 
@@ -54,13 +57,31 @@ type Kafka struct {
 	done chan struct{}
 
 	// keep the accumulator internally:
-	acc telegraf.Accumulator
+	acc telegraf.TrackingAccumulator
+
+	// messages waiting for delivery, limited by sem
+	messages map[telegraf.TrackingID]*sarama.ConsumerMessage
+	sem      semaphore
+
+	// lowest offset per partition whose metrics were not delivered; offsets
+	// from there on are not committed so the messages are read again.
+	undelivered map[partition]int64
 
 	// doNotCommitMsgs tells the parser not to call CommitUpTo on the consumer
 	// this is mostly for test purposes, but there may be a use-case for it later.
 	doNotCommitMsgs bool
 }
 
+type empty struct{}
+type semaphore chan empty
+
+type partition struct {
+	topic     string
+	partition int32
+}
+
+const defaultMaxUndeliveredMessages = 1000
+
 var sampleConfig = `
   ## kafka servers
   brokers = ["localhost:9092"]
@@ -92,6 +113,12 @@ var sampleConfig = `
   ## Maximum length of a message to consume, in bytes (default 0/unlimited);
   ## larger messages are dropped
   max_message_len = 65536
+
+  ## Maximum number of messages read from Kafka but not yet written to an
+  ## output. Offsets are only committed once the metrics created from a
+  ## message have been delivered, so up to this many messages may be read
+  ## again after a restart.
+  # max_undelivered_messages = 1000
 `
 
 func (k *Kafka) SampleConfig() string {
@@ -111,7 +138,13 @@ func (k *Kafka) Start(acc telegraf.Accumulator) error {
 	defer k.Unlock()
 	var clusterErr error
 
-	k.acc = acc
+	if k.MaxUndeliveredMessages <= 0 {
+		k.MaxUndeliveredMessages = defaultMaxUndeliveredMessages
+	}
+	k.acc = acc.WithTracking(k.MaxUndeliveredMessages)
+	k.messages = make(map[telegraf.TrackingID]*sarama.ConsumerMessage)
+	k.undelivered = make(map[partition]int64)
+	k.sem = make(semaphore, k.MaxUndeliveredMessages)
 
 	config := cluster.NewConfig()
 	config.Consumer.Return.Errors = true
@@ -180,34 +213,91 @@ func (k *Kafka) receiver() {
 		select {
 		case <-k.done:
 			return
+		case track := <-k.acc.Delivered():
+			k.onDelivery(track)
 		case err := <-k.errs:
 			if err != nil {
 				k.acc.AddError(fmt.Errorf("Consumer Error: %s\n", err))
 			}
-		case msg := <-k.in:
-			if k.MaxMessageLen != 0 && len(msg.Value) > k.MaxMessageLen {
-				k.acc.AddError(fmt.Errorf("Message longer than max_message_len (%d > %d)",
-					len(msg.Value), k.MaxMessageLen))
-			} else {
-				metrics, err := k.parser.Parse(msg.Value)
+		case k.sem <- empty{}:
+			// A slot is reserved, wait for a message while continuing to
+			// handle deliveries.
+			select {
+			case <-k.done:
+				return
+			case track := <-k.acc.Delivered():
+				<-k.sem
+				k.onDelivery(track)
+			case err := <-k.errs:
+				<-k.sem
 				if err != nil {
-					k.acc.AddError(fmt.Errorf("Message Parse Error\nmessage: %s\nerror: %s",
-						string(msg.Value), err.Error()))
+					k.acc.AddError(fmt.Errorf("Consumer Error: %s\n", err))
 				}
-				for _, metric := range metrics {
-					k.acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+			case msg := <-k.in:
+				if err := k.onMessage(msg); err != nil {
+					k.acc.AddError(err)
+					// The message can never be delivered, so commit it now.
+					<-k.sem
+					k.markOffset(msg)
 				}
 			}
+		}
+	}
+}
 
-			if !k.doNotCommitMsgs {
-				// TODO(cam) this locking can be removed if this PR gets merged:
-				// https://github.com/wvanbergen/kafka/pull/84
-				k.Lock()
-				k.Cluster.MarkOffset(msg, "")
-				k.Unlock()
-			}
+// onMessage parses the message and adds the metrics as a tracked group; the
+// offset is committed once the group is delivered.
+func (k *Kafka) onMessage(msg *sarama.ConsumerMessage) error {
+	if k.MaxMessageLen != 0 && len(msg.Value) > k.MaxMessageLen {
+		return fmt.Errorf("Message longer than max_message_len (%d > %d)",
+			len(msg.Value), k.MaxMessageLen)
+	}
+
+	metrics, err := k.parser.Parse(msg.Value)
+	if err != nil {
+		return fmt.Errorf("Message Parse Error\nmessage: %s\nerror: %s",
+			string(msg.Value), err.Error())
+	}
+
+	id := k.acc.AddTrackingMetricGroup(metrics)
+	k.messages[id] = msg
+	return nil
+}
+
+func (k *Kafka) onDelivery(track telegraf.DeliveryInfo) {
+	msg, ok := k.messages[track.ID()]
+	if !ok {
+		return
+	}
+	delete(k.messages, track.ID())
+	<-k.sem
+
+	if !track.Delivered() {
+		log.Printf("E! Kafka message at offset %d of partition %d was not "+
+			"delivered, it is not committed\n", msg.Offset, msg.Partition)
+		p := partition{msg.Topic, msg.Partition}
+		if offset, ok := k.undelivered[p]; !ok || msg.Offset < offset {
+			k.undelivered[p] = msg.Offset
 		}
+		return
+	}
+	k.markOffset(msg)
+}
+
+func (k *Kafka) markOffset(msg *sarama.ConsumerMessage) {
+	// Committing a later offset would skip an undelivered message.
+	p := partition{msg.Topic, msg.Partition}
+	if offset, ok := k.undelivered[p]; ok && msg.Offset >= offset {
+		return
+	}
+	if k.doNotCommitMsgs {
+		return
 	}
+	// TODO(cam) this locking can be removed if this PR gets merged:
+	// https://github.com/wvanbergen/kafka/pull/84
+	k.Lock()
+	k.Cluster.MarkOffset(msg, "")
+	k.Unlock()
 }
 
 func (k *Kafka) Stop() {
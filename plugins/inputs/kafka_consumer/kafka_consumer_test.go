@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/testutil"
 
@@ -29,6 +30,9 @@ func newTestKafka() (*Kafka, chan *sarama.ConsumerMessage) {
 		doNotCommitMsgs: true,
 		errs:            make(chan error, 1000),
 		done:            make(chan struct{}),
+		messages:        make(map[telegraf.TrackingID]*sarama.ConsumerMessage),
+		undelivered:     make(map[partition]int64),
+		sem:             make(semaphore, 10),
 	}
 	return &k, in
 }
@@ -140,9 +144,56 @@ func TestRunParserAndGatherJSON(t *testing.T) {
 		})
 }
 
+// Test that a message is only released once its metrics are delivered
+func TestMessageReleasedOnDelivery(t *testing.T) {
+	k, _ := newTestKafka()
+	acc := testutil.Accumulator{}
+	k.acc = acc.WithTracking(10)
+	k.parser, _ = parsers.NewInfluxParser()
+
+	k.sem <- empty{}
+	err := k.onMessage(saramaMsg(testMsg))
+	assert.NoError(t, err)
+	assert.Len(t, k.messages, 1)
+
+	track := <-k.acc.Delivered()
+	assert.True(t, track.Delivered())
+	k.onDelivery(track)
+	assert.Len(t, k.messages, 0)
+	assert.Len(t, k.sem, 0)
+}
+
+type rejected telegraf.TrackingID
+
+func (r rejected) ID() telegraf.TrackingID { return telegraf.TrackingID(r) }
+func (r rejected) Delivered() bool         { return false }
+
+// Test that a rejected message holds back the offsets of its partition
+func TestRejectedMessageNotCommitted(t *testing.T) {
+	k, _ := newTestKafka()
+
+	first := saramaMsg(testMsg)
+	first.Offset = 5
+	later := saramaMsg(testMsg)
+	later.Offset = 6
+	k.messages[1] = first
+	k.sem <- empty{}
+
+	k.onDelivery(rejected(1))
+	assert.Len(t, k.messages, 0)
+	assert.Len(t, k.sem, 0)
+	assert.Equal(t, map[partition]int64{{"telegraf", 0}: 5}, k.undelivered)
+
+	// Offsets from the rejected message on must not be committed; the
+	// consumer would panic on the unset cluster if they were.
+	k.doNotCommitMsgs = false
+	k.markOffset(later)
+}
+
 func saramaMsg(val string) *sarama.ConsumerMessage {
 	return &sarama.ConsumerMessage{
 		Key:       nil,
+		Topic:     "telegraf",
 		Value:     []byte(val),
 		Offset:    0,
 		Partition: 0,
@@ -4,7 +4,6 @@ package logparser
 
 import (
 	"fmt"
-	"log"
 	"reflect"
 	"strings"
 	"sync"
@@ -16,7 +15,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs"
 
 	// Parsers
-	"github.com/influxdata/telegraf/plugins/inputs/logparser/grok"
+	"github.com/influxdata/telegraf/plugins/parsers/grok"
 )
 
 const (
@@ -40,6 +39,8 @@ type LogParserPlugin struct {
 	FromBeginning bool
 	WatchMethod   string
 
+	Log telegraf.Logger `toml:"-"`
+
 	tailers map[string]*tail.Tail
 	lines   chan logEntry
 	done    chan struct{}
@@ -184,7 +185,7 @@ func (l *LogParserPlugin) tailNewfiles(fromBeginning bool) error {
 	for _, filepath := range l.Files {
 		g, err := globpath.Compile(filepath)
 		if err != nil {
-			log.Printf("E! Error Glob %s failed to compile, %s", filepath, err)
+			l.Log.Errorf("Glob %s failed to compile: %s", filepath, err)
 			continue
 		}
 		files := g.Match()
@@ -228,8 +229,7 @@ func (l *LogParserPlugin) receiver(tailer *tail.Tail) {
 	for line = range tailer.Lines {
 
 		if line.Err != nil {
-			log.Printf("E! Error tailing file %s, Error: %s\n",
-				tailer.Filename, line.Err)
+			l.Log.Errorf("Error tailing file %s: %s", tailer.Filename, line.Err)
 			continue
 		}
 
@@ -275,7 +275,7 @@ func (l *LogParserPlugin) parser() {
 					l.acc.AddFields(m.Name(), m.Fields(), tags, m.Time())
 				}
 			} else {
-				log.Println("E! Error parsing log line: " + err.Error())
+				l.Log.Errorf("Error parsing log line: %s", err)
 			}
 		}
 	}
@@ -289,7 +289,7 @@ func (l *LogParserPlugin) Stop() {
 	for _, t := range l.tailers {
 		err := t.Stop()
 		if err != nil {
-			log.Printf("E! Error stopping tail on file %s\n", t.Filename)
+			l.Log.Errorf("Error stopping tail on file %s", t.Filename)
 		}
 		t.Cleanup()
 	}
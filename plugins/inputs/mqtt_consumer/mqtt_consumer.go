@@ -34,6 +34,9 @@ type MQTTConsumer struct {
 	PersistentSession bool
 	ClientID          string `toml:"client_id"`
 
+	// Maximum number of messages read but not yet written to the outputs
+	MaxUndeliveredMessages int `toml:"max_undelivered_messages"`
+
 	// Path to CA file
 	SSLCA string `toml:"ssl_ca"`
 	// Path to host cert file
@@ -50,11 +53,18 @@ type MQTTConsumer struct {
 	done chan struct{}
 
 	// keep the accumulator internally:
-	acc telegraf.Accumulator
+	acc telegraf.TrackingAccumulator
+	// limits the number of undelivered messages
+	sem semaphore
 
 	connected bool
 }
 
+type empty struct{}
+type semaphore chan empty
+
+const defaultMaxUndeliveredMessages = 1000
+
 var sampleConfig = `
   ## MQTT broker URLs to be used. The format should be scheme://host:port,
   ## schema can be tcp, ssl, or ws.
@@ -79,6 +89,13 @@ var sampleConfig = `
   # If empty, a random client ID will be generated.
   client_id = ""
 
+  ## Maximum number of messages read but not yet written to an output. When
+  ## the limit is reached no further messages are read until metrics have
+  ## been delivered. Messages are still acknowledged to the broker on
+  ## receipt by the MQTT client, so unlike the other queue consumers messages
+  ## not yet written are lost when Telegraf stops.
+  # max_undelivered_messages = 1000
+
   ## username and password to connect MQTT server.
   # username = "telegraf"
   # password = "metricsmetricsmetricsmetrics"
@@ -119,7 +136,11 @@ func (m *MQTTConsumer) Start(acc telegraf.Accumulator) error {
 			" = true, you MUST also set client_id")
 	}
 
-	m.acc = acc
+	if m.MaxUndeliveredMessages <= 0 {
+		m.MaxUndeliveredMessages = defaultMaxUndeliveredMessages
+	}
+	m.acc = acc.WithTracking(m.MaxUndeliveredMessages)
+	m.sem = make(semaphore, m.MaxUndeliveredMessages)
 	if m.QoS > 2 || m.QoS < 0 {
 		return fmt.Errorf("MQTT Consumer, invalid QoS value: %d", m.QoS)
 	}
@@ -185,23 +206,43 @@ func (m *MQTTConsumer) receiver() {
 		select {
 		case <-m.done:
 			return
-		case msg := <-m.in:
-			topic := msg.Topic()
-			metrics, err := m.parser.Parse(msg.Payload())
-			if err != nil {
-				m.acc.AddError(fmt.Errorf("E! MQTT Parse Error\nmessage: %s\nerror: %s",
-					string(msg.Payload()), err.Error()))
-			}
-
-			for _, metric := range metrics {
-				tags := metric.Tags()
-				tags["topic"] = topic
-				m.acc.AddFields(metric.Name(), metric.Fields(), tags, metric.Time())
+		case <-m.acc.Delivered():
+			<-m.sem
+		case m.sem <- empty{}:
+			select {
+			case <-m.done:
+				return
+			case <-m.acc.Delivered():
+				<-m.sem
+				<-m.sem
+			case msg := <-m.in:
+				if err := m.onMessage(msg); err != nil {
+					m.acc.AddError(err)
+					<-m.sem
+				}
 			}
 		}
 	}
 }
 
+func (m *MQTTConsumer) onMessage(msg mqtt.Message) error {
+	topic := msg.Topic()
+	metrics, err := m.parser.Parse(msg.Payload())
+	if err != nil {
+		return fmt.Errorf("E! MQTT Parse Error\nmessage: %s\nerror: %s",
+			string(msg.Payload()), err.Error())
+	}
+
+	for _, metric := range metrics {
+		metric.AddTag("topic", topic)
+	}
+	m.acc.AddTrackingMetricGroup(metrics)
+	return nil
+}
+
+// recvMessage queues a message for the receiver. The client has already
+// acknowledged it to the broker, it has no support for acknowledging
+// messages once they are delivered.
 func (m *MQTTConsumer) recvMessage(_ mqtt.Client, msg mqtt.Message) {
 	m.in <- msg
 }
@@ -26,6 +26,7 @@ func newTestMQTTConsumer() (*MQTTConsumer, chan mqtt.Message) {
 		Servers:   []string{"localhost:1883"},
 		in:        in,
 		done:      make(chan struct{}),
+		sem:       make(semaphore, 100),
 		connected: true,
 	}
 
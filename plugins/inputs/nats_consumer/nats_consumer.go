@@ -32,6 +32,9 @@ type natsConsumer struct {
 	PendingMessageLimit int
 	PendingBytesLimit   int
 
+	// Maximum number of messages read but not yet written to the outputs
+	MaxUndeliveredMessages int `toml:"max_undelivered_messages"`
+
 	// Legacy metric buffer support
 	MetricBuffer int
 
@@ -47,9 +50,16 @@ type natsConsumer struct {
 	// channel for all NATS read errors
 	errs chan error
 	done chan struct{}
-	acc  telegraf.Accumulator
+	acc  telegraf.TrackingAccumulator
+	// limits the number of undelivered messages
+	sem semaphore
 }
 
+type empty struct{}
+type semaphore chan empty
+
+const defaultMaxUndeliveredMessages = 1000
+
 var sampleConfig = `
   ## urls of NATS servers
   # servers = ["nats://localhost:4222"]
@@ -65,6 +75,12 @@ var sampleConfig = `
   # pending_message_limit = 65536
   # pending_bytes_limit = 67108864
 
+  ## Maximum number of messages read but not yet written to an output. When
+  ## the limit is reached no further messages are read until metrics have
+  ## been delivered, and new messages queue in the subscription up to the
+  ## pending limits.
+  # max_undelivered_messages = 1000
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -97,7 +113,11 @@ func (n *natsConsumer) Start(acc telegraf.Accumulator) error {
 	n.Lock()
 	defer n.Unlock()
 
-	n.acc = acc
+	if n.MaxUndeliveredMessages <= 0 {
+		n.MaxUndeliveredMessages = defaultMaxUndeliveredMessages
+	}
+	n.acc = acc.WithTracking(n.MaxUndeliveredMessages)
+	n.sem = make(semaphore, n.MaxUndeliveredMessages)
 
 	var connectErr error
 
@@ -161,16 +181,29 @@ func (n *natsConsumer) receiver() {
 		select {
 		case <-n.done:
 			return
+		case <-n.acc.Delivered():
+			<-n.sem
 		case err := <-n.errs:
 			n.acc.AddError(fmt.Errorf("E! error reading from %s\n", err.Error()))
-		case msg := <-n.in:
-			metrics, err := n.parser.Parse(msg.Data)
-			if err != nil {
-				n.acc.AddError(fmt.Errorf("E! subject: %s, error: %s", msg.Subject, err.Error()))
-			}
-
-			for _, metric := range metrics {
-				n.acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+		case n.sem <- empty{}:
+			select {
+			case <-n.done:
+				return
+			case <-n.acc.Delivered():
+				<-n.sem
+				<-n.sem
+			case err := <-n.errs:
+				<-n.sem
+				n.acc.AddError(fmt.Errorf("E! error reading from %s\n", err.Error()))
+			case msg := <-n.in:
+				metrics, err := n.parser.Parse(msg.Data)
+				if err != nil {
+					n.acc.AddError(fmt.Errorf("E! subject: %s, error: %s", msg.Subject, err.Error()))
+					<-n.sem
+					continue
+				}
+
+				n.acc.AddTrackingMetricGroup(metrics)
 			}
 		}
 	}
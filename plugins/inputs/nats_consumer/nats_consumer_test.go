@@ -27,6 +27,7 @@ func newTestNatsConsumer() (*natsConsumer, chan *nats.Msg) {
 		in:         in,
 		errs:       make(chan error, metricBuffer),
 		done:       make(chan struct{}),
+		sem:        make(semaphore, metricBuffer),
 	}
 	return n, in
 }
@@ -17,6 +17,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	internalmetric "github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/selfstat"
 )
@@ -58,6 +59,10 @@ type Statsd struct {
 	Percentiles     []int
 	PercentileLimit int
 
+	// TypedTimings emits timings as summary metrics, with the percentiles as
+	// quantile fields.
+	TypedTimings bool `toml:"typed_timings"`
+
 	DeleteGauges   bool
 	DeleteCounters bool
 	DeleteSets     bool
@@ -206,6 +211,12 @@ const sampleConfig = `
   ## Percentiles to calculate for timing & histogram stats
   percentiles = [90]
 
+  ## Emit timing & histogram stats as summary metrics, with a field per
+  ## percentile named after its quantile, such as "0.9", rather than
+  ## "90_percentile". Timer fields become metrics named
+  ## "<measurement>_<field>".
+  # typed_timings = false
+
   ## separator to use between elements of a statsd metric
   metric_separator = "_"
 
@@ -239,6 +250,11 @@ func (s *Statsd) Gather(acc telegraf.Accumulator) error {
 	now := time.Now()
 
 	for _, metric := range s.timings {
+		if s.TypedTimings {
+			s.addTypedTimings(acc, metric, now)
+			continue
+		}
+
 		// Defining a template to parse field names for timers allows us to split
 		// out multiple fields per timer. In this case we prefix each stat with the
 		// field name and store these all in a single measurement.
@@ -294,6 +310,34 @@ func (s *Statsd) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// addTypedTimings adds a summary metric for each field of a timer.
+func (s *Statsd) addTypedTimings(acc telegraf.Accumulator, timing cachedtimings, now time.Time) {
+	for fieldName, stats := range timing.fields {
+		name := timing.name
+		if fieldName != defaultFieldName {
+			name = name + "_" + fieldName
+		}
+
+		d := &internalmetric.Distribution{
+			Sum:   stats.Sum(),
+			Count: uint64(stats.Count()),
+		}
+		for _, percentile := range s.Percentiles {
+			d.Quantiles = append(d.Quantiles, internalmetric.Quantile{
+				Quantile: float64(percentile) / 100,
+				Value:    stats.Percentile(percentile),
+			})
+		}
+
+		fields := d.Fields()
+		fields["mean"] = stats.Mean()
+		fields["stddev"] = stats.Stddev()
+		fields["upper"] = stats.Upper()
+		fields["lower"] = stats.Lower()
+		acc.AddSummary(name, fields, timing.tags, now)
+	}
+}
+
 func (s *Statsd) Start(_ telegraf.Accumulator) error {
 	// Make data structures
 	s.gauges = make(map[string]cachedgauge)
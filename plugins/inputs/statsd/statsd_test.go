@@ -445,6 +445,43 @@ func TestParse_Timings(t *testing.T) {
 	acc.AssertContainsFields(t, "test_timing", valid)
 }
 
+func TestParse_Timings_Typed(t *testing.T) {
+	s := NewTestStatsd()
+	s.Templates = []string{"measurement.field"}
+	s.Percentiles = []int{50, 90}
+	s.TypedTimings = true
+	acc := &testutil.Accumulator{}
+
+	validLines := []string{
+		"test_timing.success:1|ms",
+		"test_timing.success:11|ms",
+		"test_timing.success:1|ms",
+		"test_timing.success:1|ms",
+		"test_timing.success:1|ms",
+	}
+
+	for _, line := range validLines {
+		err := s.parseStatsdLine(line)
+		if err != nil {
+			t.Errorf("Parsing line %s should not have resulted in an error\n", line)
+		}
+	}
+	s.Gather(acc)
+
+	valid := map[string]interface{}{
+		"0.5":    float64(1),
+		"0.9":    float64(11),
+		"count":  float64(5),
+		"lower":  float64(1),
+		"mean":   float64(3),
+		"stddev": float64(4),
+		"sum":    float64(15),
+		"upper":  float64(11),
+	}
+
+	acc.AssertContainsFields(t, "test_timing_success", valid)
+}
+
 func TestParseScientificNotation(t *testing.T) {
 	s := NewTestStatsd()
 	sciNotationLines := []string{
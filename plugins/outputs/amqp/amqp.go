@@ -42,6 +42,8 @@ type AMQP struct {
 	// Delivery Mode controls if a published message is persistent
 	// Valid options are "transient" and "persistent". default: "transient"
 	DeliveryMode string
+	// Serialize the metrics of each routing key as a single batch
+	UseBatchFormat bool `toml:"use_batch_format"`
 
 	// Path to CA file
 	SSLCA string `toml:"ssl_ca"`
@@ -111,6 +113,11 @@ var sampleConfig = `
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   data_format = "influx"
+
+  ## Metrics with the same routing key are always published in a single
+  ## message, serialize them as a whole rather than one metric at a time,
+  ## such as into a JSON document with the json data format.
+  # use_batch_format = false
 `
 
 func (a *AMQP) SetSerializer(serializer serializers.Serializer) {
@@ -237,8 +244,7 @@ func (q *AMQP) Write(metrics []telegraf.Metric) error {
 		return fmt.Errorf("connection is not open")
 	}
 
-	outbuf := make(map[string][]byte)
-
+	batches := make(map[string][]telegraf.Metric)
 	for _, metric := range metrics {
 		var key string
 		if q.RoutingTag != "" {
@@ -246,13 +252,27 @@ func (q *AMQP) Write(metrics []telegraf.Metric) error {
 				key = h
 			}
 		}
+		batches[key] = append(batches[key], metric)
+	}
 
-		buf, err := q.serializer.Serialize(metric)
-		if err != nil {
-			return err
+	outbuf := make(map[string][]byte)
+	for key, batch := range batches {
+		if q.UseBatchFormat {
+			buf, err := serializers.SerializeBatch(q.serializer, batch)
+			if err != nil {
+				return err
+			}
+			outbuf[key] = buf
+			continue
 		}
 
-		outbuf[key] = append(outbuf[key], buf...)
+		for _, metric := range batch {
+			buf, err := q.serializer.Serialize(metric)
+			if err != nil {
+				return err
+			}
+			outbuf[key] = append(outbuf[key], buf...)
+		}
 	}
 
 	for key, buf := range outbuf {
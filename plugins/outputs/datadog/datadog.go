@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"sort"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
@@ -75,6 +77,13 @@ func (d *Datadog) Write(metrics []telegraf.Metric) error {
 	metricCounter := 0
 
 	for _, m := range metrics {
+		if dist, ok := metric.DecodeDistribution(m); ok {
+			dogMs := buildDistributionMetrics(m, dist)
+			tempSeries = append(tempSeries, dogMs...)
+			metricCounter += len(dogMs)
+			continue
+		}
+
 		if dogMs, err := buildMetrics(m); err == nil {
 			for fieldName, dogM := range dogMs {
 				// name of the datadog measurement
@@ -158,6 +167,43 @@ func buildMetrics(m telegraf.Metric) (map[string]Point, error) {
 	return ms, nil
 }
 
+// buildDistributionMetrics builds the series of a histogram or summary as
+// the Datadog OpenMetrics integration does: the cumulative count of each
+// bucket is "<name>.count" with the "upper_bound" tag, the value of each
+// quantile is "<name>.quantile" with the "quantile" tag, along with
+// "<name>.sum" and "<name>.count".
+func buildDistributionMetrics(m telegraf.Metric, d *metric.Distribution) []*Metric {
+	host, _ := m.Tags()["host"]
+	timestamp := float64(m.Time().Unix())
+	newMetric := func(name string, value float64, tags map[string]string) *Metric {
+		dogM := &Metric{
+			Metric: m.Name() + "." + name,
+			Tags:   buildTags(tags),
+			Host:   host,
+		}
+		dogM.Points[0] = Point{timestamp, value}
+		return dogM
+	}
+
+	dogMs := make([]*Metric, 0, len(d.Buckets)+len(d.Quantiles)+2)
+	for _, b := range d.Buckets {
+		tags := m.Tags()
+		tags["upper_bound"] = metric.FormatBound(b.UpperBound)
+		if math.IsInf(b.UpperBound, 1) {
+			tags["upper_bound"] = "none"
+		}
+		dogMs = append(dogMs, newMetric("count", float64(b.Count), tags))
+	}
+	for _, q := range d.Quantiles {
+		tags := m.Tags()
+		tags["quantile"] = metric.FormatBound(q.Quantile)
+		dogMs = append(dogMs, newMetric("quantile", q.Value, tags))
+	}
+	dogMs = append(dogMs, newMetric("sum", d.Sum, m.Tags()))
+	dogMs = append(dogMs, newMetric("count", float64(d.Count), m.Tags()))
+	return dogMs
+}
+
 func buildTags(mTags map[string]string) []string {
 	tags := make([]string, len(mTags))
 	index := 0
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/influxdata/telegraf"
@@ -184,6 +185,36 @@ func TestBuildPoint(t *testing.T) {
 	}
 }
 
+func TestBuildDistributionMetrics(t *testing.T) {
+	m, err := metric.New("request_latency",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{
+			"0.1":   float64(10),
+			"+Inf":  float64(20),
+			"sum":   float64(7.25),
+			"count": float64(20),
+		},
+		time.Unix(1257894000, 0), telegraf.Histogram)
+	require.NoError(t, err)
+
+	d, ok := metric.DecodeDistribution(m)
+	require.True(t, ok)
+	dogMs := buildDistributionMetrics(m, d)
+
+	var series []string
+	for _, dogM := range dogMs {
+		assert.Equal(t, "localhost", dogM.Host)
+		assert.Equal(t, float64(1257894000), dogM.Points[0][0])
+		series = append(series, fmt.Sprintf("%s %v %v", dogM.Metric, dogM.Tags, dogM.Points[0][1]))
+	}
+	assert.Equal(t, []string{
+		"request_latency.count [host:localhost upper_bound:0.1] 10",
+		"request_latency.count [host:localhost upper_bound:none] 20",
+		"request_latency.sum [host:localhost] 7.25",
+		"request_latency.count [host:localhost] 20",
+	}, series)
+}
+
 func TestVerifyValue(t *testing.T) {
 	var tagtests = []struct {
 		ptIn        telegraf.Metric
@@ -12,6 +12,7 @@ func (d *Discard) Close() error                          { return nil }
 func (d *Discard) SampleConfig() string                  { return "" }
 func (d *Discard) Description() string                   { return "Send metrics to nowhere at all" }
 func (d *Discard) Write(metrics []telegraf.Metric) error { return nil }
+func (d *Discard) SupportsConcurrentWrites() bool        { return true }
 
 func init() {
 	outputs.Add("discard", func() telegraf.Output { return &Discard{} })
@@ -11,7 +11,8 @@ import (
 )
 
 type File struct {
-	Files []string
+	Files          []string
+	UseBatchFormat bool `toml:"use_batch_format"`
 
 	writer  io.Writer
 	closers []io.Closer
@@ -28,6 +29,10 @@ var sampleConfig = `
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   data_format = "influx"
+
+  ## Serialize each batch of metrics as a whole rather than one metric at a
+  ## time, such as into a single JSON document with the json data format.
+  # use_batch_format = false
 `
 
 func (f *File) SetSerializer(serializer serializers.Serializer) {
@@ -90,6 +95,18 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 		return nil
 	}
 
+	if f.UseBatchFormat {
+		b, err := serializers.SerializeBatch(f.serializer, metrics)
+		if err != nil {
+			return fmt.Errorf("failed to serialize message: %s", err)
+		}
+		_, err = f.writer.Write(b)
+		if err != nil {
+			return fmt.Errorf("failed to write message: %s", err)
+		}
+		return nil
+	}
+
 	for _, metric := range metrics {
 		b, err := f.serializer.Serialize(metric)
 		if err != nil {
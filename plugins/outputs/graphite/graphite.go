@@ -17,11 +17,13 @@ import (
 
 type Graphite struct {
 	// URL is only for backwards compatibility
-	Servers  []string
-	Prefix   string
-	Template string
-	Timeout  int
-	conns    []net.Conn
+	Servers            []string
+	Prefix             string
+	Template           string
+	Templates          []string
+	GraphiteTagSupport bool `toml:"graphite_tag_support"`
+	Timeout            int
+	conns              []net.Conn
 
 	// Path to CA file
 	SSLCA string `toml:"ssl_ca"`
@@ -34,6 +36,8 @@ type Graphite struct {
 
 	// tls config
 	tlsConfig *tls.Config
+
+	serializer serializers.Serializer
 }
 
 var sampleConfig = `
@@ -46,6 +50,16 @@ var sampleConfig = `
   ## Graphite output template
   ## see https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   template = "host.tags.measurement.field"
+  ## Graphite templates, selected by the measurement name of each metric in
+  ## the same way as the graphite input templates, the template option is
+  ## used for the metrics matching none of them.
+  # templates = [
+  #   "cpu host.measurement.cpu.field",
+  #   "mem host.measurement.field region=us-east-1",
+  # ]
+  ## Enable Graphite 1.1 tags, written as "name;tag=value" rather than in the
+  ## bucket name. Templates are not used when it is enabled.
+  # graphite_tag_support = false
   ## timeout in seconds for the write connection to graphite
   timeout = 2
 
@@ -66,8 +80,14 @@ func (g *Graphite) Connect() error {
 		g.Servers = append(g.Servers, "localhost:2003")
 	}
 
-	// Set tls config
 	var err error
+	g.serializer, err = serializers.NewGraphiteSerializer(g.Prefix, g.Template,
+		g.GraphiteTagSupport, g.Templates)
+	if err != nil {
+		return err
+	}
+
+	// Set tls config
 	g.tlsConfig, err = internal.GetTLSConfig(
 		g.SSLCert, g.SSLKey, g.SSLCA, g.InsecureSkipVerify)
 	if err != nil {
@@ -142,20 +162,15 @@ func checkEOF(conn net.Conn) {
 func (g *Graphite) Write(metrics []telegraf.Metric) error {
 	// Prepare data
 	var batch []byte
-	s, err := serializers.NewGraphiteSerializer(g.Prefix, g.Template)
-	if err != nil {
-		return err
-	}
-
 	for _, metric := range metrics {
-		buf, err := s.Serialize(metric)
+		buf, err := g.serializer.Serialize(metric)
 		if err != nil {
 			log.Printf("E! Error serializing some metrics to graphite: %s", err.Error())
 		}
 		batch = append(batch, buf...)
 	}
 
-	err = g.send(batch)
+	err := g.send(batch)
 
 	// try to reconnect and retry to send
 	if err != nil {
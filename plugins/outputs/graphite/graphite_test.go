@@ -39,6 +39,14 @@ func TestGraphiteError(t *testing.T) {
 	assert.Equal(t, "Could not write to any Graphite server in cluster\n", err2.Error())
 }
 
+func TestGraphiteInvalidTemplate(t *testing.T) {
+	g := Graphite{
+		Servers:   []string{"127.0.0.1:12003"},
+		Templates: []string{"cpu host.cpu.field"},
+	}
+	require.Error(t, g.Connect())
+}
+
 func TestGraphiteOK(t *testing.T) {
 	var wg sync.WaitGroup
 	// Start TCP server
@@ -122,6 +122,19 @@ func (r *Response) Error() error {
 	return nil
 }
 
+// StatusError is returned when the server responds with an unexpected
+// status code, or with an error in the response body.
+type StatusError struct {
+	StatusCode   int
+	ExpectedCode int
+	Err          error
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Response Error: Status Code [%d], expected [%d], [%v]",
+		e.StatusCode, e.ExpectedCode, e.Err)
+}
+
 type httpClient struct {
 	writeURL string
 	config   HTTPConfig
@@ -176,8 +189,11 @@ func (c *httpClient) doRequest(
 	// Unexpected response code OR error in JSON response body overrides
 	// a JSON decode error:
 	if code != expectedCode || response.Error() != nil {
-		err = fmt.Errorf("Response Error: Status Code [%d], expected [%d], [%v]",
-			code, expectedCode, response.Error())
+		err = &StatusError{
+			StatusCode:   code,
+			ExpectedCode: expectedCode,
+			Err:          response.Error(),
+		}
 	}
 
 	return err
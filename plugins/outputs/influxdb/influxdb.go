@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"net/http"
 	"strings"
 	"time"
 
@@ -37,6 +38,9 @@ type InfluxDB struct {
 	HTTPHeaders      map[string]string `toml:"http_headers"`
 	ContentEncoding  string            `toml:"content_encoding"`
 
+	// Write a metric per bucket or quantile of histograms and summaries
+	SplitDistributions bool `toml:"split_distributions"`
+
 	// Path to CA file
 	SSLCA string `toml:"ssl_ca"`
 	// Path to host cert file
@@ -93,6 +97,12 @@ var sampleConfig = `
 
   ## Compress each HTTP request payload using GZIP.
   # content_encoding = "gzip"
+
+  ## Write histogram and summary metrics as a point per bucket or quantile,
+  ## with the bucket upper bound in the "le" tag or the quantile in the
+  ## "quantile" tag, as used by the histogramQuantile function of Flux, and a
+  ## point with the sum and count of the values.
+  # split_distributions = false
 `
 
 // Connect initiates the primary connection to the range of provided URLs
@@ -170,6 +180,17 @@ func (i *InfluxDB) Close() error {
 	return nil
 }
 
+// SupportsConcurrentWrites returns true unless a UDP server is configured, as
+// the UDP client is not safe for concurrent use.
+func (i *InfluxDB) SupportsConcurrentWrites() bool {
+	for _, u := range append([]string{i.URL}, i.URLs...) {
+		if strings.HasPrefix(u, "udp") {
+			return false
+		}
+	}
+	return true
+}
+
 // SampleConfig returns the formatted sample configuration for the plugin
 func (i *InfluxDB) SampleConfig() string {
 	return sampleConfig
@@ -183,6 +204,9 @@ func (i *InfluxDB) Description() string {
 // Write will choose a random server in the cluster to write to until a successful write
 // occurs, logging each unsuccessful. If all servers fail, return error.
 func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
+	if i.SplitDistributions {
+		metrics = splitDistributions(metrics)
+	}
 	r := metric.NewReader(metrics)
 
 	// This will get set to nil if a successful write occurs
@@ -232,6 +256,14 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 				break
 			}
 
+			if se, ok := e.(*client.StatusError); ok &&
+				se.StatusCode == http.StatusBadRequest {
+				// The server refuses the data itself, the other servers
+				// in the cluster will refuse it as well.
+				err = e
+				break
+			}
+
 			// Log write failure
 			log.Printf("E! InfluxDB Output Error: %s", e)
 		} else {
@@ -243,6 +275,55 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 	return err
 }
 
+// splitDistributions replaces the histogram and summary metrics by a metric
+// per bucket, with the cumulative "count" of the bucket and the "le" tag, or
+// per quantile, with its "value" and the "quantile" tag, and a metric holding
+// the "sum" and "count" of the values.
+func splitDistributions(metrics []telegraf.Metric) []telegraf.Metric {
+	out := make([]telegraf.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		d, ok := metric.DecodeDistribution(m)
+		if !ok {
+			out = append(out, m)
+			continue
+		}
+
+		for _, b := range d.Buckets {
+			tags := m.Tags()
+			tags["le"] = metric.FormatBound(b.UpperBound)
+			fields := map[string]interface{}{"count": float64(b.Count)}
+			if bm, err := metric.New(m.Name(), tags, fields, m.Time(), m.Type()); err == nil {
+				out = append(out, bm)
+			}
+		}
+		for _, q := range d.Quantiles {
+			tags := m.Tags()
+			tags["quantile"] = metric.FormatBound(q.Quantile)
+			fields := map[string]interface{}{"value": q.Value}
+			if qm, err := metric.New(m.Name(), tags, fields, m.Time(), m.Type()); err == nil {
+				out = append(out, qm)
+			}
+		}
+		fields := map[string]interface{}{
+			"sum":   d.Sum,
+			"count": float64(d.Count),
+		}
+		if sm, err := metric.New(m.Name(), m.Tags(), fields, m.Time(), m.Type()); err == nil {
+			out = append(out, sm)
+		}
+	}
+	return out
+}
+
+// IsRetryable returns false for errors caused by the server rejecting the
+// data written, such writes fail no matter how often they are retried.
+func (i *InfluxDB) IsRetryable(err error) bool {
+	if se, ok := err.(*client.StatusError); ok {
+		return se.StatusCode != http.StatusBadRequest
+	}
+	return true
+}
+
 func newInflux() *InfluxDB {
 	return &InfluxDB{
 		Timeout: internal.Duration{Duration: time.Second * 5},
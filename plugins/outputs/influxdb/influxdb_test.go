@@ -6,7 +6,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/outputs/influxdb/client"
 	"github.com/influxdata/telegraf/testutil"
 
@@ -96,6 +99,36 @@ func TestHTTPInflux(t *testing.T) {
 	require.NoError(t, i.Close())
 }
 
+func TestSplitDistributions(t *testing.T) {
+	m, err := metric.New("request_latency",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{
+			"0.1":   float64(10),
+			"+Inf":  float64(20),
+			"sum":   float64(7.25),
+			"count": float64(20),
+		},
+		time.Unix(0, 0), telegraf.Histogram)
+	require.NoError(t, err)
+	cpu := testutil.TestMetric(1.0, "cpu")
+
+	metrics := splitDistributions([]telegraf.Metric{m, cpu})
+	require.Len(t, metrics, 4)
+	assert.Equal(t, map[string]string{"host": "localhost", "le": "0.1"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"count": float64(10)}, metrics[0].Fields())
+	assert.Equal(t, map[string]string{"host": "localhost", "le": "+Inf"}, metrics[1].Tags())
+	assert.Equal(t, map[string]interface{}{"count": float64(20)}, metrics[1].Fields())
+	assert.Equal(t, map[string]string{"host": "localhost"}, metrics[2].Tags())
+	assert.Equal(t,
+		map[string]interface{}{"sum": float64(7.25), "count": float64(20)},
+		metrics[2].Fields())
+	for _, sm := range metrics[:3] {
+		assert.Equal(t, "request_latency", sm.Name())
+		assert.Equal(t, int64(0), sm.UnixNano())
+	}
+	assert.Equal(t, cpu, metrics[3])
+}
+
 func TestUDPConnectError(t *testing.T) {
 	i := InfluxDB{
 		URLs: []string{"udp://foobar:8089"},
@@ -281,6 +314,26 @@ func TestHTTPError_WriteErrors(t *testing.T) {
 	}
 }
 
+func TestHTTPError_BadRequestNotRetryable(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintln(rw, `{"error":"max-values-per-tag limit exceeded"}`)
+	}))
+	defer ts.Close()
+
+	influx := InfluxDB{
+		URLs:     []string{ts.URL},
+		Database: "test",
+	}
+
+	require.NoError(t, influx.Connect())
+	err := influx.Write(testutil.MockMetrics())
+	require.Error(t, err)
+	assert.False(t, influx.IsRetryable(err))
+	assert.True(t, influx.IsRetryable(fmt.Errorf("connection refused")))
+	require.NoError(t, influx.Close())
+}
+
 type MockClient struct {
 	writeStreamCalled int
 	contentLength     int
@@ -35,6 +35,8 @@ type (
 		RequiredAcks int
 		// MaxRetry Tag
 		MaxRetry int
+		// Send each batch of metrics as a single message per topic
+		UseBatchFormat bool `toml:"use_batch_format"`
 
 		// Legacy SSL config options
 		// TLS client certificate
@@ -150,6 +152,11 @@ var sampleConfig = `
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   data_format = "influx"
+
+  ## Send each batch of metrics as a single message per topic rather than one
+  ## message per metric, such as a JSON document with the json data format.
+  ## Batched messages have no routing key.
+  # use_batch_format = false
 `
 
 func ValidateTopicSuffixMethod(method string) error {
@@ -247,6 +254,10 @@ func (k *Kafka) Write(metrics []telegraf.Metric) error {
 		return nil
 	}
 
+	if k.UseBatchFormat {
+		return k.writeBatch(metrics)
+	}
+
 	for _, metric := range metrics {
 		buf, err := k.serializer.Serialize(metric)
 		if err != nil {
@@ -272,6 +283,31 @@ func (k *Kafka) Write(metrics []telegraf.Metric) error {
 	return nil
 }
 
+// writeBatch sends the metrics of each topic as a single message.
+func (k *Kafka) writeBatch(metrics []telegraf.Metric) error {
+	batches := make(map[string][]telegraf.Metric)
+	for _, metric := range metrics {
+		topicName := k.GetTopicName(metric)
+		batches[topicName] = append(batches[topicName], metric)
+	}
+
+	for topicName, batch := range batches {
+		buf, err := serializers.SerializeBatch(k.serializer, batch)
+		if err != nil {
+			return err
+		}
+
+		_, _, err = k.producer.SendMessage(&sarama.ProducerMessage{
+			Topic: topicName,
+			Value: sarama.ByteEncoder(buf),
+		})
+		if err != nil {
+			return fmt.Errorf("FAILED to send kafka message: %s\n", err)
+		}
+	}
+	return nil
+}
+
 func init() {
 	outputs.Add("kafka", func() telegraf.Output {
 		return &Kafka{
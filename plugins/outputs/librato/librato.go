@@ -11,6 +11,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 )
@@ -26,6 +27,8 @@ type Librato struct {
 
 	APIUrl string
 	client *http.Client
+
+	deltas metric.DistributionDeltas
 }
 
 // https://www.librato.com/docs/kb/faq/best_practices/naming_convention_metrics_sources.html#naming-limitations-for-sources-and-metrics
@@ -60,6 +63,23 @@ type Gauge struct {
 	Value       float64 `json:"value"`
 	Source      string  `json:"source"`
 	MeasureTime int64   `json:"measure_time"`
+	// Count and Sum replace Value for gauges summarizing many values
+	Count int64   `json:"count,omitempty"`
+	Sum   float64 `json:"sum,omitempty"`
+}
+
+// MarshalJSON leaves out the value of gauges with a count, as Librato
+// expects either the value of a single measurement or a count and sum.
+func (g *Gauge) MarshalJSON() ([]byte, error) {
+	type gauge Gauge
+	if g.Count == 0 {
+		return json.Marshal((*gauge)(g))
+	}
+	return json.Marshal(struct {
+		*gauge
+		Value *float64 `json:"value,omitempty"`
+		Sum   float64  `json:"sum"`
+	}{gauge: (*gauge)(g), Sum: g.Sum})
 }
 
 const libratoAPI = "https://metrics-api.librato.com/v1/metrics"
@@ -199,7 +219,27 @@ func (l *Librato) buildGauges(m telegraf.Metric) ([]*Gauge, error) {
 			fmt.Errorf("undeterminable Source type from Field, %s\n",
 				l.Template)
 	}
+	// The count and sum of a distribution are cumulative, Librato is sent
+	// those of the values observed since the previous flush.
+	d, isDistribution := metric.DecodeDistribution(m)
+	if isDistribution {
+		d, isDistribution = l.deltas.Delta(m, d)
+	}
+	if isDistribution && d.Count > 0 {
+		gauges = append(gauges, &Gauge{
+			Source:      reUnacceptedChar.ReplaceAllString(metricSource, "-"),
+			Name:        reUnacceptedChar.ReplaceAllString(m.Name(), "-"),
+			MeasureTime: m.Time().Unix(),
+			Count:       int64(d.Count),
+			Sum:         d.Sum,
+		})
+	}
+
 	for fieldName, value := range m.Fields() {
+		if (m.Type() == telegraf.Histogram || m.Type() == telegraf.Summary) &&
+			(fieldName == "sum" || fieldName == "count") {
+			continue
+		}
 
 		metricName := m.Name()
 		if fieldName != "value" {
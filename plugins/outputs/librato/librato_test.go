@@ -1,6 +1,7 @@
 package librato
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -163,6 +164,63 @@ func TestBuildGauge(t *testing.T) {
 	}
 }
 
+func TestBuildGaugeDistribution(t *testing.T) {
+	mtime := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	newSummary := func(sum, count float64) telegraf.Metric {
+		m, err := metric.New("rpc_duration",
+			map[string]string{"host": "host1"},
+			map[string]interface{}{
+				"0.5":   float64(0.1),
+				"sum":   sum,
+				"count": count,
+			},
+			mtime, telegraf.Summary)
+		require.NoError(t, err)
+		return m
+	}
+
+	l := NewLibrato(fakeURL)
+	l.Template = "host"
+
+	// the count and sum are cumulative, only the quantiles are sent for the
+	// first flush of a series
+	gauges, err := l.buildGauges(newSummary(3, 20))
+	require.NoError(t, err)
+	require.Equal(t, []*Gauge{
+		{
+			Name:        "rpc_duration.0.5",
+			Value:       0.1,
+			Source:      "host1",
+			MeasureTime: mtime.Unix(),
+		},
+	}, gauges)
+
+	gauges, err = l.buildGauges(newSummary(20, 140))
+	require.NoError(t, err)
+	require.Equal(t, []*Gauge{
+		{
+			Name:        "rpc_duration",
+			Source:      "host1",
+			MeasureTime: mtime.Unix(),
+			Count:       120,
+			Sum:         17,
+		},
+		{
+			Name:        "rpc_duration.0.5",
+			Value:       0.1,
+			Source:      "host1",
+			MeasureTime: mtime.Unix(),
+		},
+	}, gauges)
+
+	buf, err := json.Marshal(gauges)
+	require.NoError(t, err)
+	require.Equal(t,
+		`[{"name":"rpc_duration","source":"host1","measure_time":1257894000,"count":120,"sum":17},`+
+			`{"name":"rpc_duration.0.5","value":0.1,"source":"host1","measure_time":1257894000}]`,
+		string(buf))
+}
+
 func newHostMetric(value interface{}, name, host string) telegraf.Metric {
 	m, _ := metric.New(
 		name,
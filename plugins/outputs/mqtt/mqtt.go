@@ -44,6 +44,11 @@ var sampleConfig = `
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   data_format = "influx"
+
+  ## Publish each batch of metrics as a single message per topic rather than
+  ## one message per metric, such as a JSON document with the json data
+  ## format.
+  # use_batch_format = false
 `
 
 type MQTT struct {
@@ -56,6 +61,9 @@ type MQTT struct {
 	QoS         int    `toml:"qos"`
 	ClientID    string `toml:"client_id"`
 
+	// Publish each batch of metrics as a single message per topic
+	UseBatchFormat bool `toml:"use_batch_format"`
+
 	// Path to CA file
 	SSLCA string `toml:"ssl_ca"`
 	// Path to host cert file
@@ -124,6 +132,8 @@ func (m *MQTT) Write(metrics []telegraf.Metric) error {
 		hostname = ""
 	}
 
+	batches := make(map[string][]telegraf.Metric)
+	var topics []string
 	for _, metric := range metrics {
 		var t []string
 		if m.TopicPrefix != "" {
@@ -136,6 +146,14 @@ func (m *MQTT) Write(metrics []telegraf.Metric) error {
 		t = append(t, metric.Name())
 		topic := strings.Join(t, "/")
 
+		if m.UseBatchFormat {
+			if _, ok := batches[topic]; !ok {
+				topics = append(topics, topic)
+			}
+			batches[topic] = append(batches[topic], metric)
+			continue
+		}
+
 		buf, err := m.serializer.Serialize(metric)
 		if err != nil {
 			return fmt.Errorf("MQTT Could not serialize metric: %s",
@@ -148,6 +166,18 @@ func (m *MQTT) Write(metrics []telegraf.Metric) error {
 		}
 	}
 
+	for _, topic := range topics {
+		buf, err := serializers.SerializeBatch(m.serializer, batches[topic])
+		if err != nil {
+			return fmt.Errorf("MQTT Could not serialize metrics: %s", err)
+		}
+
+		err = m.publish(topic, buf)
+		if err != nil {
+			return fmt.Errorf("Could not write to MQTT server, %s", err)
+		}
+	}
+
 	return nil
 }
 
@@ -19,6 +19,8 @@ type NATS struct {
 	Password string
 	// NATS subject to publish metrics to
 	Subject string
+	// Publish each batch of metrics as a single message
+	UseBatchFormat bool `toml:"use_batch_format"`
 
 	// Path to CA file
 	SSLCA string `toml:"ssl_ca"`
@@ -54,6 +56,10 @@ var sampleConfig = `
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   data_format = "influx"
+
+  ## Publish each batch of metrics as a single message rather than one
+  ## message per metric, such as a JSON document with the json data format.
+  # use_batch_format = false
 `
 
 func (n *NATS) SetSerializer(serializer serializers.Serializer) {
@@ -114,6 +120,19 @@ func (n *NATS) Write(metrics []telegraf.Metric) error {
 		return nil
 	}
 
+	if n.UseBatchFormat {
+		buf, err := serializers.SerializeBatch(n.serializer, metrics)
+		if err != nil {
+			return err
+		}
+
+		err = n.conn.Publish(n.Subject, buf)
+		if err != nil {
+			return fmt.Errorf("FAILED to send NATS message: %s", err)
+		}
+		return nil
+	}
+
 	for _, metric := range metrics {
 		buf, err := n.serializer.Serialize(metric)
 		if err != nil {
@@ -11,9 +11,10 @@ import (
 )
 
 type NSQ struct {
-	Server   string
-	Topic    string
-	producer *nsq.Producer
+	Server         string
+	Topic          string
+	UseBatchFormat bool `toml:"use_batch_format"`
+	producer       *nsq.Producer
 
 	serializer serializers.Serializer
 }
@@ -29,6 +30,10 @@ var sampleConfig = `
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   data_format = "influx"
+
+  ## Publish each batch of metrics as a single message rather than one
+  ## message per metric, such as a JSON document with the json data format.
+  # use_batch_format = false
 `
 
 func (n *NSQ) SetSerializer(serializer serializers.Serializer) {
@@ -65,6 +70,19 @@ func (n *NSQ) Write(metrics []telegraf.Metric) error {
 		return nil
 	}
 
+	if n.UseBatchFormat {
+		buf, err := serializers.SerializeBatch(n.serializer, metrics)
+		if err != nil {
+			return err
+		}
+
+		err = n.producer.Publish(n.Topic, buf)
+		if err != nil {
+			return fmt.Errorf("FAILED to send NSQD message: %s", err)
+		}
+		return nil
+	}
+
 	for _, metric := range metrics {
 		buf, err := n.serializer.Serialize(metric)
 		if err != nil {
@@ -9,13 +9,13 @@ import (
 	"os"
 	"regexp"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -343,80 +343,36 @@ func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
 
 		switch point.Type() {
 		case telegraf.Summary:
-			var mname string
-			var sum float64
-			var count uint64
+			d, _ := metric.DecodeDistribution(point)
 			summaryvalue := make(map[float64]float64)
-			for fn, fv := range point.Fields() {
-				var value float64
-				switch fv := fv.(type) {
-				case int64:
-					value = float64(fv)
-				case float64:
-					value = fv
-				default:
-					continue
-				}
-
-				switch fn {
-				case "sum":
-					sum = value
-				case "count":
-					count = uint64(value)
-				default:
-					limit, err := strconv.ParseFloat(fn, 64)
-					if err == nil {
-						summaryvalue[limit] = value
-					}
-				}
+			for _, q := range d.Quantiles {
+				summaryvalue[q.Quantile] = q.Value
 			}
 			sample := &Sample{
 				Labels:       labels,
 				SummaryValue: summaryvalue,
-				Count:        count,
-				Sum:          sum,
+				Count:        d.Count,
+				Sum:          d.Sum,
 				Expiration:   now.Add(p.ExpirationInterval.Duration),
 			}
-			mname = sanitize(point.Name())
+			mname := sanitize(point.Name())
 
 			p.addMetricFamily(point, sample, mname, sampleID)
 
 		case telegraf.Histogram:
-			var mname string
-			var sum float64
-			var count uint64
+			d, _ := metric.DecodeDistribution(point)
 			histogramvalue := make(map[float64]uint64)
-			for fn, fv := range point.Fields() {
-				var value float64
-				switch fv := fv.(type) {
-				case int64:
-					value = float64(fv)
-				case float64:
-					value = fv
-				default:
-					continue
-				}
-
-				switch fn {
-				case "sum":
-					sum = value
-				case "count":
-					count = uint64(value)
-				default:
-					limit, err := strconv.ParseFloat(fn, 64)
-					if err == nil {
-						histogramvalue[limit] = uint64(value)
-					}
-				}
+			for _, b := range d.Buckets {
+				histogramvalue[b.UpperBound] = b.Count
 			}
 			sample := &Sample{
 				Labels:         labels,
 				HistogramValue: histogramvalue,
-				Count:          count,
-				Sum:            sum,
+				Count:          d.Count,
+				Sum:            d.Sum,
 				Expiration:     now.Add(p.ExpirationInterval.Duration),
 			}
-			mname = sanitize(point.Name())
+			mname := sanitize(point.Name())
 
 			p.addMetricFamily(point, sample, mname, sampleID)
 
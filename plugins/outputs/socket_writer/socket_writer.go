@@ -15,6 +15,7 @@ import (
 type SocketWriter struct {
 	Address         string
 	KeepAlivePeriod *internal.Duration
+	UseBatchFormat  bool `toml:"use_batch_format"`
 
 	serializers.Serializer
 
@@ -50,6 +51,11 @@ func (sw *SocketWriter) SampleConfig() string {
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
   # data_format = "influx"
+
+  ## Serialize each batch of metrics as a whole rather than one metric at a
+  ## time, such as into a single JSON document with the json data format.
+  ## Batches may be too large for a single datagram on UDP sockets.
+  # use_batch_format = false
 `
 }
 
@@ -104,19 +110,22 @@ func (sw *SocketWriter) Write(metrics []telegraf.Metric) error {
 		}
 	}
 
+	if sw.UseBatchFormat {
+		bs, err := serializers.SerializeBatch(sw.Serializer, metrics)
+		if err != nil {
+			return err
+		}
+		return sw.write(bs)
+	}
+
 	for _, m := range metrics {
 		bs, err := sw.Serialize(m)
 		if err != nil {
 			//TODO log & keep going with remaining metrics
 			return err
 		}
-		if _, err := sw.Conn.Write(bs); err != nil {
+		if err := sw.write(bs); err != nil {
 			//TODO log & keep going with remaining strings
-			if err, ok := err.(net.Error); !ok || !err.Temporary() {
-				// permanent error. close the connection
-				sw.Close()
-				sw.Conn = nil
-			}
 			return err
 		}
 	}
@@ -124,6 +133,19 @@ func (sw *SocketWriter) Write(metrics []telegraf.Metric) error {
 	return nil
 }
 
+// write writes bs to the connection, closing it on permanent errors.
+func (sw *SocketWriter) write(bs []byte) error {
+	if _, err := sw.Conn.Write(bs); err != nil {
+		if err, ok := err.(net.Error); !ok || !err.Temporary() {
+			// permanent error. close the connection
+			sw.Close()
+			sw.Conn = nil
+		}
+		return err
+	}
+	return nil
+}
+
 // Close closes the connection. Noop if already closed.
 func (sw *SocketWriter) Close() error {
 	if sw.Conn == nil {
@@ -4,13 +4,15 @@ import (
 	"bytes"
 	"fmt"
 	"log"
+	"math"
 	"net"
-	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/outputs"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/wavefront"
 	"time"
 )
 
@@ -25,21 +27,9 @@ type Wavefront struct {
 	UseRegex        bool
 	SourceOverride  []string
 	StringToNumber  map[string][]map[string]float64
-}
-
-// catch many of the invalid chars that could appear in a metric or tag name
-var sanitizedChars = strings.NewReplacer(
-	"!", "-", "@", "-", "#", "-", "$", "-", "%", "-", "^", "-", "&", "-",
-	"*", "-", "(", "-", ")", "-", "+", "-", "`", "-", "'", "-", "\"", "-",
-	"[", "-", "]", "-", "{", "-", "}", "-", ":", "-", ";", "-", "<", "-",
-	">", "-", ",", "-", "?", "-", "/", "-", "\\", "-", "|", "-", " ", "-",
-	"=", "-",
-)
-
-// instead of Replacer which may miss some special characters we can use a regex pattern, but this is significantly slower than Replacer
-var sanitizedRegex = regexp.MustCompile("[^a-zA-Z\\d_.-]")
 
-var tagValueReplacer = strings.NewReplacer("\"", "\\\"", "*", "-")
+	deltas metric.DistributionDeltas
+}
 
 var pathReplacer = strings.NewReplacer("_", "_")
 
@@ -90,6 +80,21 @@ type MetricPoint struct {
 	Tags      map[string]string
 }
 
+// Distribution is a Wavefront histogram distribution, with the number of
+// values near each centroid.
+type Distribution struct {
+	Metric    string
+	Centroids []Centroid
+	Timestamp int64
+	Source    string
+	Tags      map[string]string
+}
+
+type Centroid struct {
+	Value float64
+	Count uint64
+}
+
 func (w *Wavefront) Connect() error {
 	if w.ConvertPaths && w.MetricSeparator == "_" {
 		w.ConvertPaths = false
@@ -124,6 +129,20 @@ func (w *Wavefront) Write(metrics []telegraf.Metric) error {
 	connection.SetWriteDeadline(time.Now().Add(5 * time.Second))
 
 	for _, m := range metrics {
+		if m.Type() == telegraf.Histogram {
+			distribution := buildDistribution(m, w)
+			if distribution == nil {
+				continue
+			}
+			line := formatDistribution(distribution, w)
+			log.Printf("D! Output [wavefront] %s", line)
+			_, err := connection.Write([]byte(line))
+			if err != nil {
+				return fmt.Errorf("Wavefront: TCP writing error %s", err.Error())
+			}
+			continue
+		}
+
 		for _, metricPoint := range buildMetrics(m, w) {
 			metricLine := formatMetricPoint(metricPoint, w)
 			log.Printf("D! Output [wavefront] %s", metricLine)
@@ -148,18 +167,8 @@ func buildMetrics(m telegraf.Metric, w *Wavefront) []*MetricPoint {
 			name = fmt.Sprintf("%s%s%s%s", w.Prefix, m.Name(), w.MetricSeparator, fieldName)
 		}
 
-		if w.UseRegex {
-			name = sanitizedRegex.ReplaceAllLiteralString(name, "-")
-		} else {
-			name = sanitizedChars.Replace(name)
-		}
-
-		if w.ConvertPaths {
-			name = pathReplacer.Replace(name)
-		}
-
 		metric := &MetricPoint{
-			Metric:    name,
+			Metric:    buildName(name, w),
 			Timestamp: m.UnixNano() / 1000000000,
 		}
 
@@ -179,31 +188,66 @@ func buildMetrics(m telegraf.Metric, w *Wavefront) []*MetricPoint {
 	return ret
 }
 
-func buildTags(mTags map[string]string, w *Wavefront) (string, map[string]string) {
-	var source string
-	sourceTagFound := false
-
-	for _, s := range w.SourceOverride {
-		for k, v := range mTags {
-			if k == s {
-				source = v
-				mTags["telegraf_host"] = mTags["host"]
-				sourceTagFound = true
-				delete(mTags, k)
-				break
-			}
+// buildDistribution builds the distribution of the values observed since the
+// previous flush of a histogram series, as the bucket counts are cumulative.
+// It returns nil for metrics of other types, the first flush of a series and
+// when there are no new values. The values of each bucket are counted at its
+// upper bound, or at the largest finite bound for the +Inf bucket.
+func buildDistribution(m telegraf.Metric, w *Wavefront) *Distribution {
+	if m.Type() != telegraf.Histogram {
+		return nil
+	}
+	d, _ := metric.DecodeDistribution(m)
+	d, ok := w.deltas.Delta(m, d)
+	if !ok {
+		return nil
+	}
+
+	var centroids []Centroid
+	var count uint64
+	value := 0.0
+	if d.Count > 0 {
+		value = d.Sum / float64(d.Count)
+	}
+	for _, b := range d.Buckets {
+		if !math.IsInf(b.UpperBound, 1) {
+			value = b.UpperBound
 		}
-		if sourceTagFound {
-			break
+		if b.Count <= count {
+			continue
 		}
+		if n := len(centroids); n > 0 && centroids[n-1].Value == value {
+			centroids[n-1].Count += b.Count - count
+		} else {
+			centroids = append(centroids, Centroid{Value: value, Count: b.Count - count})
+		}
+		count = b.Count
+	}
+	if len(centroids) == 0 {
+		return nil
+	}
+
+	source, tags := buildTags(m.Tags(), w)
+	return &Distribution{
+		Metric:    buildName(w.Prefix+m.Name(), w),
+		Centroids: centroids,
+		Timestamp: m.UnixNano() / 1000000000,
+		Source:    source,
+		Tags:      tags,
 	}
+}
+
+func buildName(name string, w *Wavefront) string {
+	name = serializer.Sanitize(name, w.UseRegex)
 
-	if !sourceTagFound {
-		source = mTags["host"]
+	if w.ConvertPaths {
+		name = pathReplacer.Replace(name)
 	}
-	delete(mTags, "host")
+	return name
+}
 
-	return tagValueReplacer.Replace(source), mTags
+func buildTags(mTags map[string]string, w *Wavefront) (string, map[string]string) {
+	return serializer.BuildTags(mTags, w.SourceOverride)
 }
 
 func buildValue(v interface{}, name string, w *Wavefront) (float64, error) {
@@ -248,27 +292,36 @@ func formatMetricPoint(metricPoint *MetricPoint, w *Wavefront) string {
 	buffer.WriteString(strconv.FormatFloat(metricPoint.Value, 'f', 6, 64))
 	buffer.WriteString(" ")
 	buffer.WriteString(strconv.FormatInt(metricPoint.Timestamp, 10))
-	buffer.WriteString(" source=\"")
-	buffer.WriteString(metricPoint.Source)
-	buffer.WriteString("\"")
+	writeTags(buffer, metricPoint.Source, metricPoint.Tags, w)
+	buffer.WriteString("\n")
+
+	return buffer.String()
+}
 
-	for k, v := range metricPoint.Tags {
+// formatDistribution formats a distribution aggregated by minute, such as:
+//
+//	!M 1493773500 #20 30 #10 5.1 request.latency source="appServer1"
+func formatDistribution(distribution *Distribution, w *Wavefront) string {
+	buffer := bytes.NewBufferString("!M ")
+	buffer.WriteString(strconv.FormatInt(distribution.Timestamp, 10))
+	for _, c := range distribution.Centroids {
+		buffer.WriteString(" #")
+		buffer.WriteString(strconv.FormatUint(c.Count, 10))
 		buffer.WriteString(" ")
-		if w.UseRegex {
-			buffer.WriteString(sanitizedRegex.ReplaceAllLiteralString(k, "-"))
-		} else {
-			buffer.WriteString(sanitizedChars.Replace(k))
-		}
-		buffer.WriteString("=\"")
-		buffer.WriteString(tagValueReplacer.Replace(v))
-		buffer.WriteString("\"")
+		buffer.WriteString(strconv.FormatFloat(c.Value, 'f', -1, 64))
 	}
-
+	buffer.WriteString(" ")
+	buffer.WriteString(distribution.Metric)
+	writeTags(buffer, distribution.Source, distribution.Tags, w)
 	buffer.WriteString("\n")
 
 	return buffer.String()
 }
 
+func writeTags(buffer *bytes.Buffer, source string, tags map[string]string, w *Wavefront) {
+	serializer.WriteTags(buffer, source, tags, w.UseRegex)
+}
+
 func (w *Wavefront) SampleConfig() string {
 	return sampleConfig
 }
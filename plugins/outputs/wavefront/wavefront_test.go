@@ -189,7 +189,7 @@ func TestBuildTagsWithSource(t *testing.T) {
 		},
 		{
 			map[string]string{"something": "abc", "host": "r*@l\"Ho/st"},
-			"r-@l\\\"Ho/st",
+			"r*@l\"Ho/st",
 			map[string]string{"something": "abc"},
 		},
 	}
@@ -271,11 +271,11 @@ func TestFormatMetricPoint(t *testing.T) {
 		Metric:    "test.metric.something",
 		Value:     123.456,
 		Timestamp: 1257894000,
-		Source:    "testSource",
+		Source:    "test\"Source",
 		Tags:      map[string]string{"sp*c!@l\"-ch/rs": "sp*c!@l/ val\"ue"},
 	}
 
-	expected := "test.metric.something 123.456000 1257894000 source=\"testSource\" sp-c--l--ch-rs=\"sp-c!@l/ val\\\"ue\"\n"
+	expected := "test.metric.something 123.456000 1257894000 source=\"test\\\"Source\" sp-c--l--ch-rs=\"sp-c!@l/ val\\\"ue\"\n"
 
 	received := formatMetricPoint(testpoint, w)
 
@@ -285,23 +285,60 @@ func TestFormatMetricPoint(t *testing.T) {
 	}
 }
 
-// Benchmarks to test performance of string replacement via Regex and Replacer
-var testString = "this_is*my!test/string\\for=replacement"
+func TestBuildDistribution(t *testing.T) {
+	w := defaultWavefront()
+
+	pathReplacer = strings.NewReplacer("_", w.MetricSeparator)
 
-func BenchmarkReplaceAllString(b *testing.B) {
-	for n := 0; n < b.N; n++ {
-		sanitizedRegex.ReplaceAllString(testString, "-")
+	newHistogram := func(counts ...float64) telegraf.Metric {
+		m, _ := metric.New(
+			"request_latency",
+			map[string]string{"host": "testHost"},
+			map[string]interface{}{
+				"0.1":   counts[0],
+				"1":     counts[1],
+				"5":     counts[2],
+				"+Inf":  counts[3],
+				"sum":   counts[4],
+				"count": counts[3],
+			},
+			time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+			telegraf.Histogram,
+		)
+		return m
 	}
-}
 
-func BenchmarkReplaceAllLiteralString(b *testing.B) {
-	for n := 0; n < b.N; n++ {
-		sanitizedRegex.ReplaceAllLiteralString(testString, "-")
+	// the first flush of a series only records the cumulative counts
+	if distribution := buildDistribution(newHistogram(5, 5, 8, 10, 20), w); distribution != nil {
+		t.Errorf("expected no distribution for the first histogram, received %+v\n", distribution)
+	}
+
+	testMetric1 := newHistogram(15, 15, 26, 30, 62)
+	distribution := buildDistribution(testMetric1, w)
+	expected := &Distribution{
+		Metric:    "testWF.request.latency",
+		Centroids: []Centroid{{Value: 0.1, Count: 10}, {Value: 5, Count: 10}},
+		Timestamp: 1257894000,
+		Source:    "testHost",
+		Tags:      map[string]string{},
+	}
+	if !reflect.DeepEqual(expected, distribution) {
+		t.Errorf("\nexpected\t%+v\nreceived\t%+v\n", expected, distribution)
 	}
-}
 
-func BenchmarkReplacer(b *testing.B) {
-	for n := 0; n < b.N; n++ {
-		sanitizedChars.Replace(testString)
+	expectedLine := "!M 1257894000 #10 0.1 #10 5 testWF.request.latency source=\"testHost\"\n"
+	received := formatDistribution(distribution, w)
+	if expectedLine != received {
+		t.Errorf("\nexpected\t%+v\nreceived\t%+v\n", expectedLine, received)
+	}
+
+	testMetric2, _ := metric.New(
+		"request_latency",
+		map[string]string{"host": "testHost"},
+		map[string]interface{}{"value": float64(1)},
+		time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+	if distribution := buildDistribution(testMetric2, w); distribution != nil {
+		t.Errorf("expected no distribution for untyped metric, received %+v\n", distribution)
 	}
 }
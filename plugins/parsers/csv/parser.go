@@ -0,0 +1,298 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Parser parses CSV data, each row becomes a metric.
+type Parser struct {
+	MetricName string
+	// HeaderRowCount is the number of header rows, the column names are
+	// made up of the header rows joined together.
+	HeaderRowCount int
+	// SkipRows is the number of rows ignored before the header.
+	SkipRows int
+	// Delimiter and Comment are single characters, the delimiter defaults
+	// to a comma and comments are not recognized unless set.
+	Delimiter string
+	Comment   string
+	TrimSpace bool
+	// ColumnNames names the columns, overriding the header if there is one.
+	ColumnNames []string
+	// ColumnTypes sets the type of each column's field, one of "int",
+	// "float", "bool" or "string". The type is inferred from the value when
+	// unset.
+	ColumnTypes       []string
+	TagColumns        []string
+	MeasurementColumn string
+	TimestampColumn   string
+	// TimestampFormat is the Go time layout of the timestamp column.
+	TimestampFormat string
+	DefaultTags     map[string]string
+
+	// TimeFunc returns the time of metrics without a timestamp column.
+	TimeFunc func() time.Time
+
+	// headerNames are the column names read from the header of the last
+	// document, used when ColumnNames is not set.
+	mu          sync.Mutex
+	headerNames []string
+}
+
+// NewParser returns a CSV parser, checking its configuration.
+func NewParser(p *Parser) (*Parser, error) {
+	if p.HeaderRowCount == 0 && len(p.ColumnNames) == 0 {
+		return nil, fmt.Errorf("csv_column_names must be set when there is no header row")
+	}
+	if len([]rune(p.Delimiter)) > 1 {
+		return nil, fmt.Errorf("csv_delimiter must be a single character, got: %q",
+			p.Delimiter)
+	}
+	if len([]rune(p.Comment)) > 1 {
+		return nil, fmt.Errorf("csv_comment must be a single character, got: %q",
+			p.Comment)
+	}
+	// With names read from the header, the types are checked once it has
+	// been parsed.
+	if len(p.ColumnNames) > 0 {
+		if err := p.checkColumnTypes(p.ColumnNames); err != nil {
+			return nil, err
+		}
+	}
+	for _, typ := range p.ColumnTypes {
+		switch typ {
+		case "int", "float", "bool", "string":
+		default:
+			return nil, fmt.Errorf("invalid csv_column_types entry: %q", typ)
+		}
+	}
+	if p.TimestampColumn != "" && p.TimestampFormat == "" {
+		return nil, fmt.Errorf("csv_timestamp_format must be set along with " +
+			"csv_timestamp_column")
+	}
+	if p.TimeFunc == nil {
+		p.TimeFunc = time.Now
+	}
+	return p, nil
+}
+
+// checkColumnTypes verifies there is a type for each named column.
+func (p *Parser) checkColumnTypes(names []string) error {
+	if len(p.ColumnTypes) > 0 && len(p.ColumnTypes) != len(names) {
+		return fmt.Errorf("csv_column_types has %d entries, but there are %d "+
+			"columns", len(p.ColumnTypes), len(names))
+	}
+	return nil
+}
+
+func (p *Parser) newReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	if p.Delimiter != "" {
+		reader.Comma = []rune(p.Delimiter)[0]
+	}
+	if p.Comment != "" {
+		reader.Comment = []rune(p.Comment)[0]
+	}
+	reader.TrimLeadingSpace = p.TrimSpace
+	// rows may have fewer or more columns than there are names
+	reader.FieldsPerRecord = -1
+	return reader
+}
+
+// Parse parses a whole CSV document, skipping SkipRows rows and reading the
+// header before the data rows. Column names read from the header are kept,
+// so that lines passed to ParseLine afterwards use them too. Each document
+// is parsed with its own header unless ColumnNames is set.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	r := bytes.NewReader(buf)
+	// skip rows before the csv reader sees them, they need not be valid CSV
+	for i := 0; i < p.SkipRows; i++ {
+		if err := skipLine(r); err != nil {
+			if err == io.EOF {
+				return []telegraf.Metric{}, nil
+			}
+			return nil, err
+		}
+	}
+
+	reader := p.newReader(r)
+	var header []string
+	for i := 0; i < p.HeaderRowCount; i++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return []telegraf.Metric{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for j, name := range row {
+			if p.TrimSpace {
+				name = strings.TrimSpace(name)
+			}
+			if j < len(header) {
+				header[j] += name
+			} else {
+				header = append(header, name)
+			}
+		}
+	}
+	names := p.ColumnNames
+	if len(names) == 0 {
+		if err := p.checkColumnTypes(header); err != nil {
+			return nil, err
+		}
+		names = header
+		p.mu.Lock()
+		p.headerNames = header
+		p.mu.Unlock()
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		m, err := p.parseRow(names, row)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// ParseLine parses a single data row, the column names must have been set or
+// read from the header by an earlier call to Parse.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	names := p.ColumnNames
+	if len(names) == 0 {
+		p.mu.Lock()
+		names = p.headerNames
+		p.mu.Unlock()
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("csv column names are unknown, set " +
+			"csv_column_names or parse the header first")
+	}
+	row, err := p.newReader(strings.NewReader(line)).Read()
+	if err != nil {
+		return nil, err
+	}
+	return p.parseRow(names, row)
+}
+
+// parseRow returns the metric of a data row, the columns being named by
+// names.
+func (p *Parser) parseRow(names []string, row []string) (telegraf.Metric, error) {
+	name := p.MetricName
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	fields := make(map[string]interface{})
+	t := p.TimeFunc()
+
+outer:
+	for i, value := range row {
+		if i >= len(names) {
+			break
+		}
+		column := names[i]
+		if p.TrimSpace {
+			value = strings.TrimSpace(value)
+		}
+
+		switch {
+		case p.MeasurementColumn != "" && column == p.MeasurementColumn:
+			name = value
+			continue
+		case p.TimestampColumn != "" && column == p.TimestampColumn:
+			ts, err := time.Parse(p.TimestampFormat, value)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse timestamp column %s: %s",
+					column, err)
+			}
+			t = ts
+			continue
+		}
+		for _, tagColumn := range p.TagColumns {
+			if column == tagColumn {
+				if value != "" {
+					tags[column] = value
+				}
+				continue outer
+			}
+		}
+
+		if value == "" {
+			continue
+		}
+		field, err := p.fieldValue(i, value)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %s", column, err)
+		}
+		fields[column] = field
+	}
+
+	return metric.New(name, tags, fields, t)
+}
+
+// fieldValue converts the value of column i to its configured type, or to
+// the first of int, float and bool it can be parsed as.
+func (p *Parser) fieldValue(i int, value string) (interface{}, error) {
+	if i < len(p.ColumnTypes) {
+		switch p.ColumnTypes[i] {
+		case "int":
+			return strconv.ParseInt(value, 10, 64)
+		case "float":
+			return strconv.ParseFloat(value, 64)
+		case "bool":
+			return strconv.ParseBool(value)
+		default:
+			return value, nil
+		}
+	}
+
+	if iValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return iValue, nil
+	}
+	if fValue, err := strconv.ParseFloat(value, 64); err == nil {
+		return fValue, nil
+	}
+	if bValue, err := strconv.ParseBool(value); err == nil {
+		return bValue, nil
+	}
+	return value, nil
+}
+
+// SetDefaultTags sets the tags added to every parsed metric.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// skipLine reads up to and including the next newline.
+func skipLine(r *bytes.Reader) error {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b == '\n' {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package csv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var defaultTime = time.Date(2018, 7, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestParser(t *testing.T, p *Parser) *Parser {
+	p.MetricName = "csv"
+	p.TimeFunc = func() time.Time { return defaultTime }
+	parser, err := NewParser(p)
+	require.NoError(t, err)
+	return parser
+}
+
+func TestHeaderRow(t *testing.T) {
+	p := newTestParser(t, &Parser{HeaderRowCount: 1})
+	metrics, err := p.Parse([]byte("a,b,c\n1,2.5,true\n3,x,\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "csv", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{
+		"a": int64(1),
+		"b": float64(2.5),
+		"c": true,
+	}, metrics[0].Fields())
+	assert.Equal(t, defaultTime.UnixNano(), metrics[0].Time().UnixNano())
+	// empty values are skipped
+	assert.Equal(t, map[string]interface{}{
+		"a": int64(3),
+		"b": "x",
+	}, metrics[1].Fields())
+
+	// the header is kept for lines parsed on their own
+	m, err := p.ParseLine("4,5,6")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a": int64(4),
+		"b": int64(5),
+		"c": int64(6),
+	}, m.Fields())
+}
+
+func TestHeaderRowPerDocument(t *testing.T) {
+	p := newTestParser(t, &Parser{HeaderRowCount: 1})
+	_, err := p.Parse([]byte("a,b\n1,2\n"))
+	require.NoError(t, err)
+
+	metrics, err := p.Parse([]byte("c,d\n3,4\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{
+		"c": int64(3),
+		"d": int64(4),
+	}, metrics[0].Fields(), "Should use the header of each document")
+	assert.Empty(t, p.ColumnNames, "Should not change the configured column names")
+
+	m, err := p.ParseLine("5,6")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"c": int64(5),
+		"d": int64(6),
+	}, m.Fields())
+}
+
+func TestHeaderRowColumnTypes(t *testing.T) {
+	p := newTestParser(t, &Parser{
+		HeaderRowCount: 1,
+		ColumnTypes:    []string{"string", "float"},
+	})
+	metrics, err := p.Parse([]byte("a,b\n1,2\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{
+		"a": "1",
+		"b": float64(2),
+	}, metrics[0].Fields())
+
+	// the number of types must match the header
+	p = newTestParser(t, &Parser{
+		HeaderRowCount: 1,
+		ColumnTypes:    []string{"string"},
+	})
+	_, err = p.Parse([]byte("a,b\n1,2\n"))
+	assert.Error(t, err)
+}
+
+func TestMultipleHeaderRows(t *testing.T) {
+	p := newTestParser(t, &Parser{HeaderRowCount: 2, SkipRows: 1})
+	metrics, err := p.Parse([]byte("exported by job 42\ncpu_,mem_\nidle,free\n90,1024\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{
+		"cpu_idle": int64(90),
+		"mem_free": int64(1024),
+	}, metrics[0].Fields())
+}
+
+func TestColumnNamesAndTypes(t *testing.T) {
+	p := newTestParser(t, &Parser{
+		ColumnNames:       []string{"host", "name", "value", "code", "time"},
+		ColumnTypes:       []string{"string", "string", "float", "string", "string"},
+		TagColumns:        []string{"host"},
+		MeasurementColumn: "name",
+		TimestampColumn:   "time",
+		TimestampFormat:   "2006-01-02 15:04:05",
+		Delimiter:         ";",
+		Comment:           "#",
+		TrimSpace:         true,
+		DefaultTags:       map[string]string{"source": "batch"},
+	})
+	metrics, err := p.Parse([]byte("# comment\nweb01; cpu; 42; 007; 2018-07-02 10:00:00\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+
+	m := metrics[0]
+	assert.Equal(t, "cpu", m.Name())
+	assert.Equal(t, map[string]string{"host": "web01", "source": "batch"}, m.Tags())
+	assert.Equal(t, map[string]interface{}{
+		"value": float64(42),
+		"code":  "007",
+	}, m.Fields())
+	assert.Equal(t, time.Date(2018, 7, 2, 10, 0, 0, 0, time.UTC).UnixNano(),
+		m.Time().UnixNano())
+}
+
+func TestInvalidValues(t *testing.T) {
+	p := newTestParser(t, &Parser{
+		ColumnNames: []string{"a"},
+		ColumnTypes: []string{"int"},
+	})
+	_, err := p.ParseLine("1.5")
+	assert.Error(t, err)
+
+	p = newTestParser(t, &Parser{
+		ColumnNames:     []string{"a", "time"},
+		TimestampColumn: "time",
+		TimestampFormat: time.RFC3339,
+	})
+	_, err = p.ParseLine("1,yesterday")
+	assert.Error(t, err)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	var tests = []struct {
+		name   string
+		parser *Parser
+	}{
+		{
+			name:   "no column names",
+			parser: &Parser{},
+		},
+		{
+			name:   "long delimiter",
+			parser: &Parser{HeaderRowCount: 1, Delimiter: "::"},
+		},
+		{
+			name:   "long comment",
+			parser: &Parser{HeaderRowCount: 1, Comment: "//"},
+		},
+		{
+			name: "type count",
+			parser: &Parser{
+				ColumnNames: []string{"a", "b"},
+				ColumnTypes: []string{"int"},
+			},
+		},
+		{
+			name: "unknown type",
+			parser: &Parser{
+				ColumnNames: []string{"a"},
+				ColumnTypes: []string{"duration"},
+			},
+		},
+		{
+			name: "timestamp format",
+			parser: &Parser{
+				HeaderRowCount:  1,
+				TimestampColumn: "time",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser(tt.parser)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -958,3 +958,28 @@ func TestTimezoneLocalCompileFileAndParse(t *testing.T) {
 	assert.Equal(t, map[string]string{}, metricB.Tags())
 	assert.Equal(t, time.Date(2016, time.June, 4, 12, 41, 45, 0, time.Local).UnixNano(), metricB.UnixNano())
 }
+
+func TestParseMultipleLines(t *testing.T) {
+	p := &Parser{
+		Measurement: "grok",
+		Patterns:    []string{"%{TESTLOG}"},
+		CustomPatterns: `
+			TESTLOG %{NUMBER:num:int} %{WORD:client:tag}
+		`,
+	}
+	assert.NoError(t, p.Compile())
+	p.SetDefaultTags(map[string]string{"client": "default", "host": "web01"})
+
+	metrics, err := p.Parse([]byte("142 bot\r\nno match here\n\n7 human\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "grok", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{"num": int64(142)}, metrics[0].Fields())
+	// captured tags take precedence over the default tags
+	assert.Equal(t, map[string]string{"client": "bot", "host": "web01"},
+		metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"num": int64(7)}, metrics[1].Fields())
+	assert.Equal(t, map[string]string{"client": "human", "host": "web01"},
+		metrics[1].Tags())
+}
@@ -4,17 +4,31 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/tidwall/gjson"
 )
 
 type JSONParser struct {
-	MetricName  string
-	TagKeys     []string
+	MetricName string
+	TagKeys    []string
+	// StringFields are the keys of string values kept as fields, other
+	// string values are dropped. Nested keys are joined with underscores.
+	StringFields []string
+	// NameKey is the key of the measurement name, MetricName is used if
+	// the key is missing.
+	NameKey string
+	// Query is a GJSON path selecting the object or array to parse.
+	Query string
+	// TimeKey is the key of the metric time, parsed according to
+	// TimeFormat: a Go time layout, "unix" or "unix_ms".
+	TimeKey     string
+	TimeFormat  string
 	DefaultTags map[string]string
 }
 
@@ -29,6 +43,9 @@ func (p *JSONParser) parseArray(buf []byte) ([]telegraf.Metric, error) {
 	}
 	for _, item := range jsonOut {
 		metrics, err = p.parseObject(metrics, item)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return metrics, nil
 }
@@ -52,13 +69,41 @@ func (p *JSONParser) parseObject(metrics []telegraf.Metric, jsonOut map[string]i
 		delete(jsonOut, tag)
 	}
 
+	name := p.MetricName
+	if p.NameKey != "" {
+		if v, ok := jsonOut[p.NameKey].(string); ok && v != "" {
+			name = v
+		}
+		delete(jsonOut, p.NameKey)
+	}
+
+	t := time.Now().UTC()
+	if p.TimeKey != "" {
+		v, ok := jsonOut[p.TimeKey]
+		if !ok {
+			return nil, fmt.Errorf("json_time_key %q not found", p.TimeKey)
+		}
+		var err error
+		t, err = parseTime(p.TimeFormat, v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse json_time_key %q: %s",
+				p.TimeKey, err)
+		}
+		delete(jsonOut, p.TimeKey)
+	}
+
 	f := JSONFlattener{}
-	err := f.FlattenJSON("", jsonOut)
+	err := f.FullFlattenJSON("", jsonOut, len(p.StringFields) > 0, false)
 	if err != nil {
 		return nil, err
 	}
+	for k, v := range f.Fields {
+		if _, ok := v.(string); ok && !contains(p.StringFields, k) {
+			delete(f.Fields, k)
+		}
+	}
 
-	metric, err := metric.New(p.MetricName, tags, f.Fields, time.Now().UTC())
+	metric, err := metric.New(name, tags, f.Fields, t)
 
 	if err != nil {
 		return nil, err
@@ -72,6 +117,15 @@ func (p *JSONParser) Parse(buf []byte) ([]telegraf.Metric, error) {
 		return make([]telegraf.Metric, 0), nil
 	}
 
+	if p.Query != "" {
+		result := gjson.GetBytes(buf, p.Query)
+		if !result.IsArray() && !result.IsObject() {
+			return nil, fmt.Errorf("json_query %q must select a JSON object or "+
+				"array of objects, got: %s", p.Query, result.Raw)
+		}
+		buf = []byte(result.Raw)
+	}
+
 	if !isarray(buf) {
 		metrics := make([]telegraf.Metric, 0)
 		var jsonOut map[string]interface{}
@@ -167,6 +221,48 @@ func (f *JSONFlattener) FullFlattenJSON(
 	return nil
 }
 
+// parseTime converts the value of the time key according to format.
+func parseTime(format string, v interface{}) (time.Time, error) {
+	switch format {
+	case "unix", "unix_ms":
+		var f float64
+		switch t := v.(type) {
+		case float64:
+			f = t
+		case string:
+			var err error
+			f, err = strconv.ParseFloat(t, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+		default:
+			return time.Time{}, fmt.Errorf("expected a number, got %T", v)
+		}
+		unit := time.Second
+		if format == "unix_ms" {
+			unit = time.Millisecond
+		}
+		whole, frac := math.Modf(f)
+		ns := int64(whole)*int64(unit) + int64(frac*float64(unit))
+		return time.Unix(0, ns).UTC(), nil
+	default:
+		str, ok := v.(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("expected a string, got %T", v)
+		}
+		return time.Parse(format, str)
+	}
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
 func isarray(buf []byte) bool {
 	ia := bytes.IndexByte(buf, '[')
 	ib := bytes.IndexByte(buf, '{')
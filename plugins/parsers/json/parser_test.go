@@ -2,8 +2,10 @@ package json
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -428,3 +430,118 @@ func TestParseArrayWithTagKeys(t *testing.T) {
 		"othertag": "baz",
 	}, metrics[1].Tags())
 }
+
+const validJSONQuery = `
+{
+    "status": "ok",
+    "data": {
+        "points": [
+            {"name": "cpu", "usage": 42, "host": "web01", "state": "busy", "time": "2018-07-01T10:00:00Z"},
+            {"name": "mem", "usage": 1024, "host": "web02", "state": "idle", "time": "2018-07-01T10:00:10Z"}
+        ]
+    }
+}
+`
+
+func TestParseWithQuery(t *testing.T) {
+	parser := JSONParser{
+		MetricName:   "json_test",
+		TagKeys:      []string{"host"},
+		StringFields: []string{"state"},
+		NameKey:      "name",
+		Query:        "data.points",
+		TimeKey:      "time",
+		TimeFormat:   "2006-01-02T15:04:05Z07:00",
+	}
+	metrics, err := parser.Parse([]byte(validJSONQuery))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "cpu", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{
+		"usage": float64(42),
+		"state": "busy",
+	}, metrics[0].Fields())
+	assert.Equal(t, map[string]string{"host": "web01"}, metrics[0].Tags())
+	assert.Equal(t, time.Date(2018, 7, 1, 10, 0, 0, 0, time.UTC).UnixNano(),
+		metrics[0].Time().UnixNano())
+
+	assert.Equal(t, "mem", metrics[1].Name())
+	assert.Equal(t, time.Date(2018, 7, 1, 10, 0, 10, 0, time.UTC).UnixNano(),
+		metrics[1].Time().UnixNano())
+
+	// the query must select an object or array
+	parser.Query = "status"
+	_, err = parser.Parse([]byte(validJSONQuery))
+	assert.Error(t, err)
+}
+
+func TestParseStringFields(t *testing.T) {
+	parser := JSONParser{
+		MetricName:   "json_test",
+		StringFields: []string{"b_d"},
+	}
+	metrics, err := parser.Parse([]byte(`{"a": "dropped", "b": {"c": 6, "d": "kept"}}`))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{
+		"b_c": float64(6),
+		"b_d": "kept",
+	}, metrics[0].Fields())
+}
+
+func TestParseUnixTime(t *testing.T) {
+	var tests = []struct {
+		name     string
+		format   string
+		json     string
+		expected int64
+	}{
+		{
+			name:     "unix",
+			format:   "unix",
+			json:     `{"value": 1, "time": 1530439200.5}`,
+			expected: 1530439200500000000,
+		},
+		{
+			name:     "unix string",
+			format:   "unix",
+			json:     `{"value": 1, "time": "1530439200"}`,
+			expected: 1530439200000000000,
+		},
+		{
+			name:     "unix_ms",
+			format:   "unix_ms",
+			json:     `{"value": 1, "time": 1530439200123}`,
+			expected: 1530439200123000000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := JSONParser{
+				MetricName: "json_test",
+				TimeKey:    "time",
+				TimeFormat: tt.format,
+			}
+			metrics, err := parser.Parse([]byte(tt.json))
+			require.NoError(t, err)
+			require.Len(t, metrics, 1)
+			assert.Equal(t, tt.expected, metrics[0].Time().UnixNano())
+			assert.Equal(t, map[string]interface{}{"value": float64(1)},
+				metrics[0].Fields())
+		})
+	}
+}
+
+func TestParseTimeErrors(t *testing.T) {
+	parser := JSONParser{
+		MetricName: "json_test",
+		TimeKey:    "time",
+		TimeFormat: "unix",
+	}
+	_, err := parser.Parse([]byte(`{"value": 1}`))
+	assert.Error(t, err)
+	_, err = parser.Parse([]byte(`{"value": 1, "time": "yesterday"}`))
+	assert.Error(t, err)
+}
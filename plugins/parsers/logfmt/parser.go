@@ -0,0 +1,114 @@
+package logfmt
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logfmt/logfmt"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Parser parses logfmt data, each line of key=value pairs becomes a metric.
+type Parser struct {
+	MetricName string
+	// TagKeys are the keys whose values are added as tags rather than fields.
+	TagKeys     []string
+	DefaultTags map[string]string
+
+	// Now returns the time of parsed metrics.
+	Now func() time.Time
+}
+
+// NewParser returns a logfmt parser.
+func NewParser(metricName string, tagKeys []string, defaultTags map[string]string) *Parser {
+	return &Parser{
+		MetricName:  metricName,
+		TagKeys:     tagKeys,
+		DefaultTags: defaultTags,
+		Now:         time.Now,
+	}
+}
+
+// Parse parses each line of buf into a metric, lines without any fields are
+// skipped.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	decoder := logfmt.NewDecoder(bytes.NewReader(buf))
+	for decoder.ScanRecord() {
+		tags := make(map[string]string)
+		for k, v := range p.DefaultTags {
+			tags[k] = v
+		}
+		fields := make(map[string]interface{})
+
+		for decoder.ScanKeyval() {
+			key, value := string(decoder.Key()), string(decoder.Value())
+			if value == "" {
+				continue
+			}
+			if p.isTagKey(key) {
+				tags[key] = value
+				continue
+			}
+			fields[key] = fieldValue(value)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		m, err := metric.New(p.MetricName, tags, fields, p.Now())
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	if err := decoder.Err(); err != nil {
+		return nil, fmt.Errorf("unable to parse logfmt: %s", err)
+	}
+	return metrics, nil
+}
+
+// ParseLine parses a single line, returning a nil metric if it has no fields.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+	if len(metrics) < 1 {
+		return nil, nil
+	}
+	return metrics[0], nil
+}
+
+// SetDefaultTags sets the tags added to every parsed metric.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) isTagKey(key string) bool {
+	for _, tagKey := range p.TagKeys {
+		if key == tagKey {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldValue converts value to the first of int, float and bool it can be
+// parsed as, or keeps it as a string.
+func fieldValue(value string) interface{} {
+	if iValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return iValue
+	}
+	if fValue, err := strconv.ParseFloat(value, 64); err == nil {
+		return fValue
+	}
+	if bValue, err := strconv.ParseBool(value); err == nil {
+		return bValue
+	}
+	return value
+}
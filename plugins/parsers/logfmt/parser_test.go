@@ -0,0 +1,67 @@
+package logfmt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var defaultTime = time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestParser(tagKeys ...string) *Parser {
+	p := NewParser("logfmt", tagKeys, map[string]string{"source": "test"})
+	p.Now = func() time.Time { return defaultTime }
+	return p
+}
+
+func TestParse(t *testing.T) {
+	p := newTestParser("method")
+	metrics, err := p.Parse([]byte(
+		"method=GET path=\"/api/v1 users\" status=200 duration=0.25 cached=true\n" +
+			"\n" +
+			"method=POST status=500 error=\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "logfmt", metrics[0].Name())
+	assert.Equal(t, map[string]string{
+		"method": "GET",
+		"source": "test",
+	}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"path":     "/api/v1 users",
+		"status":   int64(200),
+		"duration": float64(0.25),
+		"cached":   true,
+	}, metrics[0].Fields())
+	assert.Equal(t, defaultTime.UnixNano(), metrics[0].Time().UnixNano())
+
+	// empty values are skipped
+	assert.Equal(t, map[string]interface{}{
+		"status": int64(500),
+	}, metrics[1].Fields())
+}
+
+func TestParseLine(t *testing.T) {
+	p := newTestParser()
+	m, err := p.ParseLine("level=info count=3")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"source": "test"}, m.Tags())
+	assert.Equal(t, map[string]interface{}{
+		"level": "info",
+		"count": int64(3),
+	}, m.Fields())
+
+	// lines without fields are not metrics
+	m, err = p.ParseLine("")
+	require.NoError(t, err)
+	assert.Nil(t, m)
+}
+
+func TestParseInvalid(t *testing.T) {
+	p := newTestParser()
+	_, err := p.Parse([]byte("key=\"unterminated"))
+	assert.Error(t, err)
+}
@@ -21,9 +21,18 @@ import (
 	"github.com/prometheus/common/expfmt"
 )
 
+// Parser parses the Prometheus exposition format. The text format is
+// expected unless Header has the Content-Type of the delimited protocol
+// buffer format.
+type Parser struct {
+	DefaultTags map[string]string
+	// Header is the header of the HTTP response the data was read from.
+	Header http.Header
+}
+
 // Parse returns a slice of Metrics from a text representation of a
 // metrics
-func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	var metrics []telegraf.Metric
 	var parser expfmt.TextParser
 	// parse even if the buffer begins with a newline
@@ -32,7 +41,7 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 	buffer := bytes.NewBuffer(buf)
 	reader := bufio.NewReader(buffer)
 
-	mediatype, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	mediatype, params, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
 	// Prepare output
 	metricFamilies := make(map[string]*dto.MetricFamily)
 
@@ -61,6 +70,11 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 		for _, m := range mf.Metric {
 			// reading tags
 			tags := makeLabels(m)
+			for k, v := range p.DefaultTags {
+				if _, ok := tags[k]; !ok {
+					tags[k] = v
+				}
+			}
 			// reading fields
 			fields := make(map[string]interface{})
 			if mf.GetType() == dto.MetricType_SUMMARY {
@@ -97,6 +111,27 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 	return metrics, err
 }
 
+// ParseLine parses a single line of the text format, such as
+// `http_requests_total{code="200"} 1027`, into an untyped metric. Lines
+// with no sample, such as `# HELP` and `# TYPE` comments, return a nil metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line + "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, nil
+	}
+
+	return metrics[0], nil
+}
+
+// SetDefaultTags sets the tags added to every parsed metric.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
 func valueType(mt dto.MetricType) telegraf.ValueType {
 	switch mt {
 	case dto.MetricType_COUNTER:
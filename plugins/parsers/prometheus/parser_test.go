@@ -5,7 +5,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var exptime = time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
@@ -101,9 +103,14 @@ cpu,cpu=cpu4 , usage_idle=99,usage_busy=1
 cpu,host=foo,datacenter=us-east usage_idle=99,usage_busy=1
 `
 
+func parse(buf string) ([]telegraf.Metric, error) {
+	parser := &Parser{Header: http.Header{}}
+	return parser.Parse([]byte(buf))
+}
+
 func TestParseValidPrometheus(t *testing.T) {
 	// Gauge value
-	metrics, err := Parse([]byte(validUniqueGauge), http.Header{})
+	metrics, err := parse(validUniqueGauge)
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "cadvisor_version_info", metrics[0].Name())
@@ -117,7 +124,7 @@ func TestParseValidPrometheus(t *testing.T) {
 	}, metrics[0].Tags())
 
 	// Counter value
-	metrics, err = Parse([]byte(validUniqueCounter), http.Header{})
+	metrics, err = parse(validUniqueCounter)
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "get_token_fail_count", metrics[0].Name())
@@ -128,7 +135,7 @@ func TestParseValidPrometheus(t *testing.T) {
 
 	// Summary data
 	//SetDefaultTags(map[string]string{})
-	metrics, err = Parse([]byte(validUniqueSummary), http.Header{})
+	metrics, err = parse(validUniqueSummary)
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "http_request_duration_microseconds", metrics[0].Name())
@@ -142,7 +149,7 @@ func TestParseValidPrometheus(t *testing.T) {
 	assert.Equal(t, map[string]string{"handler": "prometheus"}, metrics[0].Tags())
 
 	// histogram data
-	metrics, err = Parse([]byte(validUniqueHistogram), http.Header{})
+	metrics, err = parse(validUniqueHistogram)
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "apiserver_request_latencies", metrics[0].Name())
@@ -163,3 +170,41 @@ func TestParseValidPrometheus(t *testing.T) {
 		metrics[0].Tags())
 
 }
+
+func TestParseDefaultTags(t *testing.T) {
+	parser := &Parser{}
+	parser.SetDefaultTags(map[string]string{
+		"handler": "default",
+		"source":  "test",
+	})
+	metrics, err := parser.Parse([]byte(validUniqueSummary))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, telegraf.Summary, metrics[0].Type())
+	// labels take precedence over the default tags
+	assert.Equal(t, map[string]string{
+		"handler": "prometheus",
+		"source":  "test",
+	}, metrics[0].Tags())
+}
+
+func TestParseLine(t *testing.T) {
+	parser := &Parser{}
+	m, err := parser.ParseLine(`http_requests_total{code="200"} 1027`)
+	require.NoError(t, err)
+	assert.Equal(t, "http_requests_total", m.Name())
+	assert.Equal(t, telegraf.Untyped, m.Type())
+	assert.Equal(t, map[string]string{"code": "200"}, m.Tags())
+	assert.Equal(t, map[string]interface{}{"value": float64(1027)}, m.Fields())
+
+	// comments produce no metric
+	m, err = parser.ParseLine("# HELP http_requests_total Requests")
+	require.NoError(t, err)
+	assert.Nil(t, m)
+	m, err = parser.ParseLine("# TYPE http_requests_total counter")
+	require.NoError(t, err)
+	assert.Nil(t, m)
+
+	_, err = parser.ParseLine("http_requests_total{code=200} 1027")
+	assert.Error(t, err)
+}
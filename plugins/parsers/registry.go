@@ -6,11 +6,15 @@ import (
 	"github.com/influxdata/telegraf"
 
 	"github.com/influxdata/telegraf/plugins/parsers/collectd"
+	"github.com/influxdata/telegraf/plugins/parsers/csv"
 	"github.com/influxdata/telegraf/plugins/parsers/dropwizard"
 	"github.com/influxdata/telegraf/plugins/parsers/graphite"
+	"github.com/influxdata/telegraf/plugins/parsers/grok"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
+	"github.com/influxdata/telegraf/plugins/parsers/logfmt"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheus"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
 )
 
@@ -42,7 +46,8 @@ type Parser interface {
 // Config is a struct that covers the data types needed for all parser types,
 // and can be used to instantiate _any_ of the parsers.
 type Config struct {
-	// Dataformat can be one of: json, influx, graphite, value, nagios
+	// Dataformat can be one of: json, influx, graphite, value, nagios, csv,
+	// grok, logfmt, prometheus
 	DataFormat string
 
 	// Separator only applied to Graphite data.
@@ -50,8 +55,18 @@ type Config struct {
 	// Templates only apply to Graphite data.
 	Templates []string
 
-	// TagKeys only apply to JSON data
+	// TagKeys only apply to JSON and logfmt data
 	TagKeys []string
+	// JSONStringFields are the keys of JSON string values kept as fields.
+	JSONStringFields []string
+	// JSONNameKey is the key of the measurement name in JSON data.
+	JSONNameKey string
+	// JSONQuery is a GJSON path selecting the JSON object or array to parse.
+	JSONQuery string
+	// JSONTimeKey is the key of the metric time in JSON data, parsed
+	// according to JSONTimeFormat.
+	JSONTimeKey    string
+	JSONTimeFormat string
 	// MetricName applies to JSON & value. This will be the name of the measurement.
 	MetricName string
 
@@ -83,6 +98,38 @@ type Config struct {
 	// an optional map containing tag names as keys and json paths to retrieve the tag values from as values
 	// used if TagsPath is empty or doesn't return any tags
 	DropwizardTagPathsMap map[string]string
+
+	// CSVHeaderRowCount is the number of header rows naming the columns.
+	CSVHeaderRowCount int
+	// CSVSkipRows is the number of rows skipped before the header.
+	CSVSkipRows int
+	// CSVDelimiter separates the columns, defaults to a comma.
+	CSVDelimiter string
+	// CSVComment starts lines that are ignored.
+	CSVComment string
+	// CSVTrimSpace removes the leading and trailing space of each value.
+	CSVTrimSpace bool
+	// CSVColumnNames names the columns, overriding the header.
+	CSVColumnNames []string
+	// CSVColumnTypes sets the type of each column's field.
+	CSVColumnTypes []string
+	// CSVTagColumns are the columns added as tags rather than fields.
+	CSVTagColumns []string
+	// CSVMeasurementColumn is the column holding the measurement name.
+	CSVMeasurementColumn string
+	// CSVTimestampColumn is the column holding the metric time, parsed with
+	// the CSVTimestampFormat Go time layout.
+	CSVTimestampColumn string
+	CSVTimestampFormat string
+
+	// GrokPatterns are the patterns lines are matched against, in order.
+	GrokPatterns []string
+	// GrokCustomPatterns defines patterns, one per line.
+	GrokCustomPatterns string
+	// GrokCustomPatternFiles are files defining patterns.
+	GrokCustomPatternFiles []string
+	// GrokTimezone is the location of timestamps without an offset.
+	GrokTimezone string
 }
 
 // NewParser returns a Parser interface based on the given config.
@@ -91,8 +138,7 @@ func NewParser(config *Config) (Parser, error) {
 	var parser Parser
 	switch config.DataFormat {
 	case "json":
-		parser, err = NewJSONParser(config.MetricName,
-			config.TagKeys, config.DefaultTags)
+		parser, err = newJSONParser(config)
 	case "value":
 		parser, err = NewValueParser(config.MetricName,
 			config.DataType, config.DefaultTags)
@@ -110,6 +156,17 @@ func NewParser(config *Config) (Parser, error) {
 		parser, err = NewDropwizardParser(config.DropwizardMetricRegistryPath,
 			config.DropwizardTimePath, config.DropwizardTimeFormat, config.DropwizardTagsPath, config.DropwizardTagPathsMap, config.DefaultTags,
 			config.Separator, config.Templates)
+	case "csv":
+		parser, err = NewCSVParser(config)
+	case "grok":
+		parser, err = NewGrokParser(config.MetricName, config.GrokPatterns,
+			config.GrokCustomPatterns, config.GrokCustomPatternFiles,
+			config.GrokTimezone, config.DefaultTags)
+	case "logfmt":
+		parser, err = NewLogfmtParser(config.MetricName, config.TagKeys,
+			config.DefaultTags)
+	case "prometheus":
+		parser, err = NewPrometheusParser(config.DefaultTags)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -129,6 +186,24 @@ func NewJSONParser(
 	return parser, nil
 }
 
+func newJSONParser(config *Config) (Parser, error) {
+	if config.JSONTimeKey != "" && config.JSONTimeFormat == "" {
+		return nil, fmt.Errorf("json_time_format must be set along with " +
+			"json_time_key")
+	}
+	parser := &json.JSONParser{
+		MetricName:   config.MetricName,
+		TagKeys:      config.TagKeys,
+		StringFields: config.JSONStringFields,
+		NameKey:      config.JSONNameKey,
+		Query:        config.JSONQuery,
+		TimeKey:      config.JSONTimeKey,
+		TimeFormat:   config.JSONTimeFormat,
+		DefaultTags:  config.DefaultTags,
+	}
+	return parser, nil
+}
+
 func NewNagiosParser() (Parser, error) {
 	return &nagios.NagiosParser{}, nil
 }
@@ -190,3 +265,59 @@ func NewDropwizardParser(
 
 	return parser, err
 }
+
+func NewCSVParser(config *Config) (Parser, error) {
+	parser, err := csv.NewParser(&csv.Parser{
+		MetricName:        config.MetricName,
+		HeaderRowCount:    config.CSVHeaderRowCount,
+		SkipRows:          config.CSVSkipRows,
+		Delimiter:         config.CSVDelimiter,
+		Comment:           config.CSVComment,
+		TrimSpace:         config.CSVTrimSpace,
+		ColumnNames:       config.CSVColumnNames,
+		ColumnTypes:       config.CSVColumnTypes,
+		TagColumns:        config.CSVTagColumns,
+		MeasurementColumn: config.CSVMeasurementColumn,
+		TimestampColumn:   config.CSVTimestampColumn,
+		TimestampFormat:   config.CSVTimestampFormat,
+		DefaultTags:       config.DefaultTags,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+func NewGrokParser(
+	metricName string,
+	patterns []string,
+	customPatterns string,
+	customPatternFiles []string,
+	timezone string,
+	defaultTags map[string]string,
+) (Parser, error) {
+	parser := &grok.Parser{
+		Measurement:        metricName,
+		Patterns:           patterns,
+		CustomPatterns:     customPatterns,
+		CustomPatternFiles: customPatternFiles,
+		Timezone:           timezone,
+		DefaultTags:        defaultTags,
+	}
+	if err := parser.Compile(); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+func NewLogfmtParser(
+	metricName string,
+	tagKeys []string,
+	defaultTags map[string]string,
+) (Parser, error) {
+	return logfmt.NewParser(metricName, tagKeys, defaultTags), nil
+}
+
+func NewPrometheusParser(defaultTags map[string]string) (Parser, error) {
+	return &prometheus.Parser{DefaultTags: defaultTags}, nil
+}
@@ -0,0 +1,379 @@
+package converter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+var sampleConfig = `
+  ## Tags to convert
+  ##
+  ## The table key determines the target type, and the array of key-values
+  ## select the keys to convert.  The array may contain globs.
+  ##   <target-type> = [<tag-key>...]
+  ## Tags converted to a type other than field are removed and added as a
+  ## field of that type. Use field to move a tag to a string field.
+  [processors.converter.tags]
+    field = []
+    string = []
+    integer = []
+    unsigned = []
+    boolean = []
+    float = []
+
+  ## Fields to convert
+  ##
+  ## The table key determines the target type, and the array of key-values
+  ## select the keys to convert.  The array may contain globs.
+  ##   <target-type> = [<field-key>...]
+  ## Use tag to move a field to a tag.
+  [processors.converter.fields]
+    tag = []
+    string = []
+    integer = []
+    unsigned = []
+    boolean = []
+    float = []
+`
+
+type Conversion struct {
+	Tag      []string `toml:"tag"`
+	Field    []string `toml:"field"`
+	String   []string `toml:"string"`
+	Integer  []string `toml:"integer"`
+	Unsigned []string `toml:"unsigned"`
+	Boolean  []string `toml:"boolean"`
+	Float    []string `toml:"float"`
+}
+
+type Converter struct {
+	Tags   *Conversion     `toml:"tags"`
+	Fields *Conversion     `toml:"fields"`
+	Log    telegraf.Logger `toml:"-"`
+
+	conversionErrors selfstat.Stat
+
+	tagConversions   *ConversionFilter
+	fieldConversions *ConversionFilter
+}
+
+// ConversionFilter holds the compiled filters of a Conversion.
+type ConversionFilter struct {
+	Tag      filter.Filter
+	Field    filter.Filter
+	String   filter.Filter
+	Integer  filter.Filter
+	Unsigned filter.Filter
+	Boolean  filter.Filter
+	Float    filter.Filter
+}
+
+func (p *Converter) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Converter) Description() string {
+	return "Convert values to another metric value type"
+}
+
+// Init compiles the filters, so that an invalid one is reported when the
+// configuration is loaded.
+func (p *Converter) Init() error {
+	if err := p.compile(); err != nil {
+		return fmt.Errorf("could not compile filters: %s", err)
+	}
+	return nil
+}
+
+func (p *Converter) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	for _, metric := range metrics {
+		p.convertTags(metric)
+		p.convertFields(metric)
+	}
+	return metrics
+}
+
+func (p *Converter) compile() error {
+	tf, err := compileFilter(p.Tags)
+	if err != nil {
+		return err
+	}
+
+	ff, err := compileFilter(p.Fields)
+	if err != nil {
+		return err
+	}
+
+	p.tagConversions = tf
+	p.fieldConversions = ff
+	return nil
+}
+
+func compileFilter(conv *Conversion) (*ConversionFilter, error) {
+	if conv == nil {
+		return nil, nil
+	}
+
+	var err error
+	cf := &ConversionFilter{}
+	cf.Tag, err = filter.Compile(conv.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	cf.Field, err = filter.Compile(conv.Field)
+	if err != nil {
+		return nil, err
+	}
+
+	cf.String, err = filter.Compile(conv.String)
+	if err != nil {
+		return nil, err
+	}
+
+	cf.Integer, err = filter.Compile(conv.Integer)
+	if err != nil {
+		return nil, err
+	}
+
+	cf.Unsigned, err = filter.Compile(conv.Unsigned)
+	if err != nil {
+		return nil, err
+	}
+
+	cf.Boolean, err = filter.Compile(conv.Boolean)
+	if err != nil {
+		return nil, err
+	}
+
+	cf.Float, err = filter.Compile(conv.Float)
+	if err != nil {
+		return nil, err
+	}
+
+	return cf, nil
+}
+
+// convertTags converts tags into fields
+func (p *Converter) convertTags(metric telegraf.Metric) {
+	if p.tagConversions == nil {
+		return
+	}
+
+	for key, value := range metric.Tags() {
+		var converted interface{}
+		var err error
+		switch {
+		case match(p.tagConversions.Field, key), match(p.tagConversions.String, key):
+			converted = value
+		case match(p.tagConversions.Integer, key):
+			converted, err = toInteger(value)
+		case match(p.tagConversions.Unsigned, key):
+			converted, err = toUnsigned(value)
+		case match(p.tagConversions.Boolean, key):
+			converted, err = toBool(value)
+		case match(p.tagConversions.Float, key):
+			converted, err = toFloat(value)
+		default:
+			continue
+		}
+
+		if err != nil {
+			p.conversionError(metric, "tag", key, err)
+			continue
+		}
+
+		metric.RemoveTag(key)
+		metric.AddField(key, converted)
+	}
+}
+
+// convertFields converts fields into other field types or tags
+func (p *Converter) convertFields(metric telegraf.Metric) {
+	if p.fieldConversions == nil {
+		return
+	}
+
+	for key, value := range metric.Fields() {
+		var converted interface{}
+		var err error
+		switch {
+		case match(p.fieldConversions.Tag, key):
+			tag, err := toString(value)
+			if err != nil {
+				p.conversionError(metric, "field", key, err)
+				continue
+			}
+			metric.AddTag(key, tag)
+			// the last field of a metric cannot be removed, in which case
+			// it is kept along with the new tag
+			metric.RemoveField(key)
+			continue
+		case match(p.fieldConversions.String, key):
+			converted, err = toString(value)
+		case match(p.fieldConversions.Integer, key):
+			converted, err = toInteger(value)
+		case match(p.fieldConversions.Unsigned, key):
+			converted, err = toUnsigned(value)
+		case match(p.fieldConversions.Boolean, key):
+			converted, err = toBool(value)
+		case match(p.fieldConversions.Float, key):
+			converted, err = toFloat(value)
+		default:
+			continue
+		}
+
+		if err != nil {
+			p.conversionError(metric, "field", key, err)
+			continue
+		}
+
+		metric.AddField(key, converted)
+	}
+}
+
+// conversionError counts a failed conversion, leaving the value unchanged.
+func (p *Converter) conversionError(metric telegraf.Metric, kind, key string, err error) {
+	if p.conversionErrors != nil {
+		p.conversionErrors.Incr(1)
+	}
+	if p.Log != nil {
+		p.Log.Debugf("could not convert %s %q of %q: %s",
+			kind, key, metric.Name(), err)
+	}
+}
+
+func match(f filter.Filter, key string) bool {
+	return f != nil && f.Match(key)
+}
+
+func toString(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case uint64:
+		return strconv.FormatUint(value, 10), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	}
+	return "", fmt.Errorf("unsupported type %T", v)
+}
+
+func toInteger(v interface{}) (int64, error) {
+	switch value := v.(type) {
+	case string:
+		result, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			f, ferr := strconv.ParseFloat(value, 64)
+			if ferr != nil {
+				return 0, err
+			}
+			return toInteger(f)
+		}
+		return result, nil
+	case int64:
+		return value, nil
+	case uint64:
+		if value > math.MaxInt64 {
+			return 0, fmt.Errorf("%d overflows an integer", value)
+		}
+		return int64(value), nil
+	case float64:
+		if math.IsNaN(value) || value < math.MinInt64 || value >= math.MaxInt64 {
+			return 0, fmt.Errorf("%v overflows an integer", value)
+		}
+		return int64(value), nil
+	case bool:
+		if value {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unsupported type %T", v)
+}
+
+func toUnsigned(v interface{}) (uint64, error) {
+	switch value := v.(type) {
+	case string:
+		result, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			f, ferr := strconv.ParseFloat(value, 64)
+			if ferr != nil {
+				return 0, err
+			}
+			return toUnsigned(f)
+		}
+		return result, nil
+	case int64:
+		if value < 0 {
+			return 0, fmt.Errorf("%d is negative", value)
+		}
+		return uint64(value), nil
+	case uint64:
+		return value, nil
+	case float64:
+		if math.IsNaN(value) || value < 0 || value >= math.MaxUint64 {
+			return 0, fmt.Errorf("%v overflows an unsigned integer", value)
+		}
+		return uint64(value), nil
+	case bool:
+		if value {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unsupported type %T", v)
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch value := v.(type) {
+	case string:
+		return strconv.ParseFloat(value, 64)
+	case int64:
+		return float64(value), nil
+	case uint64:
+		return float64(value), nil
+	case float64:
+		return value, nil
+	case bool:
+		if value {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unsupported type %T", v)
+}
+
+func toBool(v interface{}) (bool, error) {
+	switch value := v.(type) {
+	case string:
+		return strconv.ParseBool(value)
+	case int64:
+		return value != 0, nil
+	case uint64:
+		return value != 0, nil
+	case float64:
+		return value != 0, nil
+	case bool:
+		return value, nil
+	}
+	return false, fmt.Errorf("unsupported type %T", v)
+}
+
+func init() {
+	processors.Add("converter", func() telegraf.Processor {
+		return &Converter{
+			conversionErrors: selfstat.Register("converter", "conversion_errors", map[string]string{}),
+		}
+	})
+}
@@ -0,0 +1,241 @@
+package converter
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Metric(v telegraf.Metric, err error) telegraf.Metric {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func TestConverter(t *testing.T) {
+	tests := []struct {
+		name           string
+		converter      *Converter
+		input          telegraf.Metric
+		expectedTags   map[string]string
+		expectedFields map[string]interface{}
+	}{
+		{
+			name:      "empty",
+			converter: &Converter{},
+			input: Metric(metric.New("cpu",
+				map[string]string{"host": "localhost"},
+				map[string]interface{}{"value": int64(42)},
+				time.Unix(0, 0),
+			)),
+			expectedTags:   map[string]string{"host": "localhost"},
+			expectedFields: map[string]interface{}{"value": int64(42)},
+		},
+		{
+			name: "from tag",
+			converter: &Converter{
+				Tags: &Conversion{
+					Field:    []string{"name"},
+					String:   []string{"string"},
+					Integer:  []string{"int*"},
+					Unsigned: []string{"uint"},
+					Boolean:  []string{"bool"},
+					Float:    []string{"float"},
+				},
+			},
+			input: Metric(metric.New("cpu",
+				map[string]string{
+					"name":      "eth0",
+					"string":    "howdy",
+					"int":       "42",
+					"int_float": "42.7",
+					"uint":      "42",
+					"bool":      "true",
+					"float":     "4.2",
+					"host":      "localhost",
+				},
+				map[string]interface{}{"value": int64(42)},
+				time.Unix(0, 0),
+			)),
+			expectedTags: map[string]string{"host": "localhost"},
+			expectedFields: map[string]interface{}{
+				"value":     int64(42),
+				"name":      "eth0",
+				"string":    "howdy",
+				"int":       int64(42),
+				"int_float": int64(42),
+				"uint":      int64(42),
+				"bool":      true,
+				"float":     float64(4.2),
+			},
+		},
+		{
+			name: "from string field",
+			converter: &Converter{
+				Fields: &Conversion{
+					Tag:      []string{"name"},
+					Integer:  []string{"int"},
+					Unsigned: []string{"uint"},
+					Boolean:  []string{"bool"},
+					Float:    []string{"float"},
+				},
+			},
+			input: Metric(metric.New("cpu",
+				nil,
+				map[string]interface{}{
+					"name":  "eth0",
+					"int":   "-42",
+					"uint":  "42",
+					"bool":  "false",
+					"float": "4.2",
+				},
+				time.Unix(0, 0),
+			)),
+			expectedTags: map[string]string{"name": "eth0"},
+			expectedFields: map[string]interface{}{
+				"int":   int64(-42),
+				"uint":  int64(42),
+				"bool":  false,
+				"float": float64(4.2),
+			},
+		},
+		{
+			name: "from numeric fields",
+			converter: &Converter{
+				Fields: &Conversion{
+					Tag:      []string{"id"},
+					String:   []string{"a"},
+					Integer:  []string{"b"},
+					Unsigned: []string{"c"},
+					Boolean:  []string{"d"},
+					Float:    []string{"e"},
+				},
+			},
+			input: Metric(metric.New("cpu",
+				nil,
+				map[string]interface{}{
+					"id": float64(12345),
+					"a":  int64(42),
+					"b":  float64(42.9),
+					"c":  int64(42),
+					"d":  int64(0),
+					"e":  uint64(42),
+				},
+				time.Unix(0, 0),
+			)),
+			expectedTags: map[string]string{"id": "12345"},
+			expectedFields: map[string]interface{}{
+				"a": "42",
+				"b": int64(42),
+				"c": int64(42),
+				"d": false,
+				"e": float64(42),
+			},
+		},
+		{
+			name: "conversion errors leave the value unchanged",
+			converter: &Converter{
+				Tags: &Conversion{
+					Integer: []string{"host"},
+				},
+				Fields: &Conversion{
+					Unsigned: []string{"negative"},
+					Integer:  []string{"huge"},
+					Boolean:  []string{"word"},
+				},
+			},
+			input: Metric(metric.New("cpu",
+				map[string]string{"host": "localhost"},
+				map[string]interface{}{
+					"negative": int64(-1),
+					"huge":     math.MaxFloat64,
+					"word":     "maybe",
+				},
+				time.Unix(0, 0),
+			)),
+			expectedTags: map[string]string{"host": "localhost"},
+			expectedFields: map[string]interface{}{
+				"negative": int64(-1),
+				"huge":     math.MaxFloat64,
+				"word":     "maybe",
+			},
+		},
+		{
+			name: "last field is kept when converted to a tag",
+			converter: &Converter{
+				Fields: &Conversion{
+					Tag: []string{"value"},
+				},
+			},
+			input: Metric(metric.New("cpu",
+				nil,
+				map[string]interface{}{"value": int64(42)},
+				time.Unix(0, 0),
+			)),
+			expectedTags:   map[string]string{"value": "42"},
+			expectedFields: map[string]interface{}{"value": int64(42)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.converter.Log = testutil.Logger{}
+			require.NoError(t, tt.converter.Init())
+			metrics := tt.converter.Apply(tt.input)
+
+			require.Len(t, metrics, 1)
+			assert.Equal(t, "cpu", metrics[0].Name())
+			assert.Equal(t, tt.expectedTags, metrics[0].Tags())
+			assert.Equal(t, tt.expectedFields, metrics[0].Fields())
+		})
+	}
+}
+
+func TestConversionErrors(t *testing.T) {
+	stat := selfstat.Register("converter_test", "conversion_errors", map[string]string{})
+	converter := &Converter{
+		Fields: &Conversion{
+			Integer: []string{"*"},
+		},
+		Log:              testutil.Logger{},
+		conversionErrors: stat,
+	}
+	require.NoError(t, converter.Init())
+
+	m := Metric(metric.New("cpu",
+		nil,
+		map[string]interface{}{
+			"a": "one",
+			"b": "2",
+			"c": "three",
+		},
+		time.Unix(0, 0),
+	))
+	metrics := converter.Apply(m)
+
+	require.Len(t, metrics, 1)
+	assert.Equal(t, int64(2), stat.Get())
+	assert.Equal(t, map[string]interface{}{
+		"a": "one",
+		"b": int64(2),
+		"c": "three",
+	}, metrics[0].Fields())
+}
+
+func TestInvalidFilter(t *testing.T) {
+	converter := &Converter{
+		Fields: &Conversion{
+			Integer: []string{"a[b"},
+		},
+	}
+
+	require.Error(t, converter.Init())
+}
@@ -0,0 +1,122 @@
+package enum
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Mappings are applied in the order they are defined.
+  # [[processors.enum.mapping]]
+  #   ## Name of the field to map
+  #   field = "status"
+  #   ## Name of the tag to map, instead of a field
+  #   # tag = "status"
+  #
+  #   ## Destination field or tag to be used for the mapped value. By default
+  #   ## the source field or tag is used, overwriting the original value.
+  #   # dest = "status_code"
+  #
+  #   ## Default value to be used for all values not contained in the mapping
+  #   ## table. When unset, the unmodified value is used.
+  #   # default = 0
+  #
+  #   ## Table of mappings
+  #   [processors.enum.mapping.value_mappings]
+  #     green = 1
+  #     yellow = 2
+  #     red = 3
+`
+
+type EnumMapper struct {
+	Mappings []Mapping `toml:"mapping"`
+}
+
+// Mapping maps the values of a field or tag through the ValueMappings table.
+type Mapping struct {
+	Field         string
+	Tag           string
+	Dest          string
+	Default       interface{}
+	ValueMappings map[string]interface{}
+}
+
+func (e *EnumMapper) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *EnumMapper) Description() string {
+	return "Map enum values according to given table."
+}
+
+func (e *EnumMapper) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, metric := range in {
+		for _, mapping := range e.Mappings {
+			if mapping.Field != "" {
+				value, ok := metric.Fields()[mapping.Field]
+				if !ok {
+					continue
+				}
+				if mapped, ok := mapping.mapValue(value); ok {
+					metric.AddField(mapping.destination(mapping.Field), mapped)
+				}
+			} else if mapping.Tag != "" {
+				value, ok := metric.Tags()[mapping.Tag]
+				if !ok {
+					continue
+				}
+				if mapped, ok := mapping.mapValue(value); ok {
+					metric.AddTag(mapping.destination(mapping.Tag), fmt.Sprint(mapped))
+				}
+			}
+		}
+	}
+	return in
+}
+
+// mapValue returns the value mapped from the original value, or the default
+// value if the original value is not in the table. It returns false if there
+// is no value to set.
+func (m *Mapping) mapValue(original interface{}) (interface{}, bool) {
+	if key, ok := valueKey(original); ok {
+		if mapped, found := m.ValueMappings[key]; found {
+			return mapped, true
+		}
+	}
+	if m.Default != nil {
+		return m.Default, true
+	}
+	return nil, false
+}
+
+func (m *Mapping) destination(source string) string {
+	if m.Dest != "" {
+		return m.Dest
+	}
+	return source
+}
+
+// valueKey returns the key of a value in the table of mappings, only string,
+// boolean and integer values can be mapped.
+func valueKey(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	}
+	return "", false
+}
+
+func init() {
+	processors.Add("enum", func() telegraf.Processor {
+		return &EnumMapper{}
+	})
+}
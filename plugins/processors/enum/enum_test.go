@@ -0,0 +1,126 @@
+package enum
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestMetric() telegraf.Metric {
+	metric, _ := metric.New("m1",
+		map[string]string{"tag": "tag_value", "resp_code": "404"},
+		map[string]interface{}{
+			"string_value": "test",
+			"int_value":    int64(200),
+			"true_value":   true,
+			"float_value":  float64(1.5),
+		},
+		time.Now(),
+	)
+	return metric
+}
+
+func calculateProcessedValues(mapper EnumMapper, metric telegraf.Metric) map[string]interface{} {
+	processed := mapper.Apply(metric)
+	return processed[0].Fields()
+}
+
+func assertFieldValue(t *testing.T, expected interface{}, field string, fields map[string]interface{}) {
+	value, present := fields[field]
+	assert.True(t, present, "value of field '"+field+"' was not present")
+	assert.EqualValues(t, expected, value)
+}
+
+func TestRetainsMetric(t *testing.T) {
+	mapper := EnumMapper{}
+	source := createTestMetric()
+
+	target := mapper.Apply(source)[0]
+	fields := target.Fields()
+
+	assertFieldValue(t, "test", "string_value", fields)
+	assertFieldValue(t, 200, "int_value", fields)
+	assertFieldValue(t, true, "true_value", fields)
+	assert.Equal(t, "m1", target.Name())
+	assert.Equal(t, source.Tags(), target.Tags())
+	assert.Equal(t, source.Time(), target.Time())
+}
+
+func TestMapsSingleStringValue(t *testing.T) {
+	mapper := EnumMapper{Mappings: []Mapping{{Field: "string_value", ValueMappings: map[string]interface{}{"test": int64(1)}}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, 1, "string_value", fields)
+}
+
+func TestMapsIntegerAndBooleanValues(t *testing.T) {
+	mapper := EnumMapper{Mappings: []Mapping{
+		{Field: "int_value", ValueMappings: map[string]interface{}{"200": "OK"}},
+		{Field: "true_value", ValueMappings: map[string]interface{}{"true": int64(1)}},
+	}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, "OK", "int_value", fields)
+	assertFieldValue(t, 1, "true_value", fields)
+}
+
+func TestNoFailureOnMappingsOnNonSupportedValues(t *testing.T) {
+	mapper := EnumMapper{Mappings: []Mapping{{Field: "float_value", ValueMappings: map[string]interface{}{"1.5": "one"}}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, 1.5, "float_value", fields)
+}
+
+func TestUsesDefaultValue(t *testing.T) {
+	mapper := EnumMapper{Mappings: []Mapping{{Field: "string_value", Default: "default", ValueMappings: map[string]interface{}{"other": int64(1)}}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, "default", "string_value", fields)
+}
+
+func TestDoNotMapToDefaultValueKnownSourceValue(t *testing.T) {
+	mapper := EnumMapper{Mappings: []Mapping{{Field: "string_value", Default: "default", ValueMappings: map[string]interface{}{"test": int64(1)}}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, 1, "string_value", fields)
+}
+
+func TestNoMappingWithoutDefaultOrDefinedMappingValue(t *testing.T) {
+	mapper := EnumMapper{Mappings: []Mapping{{Field: "string_value", ValueMappings: map[string]interface{}{"other": int64(1)}}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, "test", "string_value", fields)
+}
+
+func TestWritesToDestination(t *testing.T) {
+	mapper := EnumMapper{Mappings: []Mapping{{Field: "string_value", Dest: "string_code", ValueMappings: map[string]interface{}{"test": int64(1)}}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, "test", "string_value", fields)
+	assertFieldValue(t, 1, "string_code", fields)
+}
+
+func TestMapsTagValue(t *testing.T) {
+	mapper := EnumMapper{Mappings: []Mapping{
+		{Tag: "resp_code", Dest: "resp_class", Default: "other", ValueMappings: map[string]interface{}{"200": "success", "404": "client_error"}},
+		{Tag: "tag", ValueMappings: map[string]interface{}{"tag_value": int64(1)}},
+	}}
+
+	processed := mapper.Apply(createTestMetric())
+
+	assert.Equal(t, map[string]string{
+		"tag":        "1",
+		"resp_code":  "404",
+		"resp_class": "client_error",
+	}, processed[0].Tags())
+}
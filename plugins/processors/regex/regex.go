@@ -0,0 +1,142 @@
+package regex
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Rules are applied in the order they are defined, each rule operating on
+  ## the result of the previous ones.
+
+  ## Tag rules
+  # [[processors.regex.tags]]
+  #   ## Tag to change
+  #   key = "resp_code"
+  #   ## Regular expression to match on a tag value
+  #   pattern = "^(\\d)\\d\\d$"
+  #   ## Pattern for constructing a new value (${1} represents first subgroup)
+  #   replacement = "${1}xx"
+
+  ## Field rules, only string fields are changed
+  # [[processors.regex.fields]]
+  #   key = "request"
+  #   ## All the power of the Go regular expressions available here
+  #   ## For example, named subgroups
+  #   pattern = "^/api(?P<method>/[\\w/]+)\\S*"
+  #   replacement = "${method}"
+  #   ## If result_key is present, a new field will be created
+  #   ## instead of changing existing field
+  #   result_key = "method"
+
+  ## Measurement name rules, key is not used
+  # [[processors.regex.measurement]]
+  #   pattern = "^(\\w+)\\.v\\d+$"
+  #   replacement = "${1}"
+`
+
+type Regex struct {
+	Tags        []Converter
+	Fields      []Converter
+	Measurement []Converter
+
+	regexCache map[string]*regexp.Regexp
+}
+
+// Converter replaces the matches of Pattern in the value of Key by
+// Replacement, storing the result in ResultKey if set.
+type Converter struct {
+	Key         string
+	Pattern     string
+	Replacement string
+	ResultKey   string `toml:"result_key"`
+}
+
+func NewRegex() *Regex {
+	return &Regex{
+		regexCache: make(map[string]*regexp.Regexp),
+	}
+}
+
+func (r *Regex) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Regex) Description() string {
+	return "Transforms tag values, string field values and measurement names with regex patterns"
+}
+
+// Init compiles the patterns of all the rules, so that an invalid one is
+// reported when the configuration is loaded.
+func (r *Regex) Init() error {
+	for _, converters := range [][]Converter{r.Measurement, r.Tags, r.Fields} {
+		for _, c := range converters {
+			if _, ok := r.regexCache[c.Pattern]; ok {
+				continue
+			}
+			regex, err := regexp.Compile(c.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %q: %s", c.Pattern, err)
+			}
+			r.regexCache[c.Pattern] = regex
+		}
+	}
+	return nil
+}
+
+func (r *Regex) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, metric := range in {
+		for _, converter := range r.Measurement {
+			if value, ok := r.convert(converter, metric.Name()); ok {
+				metric.SetName(value)
+			}
+		}
+
+		for _, converter := range r.Tags {
+			if value, ok := metric.Tags()[converter.Key]; ok {
+				if value, ok := r.convert(converter, value); ok {
+					metric.AddTag(resultKey(converter), value)
+				}
+			}
+		}
+
+		for _, converter := range r.Fields {
+			if value, ok := metric.Fields()[converter.Key]; ok {
+				switch value := value.(type) {
+				case string:
+					if value, ok := r.convert(converter, value); ok {
+						metric.AddField(resultKey(converter), value)
+					}
+				}
+			}
+		}
+	}
+
+	return in
+}
+
+// convert returns the replaced value and true if the pattern of the converter
+// matches the value.
+func (r *Regex) convert(c Converter, value string) (string, bool) {
+	regex := r.regexCache[c.Pattern]
+	if regex == nil || !regex.MatchString(value) {
+		return "", false
+	}
+	return regex.ReplaceAllString(value, c.Replacement), true
+}
+
+func resultKey(c Converter) string {
+	if c.ResultKey != "" {
+		return c.ResultKey
+	}
+	return c.Key
+}
+
+func init() {
+	processors.Add("regex", func() telegraf.Processor {
+		return NewRegex()
+	})
+}
@@ -0,0 +1,263 @@
+package regex
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newM1() telegraf.Metric {
+	m1, _ := metric.New("access_log",
+		map[string]string{
+			"verb":      "GET",
+			"resp_code": "200",
+		},
+		map[string]interface{}{
+			"request": "/users/42/",
+		},
+		time.Now(),
+	)
+	return m1
+}
+
+func newM2() telegraf.Metric {
+	m2, _ := metric.New("access_log.v2",
+		map[string]string{
+			"verb":      "GET",
+			"resp_code": "200",
+		},
+		map[string]interface{}{
+			"request":       "/api/search/?category=plugins&q=regex&sort=asc",
+			"ignore_number": int64(200),
+			"ignore_bool":   true,
+		},
+		time.Now(),
+	)
+	return m2
+}
+
+func TestFieldConversions(t *testing.T) {
+	tests := []struct {
+		message        string
+		converter      Converter
+		expectedFields map[string]interface{}
+	}{
+		{
+			message: "Should change existing field",
+			converter: Converter{
+				Key:         "request",
+				Pattern:     "^/users/\\d+/$",
+				Replacement: "/users/{id}/",
+			},
+			expectedFields: map[string]interface{}{
+				"request": "/users/{id}/",
+			},
+		},
+		{
+			message: "Should add new field",
+			converter: Converter{
+				Key:         "request",
+				Pattern:     "^/users/\\d+/$",
+				Replacement: "/users/{id}/",
+				ResultKey:   "normalized_request",
+			},
+			expectedFields: map[string]interface{}{
+				"request":            "/users/42/",
+				"normalized_request": "/users/{id}/",
+			},
+		},
+		{
+			message: "Should not change field that does not match",
+			converter: Converter{
+				Key:         "request",
+				Pattern:     "^/groups/\\d+/$",
+				Replacement: "/groups/{id}/",
+				ResultKey:   "normalized_request",
+			},
+			expectedFields: map[string]interface{}{
+				"request": "/users/42/",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		regex := NewRegex()
+		regex.Fields = []Converter{test.converter}
+
+		require.NoError(t, regex.Init())
+		processed := regex.Apply(newM1())
+
+		expectedTags := map[string]string{
+			"verb":      "GET",
+			"resp_code": "200",
+		}
+
+		assert.Equal(t, test.expectedFields, processed[0].Fields(), test.message)
+		assert.Equal(t, expectedTags, processed[0].Tags(), "Should not change tags")
+		assert.Equal(t, "access_log", processed[0].Name(), "Should not change name")
+	}
+}
+
+func TestTagConversions(t *testing.T) {
+	tests := []struct {
+		message      string
+		converter    Converter
+		expectedTags map[string]string
+	}{
+		{
+			message: "Should change existing tag",
+			converter: Converter{
+				Key:         "resp_code",
+				Pattern:     "^(\\d)\\d\\d$",
+				Replacement: "${1}xx",
+			},
+			expectedTags: map[string]string{
+				"verb":      "GET",
+				"resp_code": "2xx",
+			},
+		},
+		{
+			message: "Should add new tag",
+			converter: Converter{
+				Key:         "resp_code",
+				Pattern:     "^(\\d)\\d\\d$",
+				Replacement: "${1}xx",
+				ResultKey:   "resp_code_group",
+			},
+			expectedTags: map[string]string{
+				"verb":            "GET",
+				"resp_code":       "200",
+				"resp_code_group": "2xx",
+			},
+		},
+		{
+			message: "Should ignore missing tag",
+			converter: Converter{
+				Key:         "missing",
+				Pattern:     ".*",
+				Replacement: "value",
+			},
+			expectedTags: map[string]string{
+				"verb":      "GET",
+				"resp_code": "200",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		regex := NewRegex()
+		regex.Tags = []Converter{test.converter}
+
+		require.NoError(t, regex.Init())
+		processed := regex.Apply(newM1())
+
+		expectedFields := map[string]interface{}{
+			"request": "/users/42/",
+		}
+
+		assert.Equal(t, expectedFields, processed[0].Fields(), "Should not change fields")
+		assert.Equal(t, test.expectedTags, processed[0].Tags(), test.message)
+		assert.Equal(t, "access_log", processed[0].Name(), "Should not change name")
+	}
+}
+
+func TestMeasurementConversion(t *testing.T) {
+	regex := NewRegex()
+	regex.Measurement = []Converter{
+		{
+			Pattern:     "^(\\w+)\\.v\\d+$",
+			Replacement: "${1}",
+		},
+	}
+
+	require.NoError(t, regex.Init())
+	processed := regex.Apply(newM2())
+
+	assert.Equal(t, "access_log", processed[0].Name())
+}
+
+func TestMultipleConversions(t *testing.T) {
+	regex := NewRegex()
+	regex.Tags = []Converter{
+		{
+			Key:         "resp_code",
+			Pattern:     "^(\\d)\\d\\d$",
+			Replacement: "${1}xx",
+			ResultKey:   "resp_code_group",
+		},
+		{
+			Key:         "resp_code_group",
+			Pattern:     "2xx",
+			Replacement: "OK",
+			ResultKey:   "resp_code_text",
+		},
+	}
+	regex.Fields = []Converter{
+		{
+			Key:         "request",
+			Pattern:     "^/api(?P<method>/[\\w/]+)\\S*",
+			Replacement: "${method}",
+			ResultKey:   "method",
+		},
+		{
+			Key:         "request",
+			Pattern:     ".*category=(\\w+).*",
+			Replacement: "${1}",
+			ResultKey:   "search_category",
+		},
+		{
+			Key:         "request",
+			Pattern:     "\\?.*$",
+			Replacement: "",
+		},
+		{
+			Key:         "ignore_number",
+			Pattern:     ".*",
+			Replacement: "-",
+		},
+		{
+			Key:         "ignore_bool",
+			Pattern:     ".*",
+			Replacement: "-",
+		},
+	}
+
+	require.NoError(t, regex.Init())
+	processed := regex.Apply(newM2())
+
+	expectedFields := map[string]interface{}{
+		"request":         "/api/search/",
+		"method":          "/search/",
+		"search_category": "plugins",
+		"ignore_number":   int64(200),
+		"ignore_bool":     true,
+	}
+	expectedTags := map[string]string{
+		"verb":            "GET",
+		"resp_code":       "200",
+		"resp_code_group": "2xx",
+		"resp_code_text":  "OK",
+	}
+
+	assert.Equal(t, expectedFields, processed[0].Fields())
+	assert.Equal(t, expectedTags, processed[0].Tags())
+	assert.Len(t, regexp.MustCompile("[ ,]request=").FindAllString(processed[0].String(), -1), 1, "Should replace the field in place")
+}
+
+func TestInvalidPattern(t *testing.T) {
+	regex := NewRegex()
+	regex.Tags = []Converter{
+		{
+			Key:         "verb",
+			Pattern:     "(",
+			Replacement: "POST",
+		},
+	}
+
+	require.Error(t, regex.Init())
+}
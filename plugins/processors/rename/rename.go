@@ -0,0 +1,87 @@
+package rename
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Replacements are applied in the order they are defined, each one
+  ## operating on the result of the previous ones. Each replacement sets
+  ## exactly one of measurement, tag or field.
+
+  ## Rename the measurement
+  # [[processors.rename.replace]]
+  #   measurement = "network_interface_throughput"
+  #   dest = "throughput"
+
+  ## Rename a tag
+  # [[processors.rename.replace]]
+  #   tag = "hostname"
+  #   dest = "host"
+
+  ## Rename a field
+  # [[processors.rename.replace]]
+  #   field = "lower"
+  #   dest = "min"
+`
+
+type Replace struct {
+	Measurement string
+	Tag         string
+	Field       string
+	Dest        string
+}
+
+type Rename struct {
+	Replaces []Replace `toml:"replace"`
+}
+
+func (r *Rename) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Rename) Description() string {
+	return "Rename measurements, tags, and fields that pass through this filter."
+}
+
+func (r *Rename) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, metric := range in {
+		for _, replace := range r.Replaces {
+			if replace.Dest == "" || replace.Dest == replace.Tag || replace.Dest == replace.Field {
+				continue
+			}
+
+			if replace.Measurement != "" {
+				if metric.Name() == replace.Measurement {
+					metric.SetName(replace.Dest)
+				}
+				continue
+			}
+
+			if replace.Tag != "" {
+				if value, ok := metric.Tags()[replace.Tag]; ok {
+					metric.RemoveTag(replace.Tag)
+					metric.AddTag(replace.Dest, value)
+				}
+				continue
+			}
+
+			if replace.Field != "" {
+				if value, ok := metric.Fields()[replace.Field]; ok {
+					// add the new field first, as the last field of a
+					// metric cannot be removed
+					metric.AddField(replace.Dest, value)
+					metric.RemoveField(replace.Field)
+				}
+			}
+		}
+	}
+	return in
+}
+
+func init() {
+	processors.Add("rename", func() telegraf.Processor {
+		return &Rename{}
+	})
+}
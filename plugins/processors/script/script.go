@@ -0,0 +1,469 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/yuin/gopher-lua"
+)
+
+var sampleConfig = `
+  ## Source of the Lua script, which must define an apply(metric) function
+  ## returning the metrics to pass on: the metric, a list of metrics or nil to
+  ## drop it. Global variables keep their values between calls.
+  source = '''
+function apply(metric)
+  return metric
+end
+'''
+
+  ## Path of a file holding the script, instead of source.
+  # script = "/usr/local/share/telegraf/apply.lua"
+
+  ## Maximum time a call to apply may take, the metric is passed on
+  ## unchanged when it is exceeded.
+  # timeout = "1s"
+`
+
+const (
+	defaultTimeout = time.Second
+
+	// Sizes of the call stack and of the data stack of the interpreter, which
+	// bound the recursion depth and memory of scripts.
+	callStackSize = 256
+	registrySize  = 256 * 20
+)
+
+// Functions of the base library which access the file system or print to
+// stdout, and are not available to scripts.
+var unsafeFunctions = []string{
+	"dofile",
+	"loadfile",
+	"module",
+	"require",
+	"_printregs",
+}
+
+type Script struct {
+	Source  string
+	Script  string
+	Timeout internal.Duration
+	Log     telegraf.Logger `toml:"-"`
+
+	state *lua.LState
+	apply lua.LValue
+}
+
+func (s *Script) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Script) Description() string {
+	return "Process metrics using a Lua script"
+}
+
+func (s *Script) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := make([]telegraf.Metric, 0, len(in))
+	for _, m := range in {
+		metrics, err := s.applyOne(m)
+		if err != nil {
+			if s.Log != nil {
+				s.Log.Errorf("apply failed on %q: %s", m.Name(), err)
+			}
+			out = append(out, m)
+			continue
+		}
+		out = append(out, metrics...)
+	}
+	return out
+}
+
+// Init creates the sandboxed interpreter and runs the script, so that a
+// script which cannot be loaded is reported when the configuration is loaded.
+func (s *Script) Init() error {
+	if s.Timeout.Duration <= 0 {
+		s.Timeout.Duration = defaultTimeout
+	}
+
+	source := s.Source
+	if s.Script != "" {
+		buf, err := ioutil.ReadFile(s.Script)
+		if err != nil {
+			return err
+		}
+		source = string(buf)
+	}
+	if source == "" {
+		return fmt.Errorf("either source or script must be set")
+	}
+
+	L := lua.NewState(lua.Options{
+		SkipOpenLibs:  true,
+		CallStackSize: callStackSize,
+		RegistrySize:  registrySize,
+	})
+	for _, lib := range []struct {
+		name string
+		fn   lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.fn))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range unsafeFunctions {
+		L.SetGlobal(name, lua.LNil)
+	}
+	L.SetGlobal("print", L.NewFunction(s.luaPrint))
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout.Duration)
+	L.SetContext(ctx)
+	err := L.DoString(source)
+	L.RemoveContext()
+	cancel()
+	if err != nil {
+		L.Close()
+		return err
+	}
+
+	apply := L.GetGlobal("apply")
+	if apply.Type() != lua.LTFunction {
+		L.Close()
+		return fmt.Errorf("script does not define an apply function")
+	}
+
+	s.state = L
+	s.apply = apply
+	return nil
+}
+
+// applyOne calls the apply function of the script with the metric, and
+// returns the metrics it returns. The metric is modified in place when it is
+// returned with the same time, otherwise new metrics are created and the
+// metric is dropped, the new metrics sharing its tracking. The call is aborted when it takes longer than the
+// timeout.
+func (s *Script) applyOne(m telegraf.Metric) ([]telegraf.Metric, error) {
+	table := toTable(s.state, m)
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout.Duration)
+	defer cancel()
+	s.state.SetContext(ctx)
+	err := s.state.CallByParam(lua.P{
+		Fn:      s.apply,
+		NRet:    1,
+		Protect: true,
+	}, table)
+	s.state.RemoveContext()
+	if err != nil {
+		return nil, err
+	}
+	ret := s.state.Get(-1)
+	s.state.Pop(1)
+
+	var tables []*lua.LTable
+	switch ret := ret.(type) {
+	case *lua.LNilType:
+	case *lua.LTable:
+		if ret.RawGetString("name") != lua.LNil {
+			tables = append(tables, ret)
+			break
+		}
+		for i := 1; i <= ret.Len(); i++ {
+			t, ok := ret.RawGetInt(i).(*lua.LTable)
+			if !ok {
+				return nil, fmt.Errorf("apply returned a list holding a %s", ret.RawGetInt(i).Type())
+			}
+			tables = append(tables, t)
+		}
+	default:
+		return nil, fmt.Errorf("apply returned a %s", ret.Type())
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(tables))
+	inPlace := false
+	for _, t := range tables {
+		tp := telegraf.Untyped
+		if t == table {
+			if !inPlace {
+				ok, err := update(m, t)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					inPlace = true
+					metrics = append(metrics, m)
+					continue
+				}
+			}
+			tp = m.Type()
+		}
+
+		n, err := fromTable(m, t, tp)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metric.WithTrackingOf(n, m))
+	}
+
+	if !inPlace {
+		m.Drop()
+	}
+	return metrics, nil
+}
+
+// toTable returns the metric as a table with the name, tags, fields and time
+// of the metric, the time being in nanoseconds.
+func toTable(L *lua.LState, m telegraf.Metric) *lua.LTable {
+	tags := L.NewTable()
+	for k, v := range m.Tags() {
+		tags.RawSetString(k, lua.LString(v))
+	}
+
+	fields := L.NewTable()
+	for k, v := range m.Fields() {
+		if lv, ok := toLuaValue(v); ok {
+			fields.RawSetString(k, lv)
+		}
+	}
+
+	table := L.NewTable()
+	table.RawSetString("name", lua.LString(m.Name()))
+	table.RawSetString("tags", tags)
+	table.RawSetString("fields", fields)
+	table.RawSetString("time", lua.LNumber(m.UnixNano()))
+	return table
+}
+
+// update applies the changes made by the script to the table of the metric,
+// and returns false if the time of the metric changed, which requires a new
+// metric.
+func update(m telegraf.Metric, t *lua.LTable) (bool, error) {
+	name, tags, fields, err := decode(m, t)
+	if err != nil {
+		return false, err
+	}
+
+	tm, err := decodeTime(m, t.RawGetString("time"))
+	if err != nil {
+		return false, err
+	}
+	if !tm.Equal(m.Time()) {
+		return false, nil
+	}
+
+	if name != m.Name() {
+		m.SetName(name)
+	}
+
+	for k, v := range m.Tags() {
+		if _, ok := tags[k]; !ok {
+			m.RemoveTag(k)
+		} else if tags[k] == v {
+			delete(tags, k)
+		}
+	}
+	for k, v := range tags {
+		m.AddTag(k, v)
+	}
+
+	old := m.Fields()
+	for k, v := range fields {
+		if old[k] != v {
+			m.AddField(k, v)
+		}
+	}
+	for k, v := range old {
+		// fields missing from the table of the metric are kept
+		if _, ok := toLuaValue(v); !ok {
+			continue
+		}
+		if _, ok := fields[k]; !ok {
+			m.RemoveField(k)
+		}
+	}
+	return true, nil
+}
+
+// fromTable creates a metric of the given type from a table returned by the
+// script, the time of the metric passed to the script being used if the table
+// has none.
+func fromTable(m telegraf.Metric, t *lua.LTable, tp telegraf.ValueType) (telegraf.Metric, error) {
+	name, tags, fields, err := decode(m, t)
+	if err != nil {
+		return nil, err
+	}
+
+	tm, err := decodeTime(m, t.RawGetString("time"))
+	if err != nil {
+		return nil, err
+	}
+
+	return metric.New(name, tags, fields, tm, tp)
+}
+
+func decode(m telegraf.Metric, t *lua.LTable) (string, map[string]string, map[string]interface{}, error) {
+	name, ok := t.RawGetString("name").(lua.LString)
+	if !ok || name == "" {
+		return "", nil, nil, fmt.Errorf("metric has no name")
+	}
+
+	tags := make(map[string]string)
+	switch lt := t.RawGetString("tags").(type) {
+	case *lua.LNilType:
+	case *lua.LTable:
+		var err error
+		lt.ForEach(func(k, v lua.LValue) {
+			value, ok := toTagValue(v)
+			if !ok && err == nil {
+				err = fmt.Errorf("tag %q has a %s value", k.String(), v.Type())
+			}
+			tags[k.String()] = value
+		})
+		if err != nil {
+			return "", nil, nil, err
+		}
+	default:
+		return "", nil, nil, fmt.Errorf("tags are a %s", lt.Type())
+	}
+
+	fields := make(map[string]interface{})
+	lt, ok := t.RawGetString("fields").(*lua.LTable)
+	if !ok {
+		return "", nil, nil, fmt.Errorf("metric has no fields")
+	}
+	old := m.Fields()
+	var err error
+	lt.ForEach(func(k, v lua.LValue) {
+		value, ok := toFieldValue(old[k.String()], v)
+		if !ok && err == nil {
+			err = fmt.Errorf("field %q has a %s value", k.String(), v.Type())
+		}
+		fields[k.String()] = value
+	})
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if len(fields) == 0 {
+		return "", nil, nil, fmt.Errorf("metric has no fields")
+	}
+
+	return string(name), tags, fields, nil
+}
+
+// decodeTime returns the time in nanoseconds set by the script, the time of
+// the metric being kept when unchanged as numbers lose precision in Lua.
+func decodeTime(m telegraf.Metric, v lua.LValue) (time.Time, error) {
+	switch v := v.(type) {
+	case *lua.LNilType:
+		return m.Time(), nil
+	case lua.LNumber:
+		if v == lua.LNumber(m.UnixNano()) {
+			return m.Time(), nil
+		}
+		return time.Unix(0, int64(v)), nil
+	}
+	return time.Time{}, fmt.Errorf("time is a %s", v.Type())
+}
+
+// toLuaValue converts a field value for the script, numbers becoming floats.
+func toLuaValue(v interface{}) (lua.LValue, bool) {
+	switch v := v.(type) {
+	case string:
+		return lua.LString(v), true
+	case bool:
+		return lua.LBool(v), true
+	case int64:
+		return lua.LNumber(v), true
+	case uint64:
+		return lua.LNumber(v), true
+	case float64:
+		return lua.LNumber(v), true
+	}
+	return lua.LNil, false
+}
+
+func toTagValue(v lua.LValue) (string, bool) {
+	switch v := v.(type) {
+	case lua.LString:
+		return string(v), true
+	case lua.LNumber:
+		return formatNumber(v), true
+	case lua.LBool:
+		return strconv.FormatBool(bool(v)), true
+	}
+	return "", false
+}
+
+// toFieldValue converts a field value set by the script. Numbers are floats,
+// unless the field was an integer or an unsigned integer and the number is
+// integral and in its range, the value of the field being kept when
+// unchanged as numbers lose precision in Lua.
+func toFieldValue(old interface{}, v lua.LValue) (interface{}, bool) {
+	switch v := v.(type) {
+	case lua.LString:
+		return string(v), true
+	case lua.LBool:
+		return bool(v), true
+	case lua.LNumber:
+		f := float64(v)
+		if old, ok := old.(int64); ok {
+			if f == float64(old) {
+				return old, true
+			}
+			if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+				return int64(f), true
+			}
+		}
+		if old, ok := old.(uint64); ok {
+			if f == float64(old) {
+				return old, true
+			}
+			if f == math.Trunc(f) && f >= 0 && f < math.MaxUint64 {
+				return uint64(f), true
+			}
+		}
+		return f, true
+	}
+	return nil, false
+}
+
+func formatNumber(v lua.LNumber) string {
+	f := float64(v)
+	if f == math.Trunc(f) && math.Abs(f) < 1e15 {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// luaPrint logs its arguments, in place of the print function of Lua which
+// writes to stdout.
+func (s *Script) luaPrint(L *lua.LState) int {
+	args := make([]string, 0, L.GetTop())
+	for i := 1; i <= L.GetTop(); i++ {
+		args = append(args, L.Get(i).String())
+	}
+	if s.Log != nil {
+		s.Log.Info(strings.Join(args, "\t"))
+	}
+	return 0
+}
+
+func init() {
+	processors.Add("script", func() telegraf.Processor {
+		return &Script{
+			Timeout: internal.Duration{Duration: defaultTimeout},
+		}
+	})
+}
@@ -0,0 +1,337 @@
+package script
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/gopher-lua"
+)
+
+func newMetric(t *testing.T) telegraf.Metric {
+	m, err := metric.New("http",
+		map[string]string{"host": "localhost", "path": "/api/v2/users?id=42"},
+		map[string]interface{}{
+			"status":   int64(200),
+			"duration": float64(0.25),
+			"method":   "GET",
+			"cached":   false,
+		},
+		time.Unix(0, 1519652321000000123),
+	)
+	require.NoError(t, err)
+	return m
+}
+
+func initScript(t *testing.T, s *Script) {
+	s.Log = testutil.Logger{}
+	require.NoError(t, s.Init())
+}
+
+func TestApplyInPlace(t *testing.T) {
+	s := &Script{Source: `
+function apply(metric)
+  metric.name = "requests"
+  metric.tags.version = string.match(metric.tags.path, "^/api/(v%d+)/")
+  metric.tags.path = string.gsub(metric.tags.path, "%?.*$", "")
+  metric.tags.host = nil
+  metric.fields.duration = metric.fields.duration * 1000
+  metric.fields.status = metric.fields.status + 1
+  metric.fields.cached = nil
+  metric.fields.ok = true
+  return metric
+end
+`}
+	initScript(t, s)
+
+	m := newMetric(t)
+	metrics := s.Apply(m)
+
+	require.Len(t, metrics, 1)
+	assert.True(t, m == metrics[0], "Should modify the metric in place")
+	assert.Equal(t, "requests", metrics[0].Name())
+	assert.Equal(t, map[string]string{
+		"path":    "/api/v2/users",
+		"version": "v2",
+	}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"status":   int64(201),
+		"duration": float64(250),
+		"method":   "GET",
+		"ok":       true,
+	}, metrics[0].Fields())
+	assert.Equal(t, int64(1519652321000000123), metrics[0].UnixNano())
+}
+
+func TestApplyMultiple(t *testing.T) {
+	s := &Script{Source: `
+function apply(metric)
+  local split = {}
+  for k, v in pairs(metric.fields) do
+    if type(v) == "number" then
+      table.insert(split, {
+        name = metric.name .. "_" .. k,
+        tags = metric.tags,
+        fields = {value = v},
+      })
+    end
+  end
+  return split
+end
+`}
+	initScript(t, s)
+
+	metrics := s.Apply(newMetric(t))
+
+	require.Len(t, metrics, 2)
+	values := make(map[string]interface{})
+	for _, m := range metrics {
+		assert.Equal(t, map[string]string{"host": "localhost", "path": "/api/v2/users?id=42"}, m.Tags())
+		assert.Equal(t, int64(1519652321000000123), m.UnixNano())
+		values[m.Name()] = m.Fields()["value"]
+	}
+	assert.Equal(t, map[string]interface{}{
+		"http_status":   float64(200),
+		"http_duration": float64(0.25),
+	}, values)
+}
+
+func TestApplyDrop(t *testing.T) {
+	s := &Script{Source: `
+function apply(metric)
+  if metric.fields.status == 200 then
+    return nil
+  end
+  return metric
+end
+`}
+	initScript(t, s)
+
+	assert.Len(t, s.Apply(newMetric(t)), 0)
+}
+
+func TestApplyState(t *testing.T) {
+	s := &Script{Source: `
+count = 0
+function apply(metric)
+  count = count + 1
+  metric.fields.count = count
+  return metric
+end
+`}
+	initScript(t, s)
+
+	s.Apply(newMetric(t))
+	metrics := s.Apply(newMetric(t))
+
+	require.Len(t, metrics, 1)
+	assert.Equal(t, float64(2), metrics[0].Fields()["count"])
+}
+
+func TestApplyTime(t *testing.T) {
+	s := &Script{Source: `
+function apply(metric)
+  metric.time = metric.time - metric.time % 1000000000
+  return metric
+end
+`}
+	initScript(t, s)
+
+	m, err := metric.New("cpu", nil,
+		map[string]interface{}{"value": float64(1)},
+		time.Unix(1500000000, 500000000),
+	)
+	require.NoError(t, err)
+	metrics := s.Apply(m)
+
+	require.Len(t, metrics, 1)
+	assert.Equal(t, time.Unix(1500000000, 0), metrics[0].Time())
+	assert.Equal(t, map[string]interface{}{"value": float64(1)}, metrics[0].Fields())
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{
+			name: "runtime error",
+			source: `
+function apply(metric)
+  error("failed")
+end
+`,
+		},
+		{
+			name: "no fields",
+			source: `
+function apply(metric)
+  metric.fields = {}
+  return metric
+end
+`,
+		},
+		{
+			name: "invalid field",
+			source: `
+function apply(metric)
+  metric.fields.value = {}
+  return metric
+end
+`,
+		},
+		{
+			name: "invalid return",
+			source: `
+function apply(metric)
+  return 42
+end
+`,
+		},
+		{
+			name: "timeout",
+			source: `
+function apply(metric)
+  while true do end
+end
+`,
+		},
+		{
+			name: "stack overflow",
+			source: `
+local function depth(n)
+  return 1 + depth(n + 1)
+end
+
+function apply(metric)
+  metric.fields.depth = depth(0)
+  return metric
+end
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Script{
+				Source:  tt.source,
+				Timeout: internal.Duration{Duration: 100 * time.Millisecond},
+			}
+			initScript(t, s)
+
+			expected := newMetric(t)
+			metrics := s.Apply(newMetric(t))
+
+			require.Len(t, metrics, 1)
+			assert.Equal(t, expected.Name(), metrics[0].Name())
+			assert.Equal(t, expected.Tags(), metrics[0].Tags())
+			assert.Equal(t, expected.Fields(), metrics[0].Fields())
+		})
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	for _, name := range []string{"dofile", "loadfile", "require", "io", "os"} {
+		s := &Script{Source: `
+function apply(metric)
+  metric.fields.available = ` + name + ` ~= nil
+  return metric
+end
+`}
+		initScript(t, s)
+
+		metrics := s.Apply(newMetric(t))
+
+		require.Len(t, metrics, 1)
+		assert.Equal(t, false, metrics[0].Fields()["available"], name+" should not be available")
+	}
+}
+
+func TestScriptFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "script")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(`
+function apply(metric)
+  metric.tags.script = "file"
+  return metric
+end
+`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	s := &Script{Script: f.Name()}
+	initScript(t, s)
+	metrics := s.Apply(newMetric(t))
+
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "file", metrics[0].Tags()["script"])
+}
+
+func TestInvalidScript(t *testing.T) {
+	for _, source := range []string{"", "function apply(", "x = 1", "while true do end"} {
+		s := &Script{
+			Source:  source,
+			Timeout: internal.Duration{Duration: 100 * time.Millisecond},
+			Log:     testutil.Logger{},
+		}
+
+		assert.Error(t, s.Init(), source)
+	}
+}
+
+func TestFieldValues(t *testing.T) {
+	for _, v := range []interface{}{"GET", true, int64(-42), uint64(42), float64(0.25)} {
+		lv, ok := toLuaValue(v)
+		require.True(t, ok)
+		value, ok := toFieldValue(v, lv)
+		require.True(t, ok)
+		assert.Equal(t, v, value)
+	}
+
+	value, _ := toFieldValue(uint64(41), lua.LNumber(42))
+	assert.Equal(t, uint64(42), value)
+	value, _ = toFieldValue(uint64(41), lua.LNumber(-1))
+	assert.Equal(t, float64(-1), value)
+}
+
+func TestApplyTracking(t *testing.T) {
+	s := &Script{Source: `
+function apply(metric)
+  return {metric, {name = "copy", fields = {value = 1}}}
+end
+`}
+	initScript(t, s)
+
+	var delivered []telegraf.DeliveryInfo
+	m, _ := metric.WithTracking(newMetric(t), func(info telegraf.DeliveryInfo) {
+		delivered = append(delivered, info)
+	})
+	metrics := s.Apply(m)
+
+	require.Len(t, metrics, 2)
+	metrics[0].Accept()
+	assert.Len(t, delivered, 0, "Should not deliver the metric before the new metrics")
+	metrics[1].Reject()
+	require.Len(t, delivered, 1)
+	assert.False(t, delivered[0].Delivered())
+}
+
+func TestApplyWithoutLogger(t *testing.T) {
+	s := &Script{Source: `
+function apply(metric)
+  print("applied")
+  error("failed")
+end
+`}
+	require.NoError(t, s.Init())
+
+	assert.Len(t, s.Apply(newMetric(t)), 1)
+}
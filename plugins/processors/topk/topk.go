@@ -0,0 +1,393 @@
+package topk
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## How often the top k groups are computed and their metrics passed on.
+  # period = "10s"
+
+  ## How many groups to pass on.
+  # k = 10
+
+  ## Tags used to group the metrics, which may contain globs. Metrics are
+  ## also grouped by measurement name.
+  # group_by = ["*"]
+
+  ## Fields to rank the groups by, a group is passed on when it is in the
+  ## top k of any of the fields.
+  # fields = ["value"]
+
+  ## Aggregation of the field values of a group over the period, one of
+  ## "sum", "mean", "max" or "min".
+  # aggregation = "mean"
+
+  ## Pass on the bottom k groups instead of the top k groups.
+  # bottomk = false
+
+  ## Name of the tag holding the rank of the group, starting at 1. No tag is
+  ## added when empty.
+  # rank_tag = ""
+
+  ## Value of the group_by tags of an aggregate of the groups that are not
+  ## passed on, emitted for each measurement. Not emitted when empty.
+  # other_group = ""
+`
+
+type TopK struct {
+	Period      internal.Duration
+	K           int
+	GroupBy     []string `toml:"group_by"`
+	Fields      []string
+	Aggregation string
+	Bottomk     bool
+	RankTag     string          `toml:"rank_tag"`
+	OtherGroup  string          `toml:"other_group"`
+	Log         telegraf.Logger `toml:"-"`
+
+	tagFilter filter.Filter
+	aggregate func([]float64) float64
+	lastPush  time.Time
+	// cache holds the metrics of the period by pipeline.
+	cache map[string][]telegraf.Metric
+}
+
+// group holds the values of the ranked fields of the metrics of a group.
+type group struct {
+	key    string
+	values map[string][]float64
+	rank   int
+}
+
+func NewTopK() *TopK {
+	return &TopK{
+		Period:      internal.Duration{Duration: 10 * time.Second},
+		K:           10,
+		GroupBy:     []string{"*"},
+		Fields:      []string{"value"},
+		Aggregation: "mean",
+	}
+}
+
+func (t *TopK) SampleConfig() string {
+	return sampleConfig
+}
+
+func (t *TopK) Description() string {
+	return "Pass on the metrics of the top k groups of metrics over a period"
+}
+
+func (t *TopK) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	return t.ApplyPipeline("", in...)
+}
+
+// ApplyPipeline holds on to the metrics until the end of the period, when
+// they are returned by Flush. The metrics of each pipeline are ranked apart.
+// Metrics without any of the fields are passed on unchanged.
+func (t *TopK) ApplyPipeline(pipeline string, in ...telegraf.Metric) []telegraf.Metric {
+	var out []telegraf.Metric
+	for _, m := range in {
+		if !t.isRanked(m) {
+			out = append(out, m)
+			continue
+		}
+		t.cache[pipeline] = append(t.cache[pipeline], m)
+	}
+	return out
+}
+
+// Flush returns the metrics of the top k groups of each pipeline once the
+// period is over, or of the current period when final is set.
+func (t *TopK) Flush(final bool) map[string][]telegraf.Metric {
+	if !final && time.Since(t.lastPush) < t.Period.Duration {
+		return nil
+	}
+	t.lastPush = time.Now()
+
+	out := make(map[string][]telegraf.Metric, len(t.cache))
+	for pipeline, metrics := range t.cache {
+		out[pipeline] = t.push(metrics)
+	}
+	t.cache = make(map[string][]telegraf.Metric)
+	return out
+}
+
+// Init checks the configuration, so that a mistake is reported when the
+// configuration is loaded.
+func (t *TopK) Init() error {
+	if t.K < 1 {
+		return fmt.Errorf("k must be at least 1, got %d", t.K)
+	}
+
+	switch t.Aggregation {
+	case "sum":
+		t.aggregate = sum
+	case "mean":
+		t.aggregate = mean
+	case "max":
+		t.aggregate = max
+	case "min":
+		t.aggregate = min
+	default:
+		return fmt.Errorf("unknown aggregation %q", t.Aggregation)
+	}
+
+	var err error
+	t.tagFilter, err = filter.Compile(t.GroupBy)
+	if err != nil {
+		return fmt.Errorf("could not compile group_by: %s", err)
+	}
+
+	t.lastPush = time.Now()
+	t.cache = make(map[string][]telegraf.Metric)
+	return nil
+}
+
+// push ranks the groups of the metrics, and returns the metrics of the top k
+// groups and the aggregates of the other groups.
+func (t *TopK) push(metrics []telegraf.Metric) []telegraf.Metric {
+	groups := make(map[string]*group)
+	byMetric := make([]*group, len(metrics))
+	for i, m := range metrics {
+		key := t.groupKey(m)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{key: key, values: make(map[string][]float64)}
+			groups[key] = g
+		}
+		fields := m.Fields()
+		for _, field := range t.Fields {
+			if v, ok := fields[field]; ok {
+				if f, ok := toFloat(v); ok {
+					g.values[field] = append(g.values[field], f)
+				}
+			}
+		}
+		byMetric[i] = g
+	}
+
+	t.rank(groups)
+
+	out := make([]telegraf.Metric, 0, len(metrics))
+	others := newOthers()
+	for i, m := range metrics {
+		g := byMetric[i]
+		if g.rank == 0 {
+			if t.OtherGroup != "" {
+				others.add(t, m)
+			}
+			m.Drop()
+			continue
+		}
+		if t.RankTag != "" {
+			m.AddTag(t.RankTag, strconv.Itoa(g.rank))
+		}
+		out = append(out, m)
+	}
+
+	return append(out, others.metrics(t)...)
+}
+
+// rank sets the rank of the groups in the top k of any of the fields, to the
+// best rank of the group.
+func (t *TopK) rank(groups map[string]*group) {
+	for _, field := range t.Fields {
+		type ranked struct {
+			g     *group
+			value float64
+		}
+		var values []ranked
+		for _, g := range groups {
+			if len(g.values[field]) > 0 {
+				values = append(values, ranked{g, t.aggregate(g.values[field])})
+			}
+		}
+
+		sort.Slice(values, func(i, j int) bool {
+			if values[i].value == values[j].value {
+				return values[i].g.key < values[j].g.key
+			}
+			if t.Bottomk {
+				return values[i].value < values[j].value
+			}
+			return values[i].value > values[j].value
+		})
+
+		for i := 0; i < len(values) && i < t.K; i++ {
+			g := values[i].g
+			if g.rank == 0 || i+1 < g.rank {
+				g.rank = i + 1
+			}
+		}
+	}
+}
+
+// groupKey returns the key of the group of the metric, made of the name and
+// the group_by tags of the metric.
+func (t *TopK) groupKey(m telegraf.Metric) string {
+	var tags []string
+	for k, v := range m.Tags() {
+		if t.isGroupTag(k) {
+			tags = append(tags, k+"="+v)
+		}
+	}
+	sort.Strings(tags)
+	return m.Name() + "," + strings.Join(tags, ",")
+}
+
+// isRanked returns true if the metric has a numeric value for any of the
+// fields.
+func (t *TopK) isRanked(m telegraf.Metric) bool {
+	fields := m.Fields()
+	for _, field := range t.Fields {
+		if _, ok := toFloat(fields[field]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *TopK) isGroupTag(key string) bool {
+	return t.tagFilter != nil && t.tagFilter.Match(key)
+}
+
+// others aggregates the metrics of the groups which are not passed on, by
+// measurement name.
+type others struct {
+	names   []string
+	buckets map[string]*other
+}
+
+type other struct {
+	tags   map[string]string
+	values map[string][]float64
+	time   time.Time
+	count  int
+}
+
+func newOthers() *others {
+	return &others{buckets: make(map[string]*other)}
+}
+
+func (o *others) add(t *TopK, m telegraf.Metric) {
+	b, ok := o.buckets[m.Name()]
+	if !ok {
+		b = &other{
+			tags:   make(map[string]string),
+			values: make(map[string][]float64),
+		}
+		o.buckets[m.Name()] = b
+		o.names = append(o.names, m.Name())
+	}
+
+	// the group_by tags are set to the other group, and the other tags are
+	// kept when they have the same value in all the metrics
+	tags := m.Tags()
+	for k, v := range b.tags {
+		if !t.isGroupTag(k) && tags[k] != v {
+			delete(b.tags, k)
+		}
+	}
+	for k, v := range tags {
+		if t.isGroupTag(k) {
+			b.tags[k] = t.OtherGroup
+		} else if b.count == 0 {
+			b.tags[k] = v
+		}
+	}
+	b.count++
+	fields := m.Fields()
+	for _, field := range t.Fields {
+		if v, ok := fields[field]; ok {
+			if f, ok := toFloat(v); ok {
+				b.values[field] = append(b.values[field], f)
+			}
+		}
+	}
+	if m.Time().After(b.time) {
+		b.time = m.Time()
+	}
+}
+
+func (o *others) metrics(t *TopK) []telegraf.Metric {
+	var out []telegraf.Metric
+	for _, name := range o.names {
+		b := o.buckets[name]
+		if len(b.values) == 0 {
+			continue
+		}
+
+		fields := make(map[string]interface{}, len(b.values))
+		for field, values := range b.values {
+			fields[field] = t.aggregate(values)
+		}
+
+		m, err := metric.New(name, b.tags, fields, b.time)
+		if err != nil {
+			if t.Log != nil {
+				t.Log.Errorf("could not create other group metric: %s", err)
+			}
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func sum(values []float64) float64 {
+	var s float64
+	for _, v := range values {
+		s += v
+	}
+	return s
+}
+
+func mean(values []float64) float64 {
+	return sum(values) / float64(len(values))
+}
+
+func max(values []float64) float64 {
+	m := math.Inf(-1)
+	for _, v := range values {
+		m = math.Max(m, v)
+	}
+	return m
+}
+
+func min(values []float64) float64 {
+	m := math.Inf(1)
+	for _, v := range values {
+		m = math.Min(m, v)
+	}
+	return m
+}
+
+func init() {
+	processors.Add("topk", func() telegraf.Processor {
+		return NewTopK()
+	})
+}
@@ -0,0 +1,250 @@
+package topk
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Unix(1500000000, 0)
+
+func newProcess(pid string, cpu float64, mem int64) telegraf.Metric {
+	m, _ := metric.New("procstat",
+		map[string]string{"host": "localhost", "pid": pid},
+		map[string]interface{}{
+			"cpu_usage":    cpu,
+			"memory_rss":   mem,
+			"process_name": "proc" + pid,
+		},
+		now,
+	)
+	return m
+}
+
+func processes() []telegraf.Metric {
+	return []telegraf.Metric{
+		newProcess("1", 10, 100),
+		newProcess("2", 50, 300),
+		newProcess("3", 30, 900),
+		newProcess("1", 20, 100),
+		newProcess("4", 5, 200),
+	}
+}
+
+// apply runs the metrics through the processor, then ends the period.
+func apply(t *testing.T, topk *TopK, in ...telegraf.Metric) []telegraf.Metric {
+	require.NoError(t, topk.Init())
+	require.Empty(t, topk.Apply(in...), "Should hold the metrics until the end of the period")
+	require.Empty(t, topk.Flush(false), "Should hold the metrics until the end of the period")
+	topk.lastPush = time.Now().Add(-topk.Period.Duration)
+	return topk.Flush(false)[""]
+}
+
+func pids(metrics []telegraf.Metric) []string {
+	var out []string
+	for _, m := range metrics {
+		out = append(out, m.Tags()["pid"])
+	}
+	return out
+}
+
+func TestTopK(t *testing.T) {
+	topk := NewTopK()
+	topk.K = 2
+	topk.GroupBy = []string{"pid"}
+	topk.Fields = []string{"cpu_usage"}
+
+	out := apply(t, topk, processes()...)
+
+	assert.Equal(t, []string{"2", "3"}, pids(out))
+}
+
+func TestTopKAggregations(t *testing.T) {
+	tests := []struct {
+		aggregation string
+		bottomk     bool
+		expected    []string
+	}{
+		{aggregation: "mean", expected: []string{"2", "3"}},
+		{aggregation: "sum", expected: []string{"1", "2", "1"}},
+		{aggregation: "max", expected: []string{"2", "3"}},
+		{aggregation: "min", bottomk: true, expected: []string{"1", "1", "4"}},
+		{aggregation: "mean", bottomk: true, expected: []string{"1", "1", "4"}},
+	}
+
+	for _, tt := range tests {
+		topk := NewTopK()
+		topk.K = 2
+		topk.GroupBy = []string{"pid"}
+		topk.Fields = []string{"cpu_usage"}
+		topk.Aggregation = tt.aggregation
+		topk.Bottomk = tt.bottomk
+
+		out := apply(t, topk, processes()...)
+
+		assert.Equal(t, tt.expected, pids(out), tt.aggregation)
+	}
+}
+
+func TestTopKSumIncludesAllMetricsOfGroup(t *testing.T) {
+	topk := NewTopK()
+	topk.K = 1
+	topk.GroupBy = []string{"pid"}
+	topk.Fields = []string{"cpu_usage"}
+	topk.Aggregation = "sum"
+
+	out := apply(t, topk,
+		newProcess("1", 30, 100),
+		newProcess("2", 40, 100),
+		newProcess("1", 30, 100),
+	)
+
+	assert.Equal(t, []string{"1", "1"}, pids(out))
+}
+
+func TestTopKMultipleFields(t *testing.T) {
+	topk := NewTopK()
+	topk.K = 1
+	topk.GroupBy = []string{"pid"}
+	topk.Fields = []string{"cpu_usage", "memory_rss"}
+	topk.RankTag = "rank"
+
+	out := apply(t, topk, processes()...)
+
+	require.Equal(t, []string{"2", "3"}, pids(out))
+	assert.Equal(t, "1", out[0].Tags()["rank"])
+	assert.Equal(t, "1", out[1].Tags()["rank"])
+}
+
+func TestTopKRankTag(t *testing.T) {
+	topk := NewTopK()
+	topk.K = 3
+	topk.GroupBy = []string{"pid"}
+	topk.Fields = []string{"cpu_usage"}
+	topk.RankTag = "rank"
+
+	out := apply(t, topk, processes()...)
+
+	ranks := make(map[string]string)
+	for _, m := range out {
+		ranks[m.Tags()["pid"]] = m.Tags()["rank"]
+	}
+	assert.Equal(t, map[string]string{"1": "3", "2": "1", "3": "2"}, ranks)
+}
+
+func TestTopKOtherGroup(t *testing.T) {
+	topk := NewTopK()
+	topk.K = 1
+	topk.GroupBy = []string{"pid"}
+	topk.Fields = []string{"cpu_usage", "memory_rss"}
+	topk.Aggregation = "sum"
+	topk.OtherGroup = "other"
+
+	out := apply(t, topk, processes()...)
+
+	require.Len(t, out, 3)
+	assert.Equal(t, []string{"2", "3"}, pids(out[:2]))
+	assert.Equal(t, "procstat", out[2].Name())
+	assert.Equal(t, map[string]string{"host": "localhost", "pid": "other"}, out[2].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"cpu_usage":  float64(35),
+		"memory_rss": float64(400),
+	}, out[2].Fields())
+	assert.Equal(t, now, out[2].Time())
+}
+
+func TestTopKGroupByName(t *testing.T) {
+	topk := NewTopK()
+	topk.K = 1
+	topk.GroupBy = nil
+
+	m1, _ := metric.New("a", map[string]string{"id": "1"}, map[string]interface{}{"value": float64(1)}, now)
+	m2, _ := metric.New("a", map[string]string{"id": "2"}, map[string]interface{}{"value": float64(5)}, now)
+	m3, _ := metric.New("b", map[string]string{"id": "3"}, map[string]interface{}{"value": float64(2)}, now)
+
+	out := apply(t, topk, m1, m2, m3)
+
+	var names []string
+	for _, m := range out {
+		names = append(names, m.Name())
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"a", "a"}, names)
+}
+
+func TestTopKMetricsWithoutFields(t *testing.T) {
+	topk := NewTopK()
+	topk.K = 1
+	topk.GroupBy = []string{"pid"}
+	topk.Fields = []string{"cpu_usage"}
+	require.NoError(t, topk.Init())
+
+	m, _ := metric.New("mem", nil, map[string]interface{}{"used": int64(42)}, now)
+	s, _ := metric.New("procstat", map[string]string{"pid": "5"},
+		map[string]interface{}{"cpu_usage": "n/a"}, now)
+	out := topk.Apply(append(processes(), m, s)...)
+
+	assert.Equal(t, []telegraf.Metric{m, s}, out, "Should pass on the metrics without fields unchanged")
+	assert.Len(t, topk.cache[""], 5)
+}
+
+func TestTopKPeriod(t *testing.T) {
+	topk := NewTopK()
+	topk.Period.Duration = time.Hour
+	topk.Fields = []string{"cpu_usage"}
+	require.NoError(t, topk.Init())
+
+	assert.Empty(t, topk.Apply(processes()...))
+	assert.Empty(t, topk.Apply(processes()...))
+	assert.Empty(t, topk.Flush(false))
+	assert.Len(t, topk.cache[""], 10)
+
+	topk.lastPush = time.Now().Add(-time.Hour)
+	assert.NotEmpty(t, topk.Flush(false)[""])
+	assert.Empty(t, topk.cache)
+	assert.Empty(t, topk.Flush(false))
+}
+
+func TestTopKPipelines(t *testing.T) {
+	topk := NewTopK()
+	topk.K = 1
+	topk.GroupBy = []string{"pid"}
+	topk.Fields = []string{"cpu_usage"}
+	require.NoError(t, topk.Init())
+
+	topk.ApplyPipeline("default", processes()...)
+	topk.ApplyPipeline("security", newProcess("7", 1, 100), newProcess("8", 2, 100))
+
+	out := topk.Flush(true)
+	require.Len(t, out, 2)
+	assert.Equal(t, []string{"2"}, pids(out["default"]))
+	assert.Equal(t, []string{"8"}, pids(out["security"]), "Should rank the metrics of each pipeline apart")
+}
+
+func TestTopKFinalFlush(t *testing.T) {
+	topk := NewTopK()
+	topk.K = 2
+	topk.GroupBy = []string{"pid"}
+	topk.Fields = []string{"cpu_usage"}
+	require.NoError(t, topk.Init())
+
+	topk.Apply(processes()...)
+
+	assert.Equal(t, []string{"2", "3"}, pids(topk.Flush(true)[""]), "Should pass on the partial period")
+	assert.Empty(t, topk.cache)
+}
+
+func TestTopKInvalidConfig(t *testing.T) {
+	topk := NewTopK()
+	topk.Aggregation = "median"
+	assert.Error(t, topk.Init())
+
+	topk = NewTopK()
+	topk.K = 0
+	assert.Error(t, topk.Init())
+}
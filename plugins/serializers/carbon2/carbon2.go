@@ -0,0 +1,96 @@
+package carbon2
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// spaces and equal signs would split a carbon2 tag
+var sanitizedChars = strings.NewReplacer(" ", "_", "=", "_")
+
+// Carbon2Serializer writes each numeric field of a metric as a line of the
+// carbon2 (metrics 2.0) format. The measurement and field names are the
+// "metric" and "field" intrinsic tags, followed by the metric tags, then two
+// spaces, the value and the timestamp in seconds:
+//
+//	metric=cpu field=usage_idle cpu=cpu0 host=localhost  91.5 1455320660
+//
+// Booleans are written as 1 or 0 and string fields are skipped. Tags named
+// "metric" or "field" are written as "metric_tag" and "field_tag", so that
+// they do not collide with the intrinsic tags.
+type Carbon2Serializer struct {
+}
+
+func (s *Carbon2Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+
+	tags := make(map[string]string)
+	for k, v := range metric.Tags() {
+		if v == "" {
+			continue
+		}
+		k = sanitizedChars.Replace(k)
+		if k == "metric" || k == "field" {
+			k += "_tag"
+		}
+		tags[k] = v
+	}
+	tagKeys := make([]string, 0, len(tags))
+	for k := range tags {
+		tagKeys = append(tagKeys, k)
+	}
+	sort.Strings(tagKeys)
+
+	fields := metric.Fields()
+	fieldKeys := make([]string, 0, len(fields))
+	for k := range fields {
+		fieldKeys = append(fieldKeys, k)
+	}
+	sort.Strings(fieldKeys)
+
+	timestamp := strconv.FormatInt(metric.UnixNano()/1000000000, 10)
+	for _, fieldName := range fieldKeys {
+		value, ok := formatValue(fields[fieldName])
+		if !ok {
+			continue
+		}
+
+		buf.WriteString("metric=")
+		buf.WriteString(sanitizedChars.Replace(metric.Name()))
+		buf.WriteString(" field=")
+		buf.WriteString(sanitizedChars.Replace(fieldName))
+		for _, k := range tagKeys {
+			buf.WriteString(" ")
+			buf.WriteString(k)
+			buf.WriteString("=")
+			buf.WriteString(sanitizedChars.Replace(tags[k]))
+		}
+		buf.WriteString("  ")
+		buf.WriteString(value)
+		buf.WriteString(" ")
+		buf.WriteString(timestamp)
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+func formatValue(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		if v {
+			return "1", true
+		}
+		return "0", true
+	}
+	return "", false
+}
@@ -0,0 +1,64 @@
+package carbon2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestSerializeMetric(t *testing.T) {
+	now := time.Unix(1455320660, 0)
+	m, err := metric.New("cpu",
+		map[string]string{"host": "localhost", "cpu": "cpu0"},
+		map[string]interface{}{
+			"usage_idle": float64(91.5),
+			"count":      int64(3),
+			"online":     true,
+			"state":      "running",
+		},
+		now)
+	require.NoError(t, err)
+
+	s := Carbon2Serializer{}
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"metric=cpu field=count cpu=cpu0 host=localhost  3 1455320660\n"+
+			"metric=cpu field=online cpu=cpu0 host=localhost  1 1455320660\n"+
+			"metric=cpu field=usage_idle cpu=cpu0 host=localhost  91.5 1455320660\n",
+		string(buf))
+}
+
+func TestSerializeSanitized(t *testing.T) {
+	m, err := metric.New("disk io",
+		map[string]string{"mount point": "/var/lib a=b"},
+		map[string]interface{}{"read bytes": int64(1024)},
+		time.Unix(0, 0))
+	require.NoError(t, err)
+
+	s := Carbon2Serializer{}
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"metric=disk_io field=read_bytes mount_point=/var/lib_a_b  1024 0\n",
+		string(buf))
+}
+
+func TestSerializeIntrinsicTagNames(t *testing.T) {
+	m, err := metric.New("cpu",
+		map[string]string{"metric": "usage", "field": "idle"},
+		map[string]interface{}{"value": int64(1)},
+		time.Unix(0, 0))
+	require.NoError(t, err)
+
+	s := Carbon2Serializer{}
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"metric=cpu field=value field_tag=idle metric_tag=usage  1 0\n",
+		string(buf))
+}
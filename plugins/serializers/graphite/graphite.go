@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/templating"
 )
 
 const DEFAULT_TEMPLATE = "host.tags.measurement.field"
@@ -29,6 +30,13 @@ var (
 type GraphiteSerializer struct {
 	Prefix   string
 	Template string
+	// TagSupport writes the tags using the Graphite 1.1 tag syntax,
+	// "name;tag=value", rather than in the bucket name. Templates are not
+	// used when it is set.
+	TagSupport bool
+	// Templates selects the template of each metric by its measurement name,
+	// the Template is used for the metrics matching none of them.
+	Templates *templating.Engine
 }
 
 func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
@@ -37,9 +45,12 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 	// Convert UnixNano to Unix timestamps
 	timestamp := metric.UnixNano() / 1000000000
 
-	bucket := SerializeBucketName(metric.Name(), metric.Tags(), s.Template, s.Prefix)
-	if bucket == "" {
-		return out, nil
+	var bucket string
+	if !s.TagSupport {
+		bucket = s.serializeBucketName(metric)
+		if bucket == "" {
+			return out, nil
+		}
 	}
 
 	for fieldName, value := range metric.Fields() {
@@ -53,17 +64,63 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 				value = 0
 			}
 		}
-		metricString := fmt.Sprintf("%s %#v %d\n",
+
+		var name string
+		if s.TagSupport {
+			name = SerializeBucketNameWithTags(metric.Name(), metric.Tags(),
+				s.Prefix, fieldName)
+		} else {
 			// insert "field" section of template
-			sanitize(InsertField(bucket, fieldName)),
-			value,
-			timestamp)
+			name = sanitize(InsertField(bucket, fieldName))
+		}
+		metricString := fmt.Sprintf("%s %#v %d\n", name, value, timestamp)
 		point := []byte(metricString)
 		out = append(out, point...)
 	}
 	return out, nil
 }
 
+func (s *GraphiteSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	out := []byte{}
+	for _, m := range metrics {
+		buf, err := s.Serialize(m)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, buf...)
+	}
+	return out, nil
+}
+
+// serializeBucketName returns the bucket of a metric using the template
+// matching its measurement name, or the Template if there is none.
+func (s *GraphiteSerializer) serializeBucketName(metric telegraf.Metric) string {
+	if s.Templates != nil {
+		if t := s.Templates.Match(metric.Name()); t != nil {
+			tags := metric.Tags()
+			for k, v := range t.DefaultTags() {
+				if _, ok := tags[k]; !ok {
+					tags[k] = v
+				}
+			}
+			return serializeBucketName(metric.Name(), tags, t.Parts(), s.Prefix)
+		}
+	}
+	return SerializeBucketName(metric.Name(), metric.Tags(), s.Template, s.Prefix)
+}
+
+// InitGraphiteTemplates parses templates in the format of the graphite input
+// templates, "[separator] [filter] template [tag1=value1,tag2=value2]", such
+// as "cpu host.measurement.cpu.field". The filter is matched against the
+// measurement name and the most specific match is used. The extra tags are
+// used for the tags of the template that a metric does not have.
+func InitGraphiteTemplates(templates []string) (*templating.Engine, error) {
+	if len(templates) == 0 {
+		return nil, nil
+	}
+	return templating.NewEngine(templating.DefaultSeparator, nil, templates)
+}
+
 // SerializeBucketName will take the given measurement name and tags and
 // produce a graphite bucket. It will use the GraphiteSerializer.Template
 // to generate this, or DEFAULT_TEMPLATE.
@@ -81,13 +138,21 @@ func SerializeBucketName(
 	if template == "" {
 		template = DEFAULT_TEMPLATE
 	}
+	return serializeBucketName(measurement, tags, strings.Split(template, "."), prefix)
+}
+
+func serializeBucketName(
+	measurement string,
+	tags map[string]string,
+	templateParts []string,
+	prefix string,
+) string {
 	tagsCopy := make(map[string]string)
 	for k, v := range tags {
 		tagsCopy[k] = v
 	}
 
 	var out []string
-	templateParts := strings.Split(template, ".")
 	for _, templatePart := range templateParts {
 		switch templatePart {
 		case "measurement":
@@ -136,6 +201,41 @@ func InsertField(bucket, fieldName string) string {
 	return strings.Replace(bucket, "FIELDNAME", fieldName, 1)
 }
 
+// SerializeBucketNameWithTags returns the Graphite 1.1 name of the field of a
+// metric, such as "cpu.usage_idle;cpu=cpu0;host=localhost". A "name" tag is
+// renamed to "_name", as Graphite reserves it for the metric name.
+func SerializeBucketNameWithTags(
+	measurement string,
+	tags map[string]string,
+	prefix string,
+	fieldName string,
+) string {
+	var tagPairs []string
+	for k, v := range tags {
+		if v == "" {
+			continue
+		}
+		if k == "name" {
+			k = "_name"
+		}
+		tagPairs = append(tagPairs, sanitize(k+"="+v))
+	}
+	sort.Strings(tagPairs)
+
+	out := measurement
+	if prefix != "" {
+		out = prefix + "." + out
+	}
+	if fieldName != "value" {
+		out += "." + fieldName
+	}
+	out = sanitize(out)
+	if len(tagPairs) > 0 {
+		out += ";" + strings.Join(tagPairs, ";")
+	}
+	return out
+}
+
 func buildTags(tags map[string]string) string {
 	var keys []string
 	for k := range tags {
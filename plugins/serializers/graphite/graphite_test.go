@@ -561,3 +561,91 @@ func TestClean(t *testing.T) {
 		})
 	}
 }
+
+func TestSerializeWithTagSupport(t *testing.T) {
+	now := time.Unix(1455320690, 0)
+	m, err := metric.New("cpu",
+		map[string]string{
+			"host": "tars",
+			"cpu":  "cpu-total",
+			"dc":   "us-east-1",
+			"name": "first core",
+		},
+		map[string]interface{}{
+			"usage_idle": float64(98.09),
+			"value":      int64(1),
+		},
+		now)
+	require.NoError(t, err)
+
+	s := GraphiteSerializer{Prefix: "telegraf", TagSupport: true}
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	mS := strings.Split(strings.TrimSpace(string(buf)), "\n")
+	sort.Strings(mS)
+
+	assert.Equal(t, []string{
+		"telegraf.cpu.usage_idle;_name=first_core;cpu=cpu-total;dc=us-east-1;host=tars 98.09 1455320690",
+		"telegraf.cpu;_name=first_core;cpu=cpu-total;dc=us-east-1;host=tars 1 1455320690",
+	}, mS)
+}
+
+func TestSerializeWithTemplates(t *testing.T) {
+	templates, err := InitGraphiteTemplates([]string{
+		"* host.measurement.field",
+		"cpu host.measurement.cpu.field",
+		"mem host.measurement.zone.field zone=a",
+	})
+	require.NoError(t, err)
+
+	s := GraphiteSerializer{Template: "tags.measurement.field", Templates: templates}
+	now := time.Unix(1455320690, 0)
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		// the most specific filter is used, not the first one
+		{"cpu", "localhost.cpu.cpu0.usage 1 1455320690\n"},
+		{"cpu_percent", "localhost.cpu_percent.usage 1 1455320690\n"},
+		{"mem", "localhost.mem.a.usage 1 1455320690\n"},
+	}
+	for _, tt := range tests {
+		m, err := metric.New(tt.name, defaultTags,
+			map[string]interface{}{"usage": int64(1)}, now)
+		require.NoError(t, err)
+		buf, err := s.Serialize(m)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, string(buf))
+	}
+}
+
+func TestSerializeWithTemplatesDefault(t *testing.T) {
+	templates, err := InitGraphiteTemplates([]string{
+		"cpu host.measurement.cpu.field",
+	})
+	require.NoError(t, err)
+
+	s := GraphiteSerializer{Template: "tags.measurement.field", Templates: templates}
+	m, err := metric.New("disk", defaultTags,
+		map[string]interface{}{"usage": int64(1)}, time.Unix(1455320690, 0))
+	require.NoError(t, err)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, "cpu0.us-west-2.localhost.disk.usage 1 1455320690\n", string(buf))
+
+	// a template without a filter replaces the Template
+	templates, err = InitGraphiteTemplates([]string{
+		"cpu host.measurement.cpu.field",
+		"host.measurement.field",
+	})
+	require.NoError(t, err)
+	s.Templates = templates
+	buf, err = s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, "localhost.disk.usage 1 1455320690\n", string(buf))
+}
+
+func TestInitGraphiteTemplatesInvalid(t *testing.T) {
+	_, err := InitGraphiteTemplates([]string{"cpu host.cpu.field"})
+	assert.Error(t, err)
+}
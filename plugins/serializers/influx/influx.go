@@ -10,3 +10,11 @@ type InfluxSerializer struct {
 func (s *InfluxSerializer) Serialize(m telegraf.Metric) ([]byte, error) {
 	return m.Serialize(), nil
 }
+
+func (s *InfluxSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var out []byte
+	for _, m := range metrics {
+		out = append(out, m.Serialize()...)
+	}
+	return out, nil
+}
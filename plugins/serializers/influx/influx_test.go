@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 )
 
@@ -70,3 +71,28 @@ func TestSerializeMetricString(t *testing.T) {
 	expS := []string{fmt.Sprintf("cpu,cpu=cpu0 usage_idle=\"foobar\" %d", now.UnixNano())}
 	assert.Equal(t, expS, mS)
 }
+
+func TestSerializeBatch(t *testing.T) {
+	now := time.Now()
+	m1, err := metric.New("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": int64(90)},
+		now)
+	assert.NoError(t, err)
+	m2, err := metric.New("cpu",
+		map[string]string{"cpu": "cpu1"},
+		map[string]interface{}{"usage_idle": int64(80)},
+		now)
+	assert.NoError(t, err)
+
+	s := InfluxSerializer{}
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2})
+	assert.NoError(t, err)
+	mS := strings.Split(strings.TrimSpace(string(buf)), "\n")
+
+	expS := []string{
+		fmt.Sprintf("cpu,cpu=cpu0 usage_idle=90i %d", now.UnixNano()),
+		fmt.Sprintf("cpu,cpu=cpu1 usage_idle=80i %d", now.UnixNano()),
+	}
+	assert.Equal(t, expS, mS)
+}
@@ -7,23 +7,43 @@ import (
 	"github.com/influxdata/telegraf"
 )
 
+// JsonSerializer writes metrics as JSON objects. By default the tags and
+// fields are nested in the "tags" and "fields" objects, along with the "name"
+// and "timestamp" of the metric.
 type JsonSerializer struct {
+	// TimestampUnits is the precision of the unix timestamps, in seconds when
+	// unset.
 	TimestampUnits time.Duration
+	// TimestampFormat is a Go time layout used to write the timestamps as
+	// strings in UTC instead of unix timestamps.
+	TimestampFormat string
+	// Flat writes the tags and fields as top level keys of the object. Fields
+	// take precedence over tags with the same key, and the "name" and
+	// "timestamp" keys over both.
+	Flat bool
 }
 
 func (s *JsonSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
-	m := make(map[string]interface{})
-	units_nanoseconds := s.TimestampUnits.Nanoseconds()
-	// if the units passed in were less than or equal to zero,
-	// then serialize the timestamp in seconds (the default)
-	if units_nanoseconds <= 0 {
-		units_nanoseconds = 1000000000
+	serialized, err := ejson.Marshal(s.createObject(metric))
+	if err != nil {
+		return []byte{}, err
 	}
-	m["tags"] = metric.Tags()
-	m["fields"] = metric.Fields()
-	m["name"] = metric.Name()
-	m["timestamp"] = metric.UnixNano() / units_nanoseconds
-	serialized, err := ejson.Marshal(m)
+	serialized = append(serialized, '\n')
+
+	return serialized, nil
+}
+
+// SerializeBatch serializes the metrics as a single JSON object, holding the
+// array of metrics under the "metrics" key.
+func (s *JsonSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	objects := make([]interface{}, 0, len(metrics))
+	for _, metric := range metrics {
+		objects = append(objects, s.createObject(metric))
+	}
+
+	serialized, err := ejson.Marshal(map[string]interface{}{
+		"metrics": objects,
+	})
 	if err != nil {
 		return []byte{}, err
 	}
@@ -31,3 +51,35 @@ func (s *JsonSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 
 	return serialized, nil
 }
+
+func (s *JsonSerializer) createObject(metric telegraf.Metric) map[string]interface{} {
+	m := make(map[string]interface{})
+	if s.Flat {
+		for k, v := range metric.Tags() {
+			m[k] = v
+		}
+		for k, v := range metric.Fields() {
+			m[k] = v
+		}
+	} else {
+		m["tags"] = metric.Tags()
+		m["fields"] = metric.Fields()
+	}
+	m["name"] = metric.Name()
+	m["timestamp"] = s.timestamp(metric)
+	return m
+}
+
+func (s *JsonSerializer) timestamp(metric telegraf.Metric) interface{} {
+	if s.TimestampFormat != "" {
+		return metric.Time().UTC().Format(s.TimestampFormat)
+	}
+
+	units_nanoseconds := s.TimestampUnits.Nanoseconds()
+	// if the units passed in were less than or equal to zero,
+	// then serialize the timestamp in seconds (the default)
+	if units_nanoseconds <= 0 {
+		units_nanoseconds = 1000000000
+	}
+	return metric.UnixNano() / units_nanoseconds
+}
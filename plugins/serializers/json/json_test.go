@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 )
 
@@ -108,3 +109,72 @@ func TestSerializeMetricWithEscapes(t *testing.T) {
 	expS := []byte(fmt.Sprintf(`{"fields":{"U,age=Idle":90},"name":"My CPU","tags":{"cpu tag":"cpu0"},"timestamp":%d}`, now.Unix()) + "\n")
 	assert.Equal(t, string(expS), string(buf))
 }
+
+func TestSerializeBatch(t *testing.T) {
+	now := time.Now()
+	m1, err := metric.New("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": int64(90)},
+		now)
+	assert.NoError(t, err)
+	m2, err := metric.New("mem",
+		map[string]string{},
+		map[string]interface{}{"used": int64(1024)},
+		now)
+	assert.NoError(t, err)
+
+	s := JsonSerializer{}
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2})
+	assert.NoError(t, err)
+
+	expS := []byte(fmt.Sprintf(`{"metrics":[{"fields":{"usage_idle":90},"name":"cpu","tags":{"cpu":"cpu0"},"timestamp":%d},{"fields":{"used":1024},"name":"mem","tags":{},"timestamp":%d}]}`, now.Unix(), now.Unix()) + "\n")
+	assert.Equal(t, string(expS), string(buf))
+}
+
+func TestSerializeTimestampUnits(t *testing.T) {
+	now := time.Unix(1455320660, 123456789)
+	m, err := metric.New("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": int64(90)},
+		now)
+	assert.NoError(t, err)
+
+	s := JsonSerializer{TimestampUnits: time.Millisecond}
+	buf, err := s.Serialize(m)
+	assert.NoError(t, err)
+
+	expS := `{"fields":{"usage_idle":90},"name":"cpu","tags":{"cpu":"cpu0"},"timestamp":1455320660123}` + "\n"
+	assert.Equal(t, expS, string(buf))
+}
+
+func TestSerializeTimestampFormat(t *testing.T) {
+	now := time.Unix(1455320660, 123456789)
+	m, err := metric.New("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": int64(90)},
+		now)
+	assert.NoError(t, err)
+
+	s := JsonSerializer{TimestampFormat: time.RFC3339Nano}
+	buf, err := s.Serialize(m)
+	assert.NoError(t, err)
+
+	expS := `{"fields":{"usage_idle":90},"name":"cpu","tags":{"cpu":"cpu0"},"timestamp":"2016-02-12T23:44:20.123456789Z"}` + "\n"
+	assert.Equal(t, expS, string(buf))
+}
+
+func TestSerializeFlat(t *testing.T) {
+	now := time.Now()
+	m, err := metric.New("cpu",
+		map[string]string{"cpu": "cpu0", "usage_idle": "tag", "name": "tag"},
+		map[string]interface{}{"usage_idle": int64(90)},
+		now)
+	assert.NoError(t, err)
+
+	s := JsonSerializer{Flat: true}
+	buf, err := s.Serialize(m)
+	assert.NoError(t, err)
+
+	expS := []byte(fmt.Sprintf(`{"cpu":"cpu0","name":"cpu","timestamp":%d,"usage_idle":90}`, now.Unix()) + "\n")
+	assert.Equal(t, string(expS), string(buf))
+}
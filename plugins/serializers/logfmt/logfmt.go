@@ -0,0 +1,72 @@
+package logfmt
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-logfmt/logfmt"
+
+	"github.com/influxdata/telegraf"
+)
+
+// LogfmtSerializer writes each metric as a logfmt line: the measurement name
+// as "name", followed by the tags and fields in key order and the timestamp
+// as "time", formatted as RFC3339 in UTC.
+type LogfmtSerializer struct {
+}
+
+func (s *LogfmtSerializer) Serialize(m telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := logfmt.NewEncoder(&buf)
+
+	if err := encoder.EncodeKeyval("name", m.Name()); err != nil {
+		return nil, err
+	}
+
+	tags := m.Tags()
+	tagKeys := make([]string, 0, len(tags))
+	for k := range tags {
+		tagKeys = append(tagKeys, k)
+	}
+	sort.Strings(tagKeys)
+	for _, k := range tagKeys {
+		if err := encoder.EncodeKeyval(sanitizeKey(k), tags[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := m.Fields()
+	fieldKeys := make([]string, 0, len(fields))
+	for k := range fields {
+		fieldKeys = append(fieldKeys, k)
+	}
+	sort.Strings(fieldKeys)
+	for _, k := range fieldKeys {
+		if err := encoder.EncodeKeyval(sanitizeKey(k), fields[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	err := encoder.EncodeKeyval("time", m.Time().UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, err
+	}
+	if err := encoder.EndRecord(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sanitizeKey replaces the characters logfmt does not allow in keys with
+// underscores.
+func sanitizeKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, key)
+}
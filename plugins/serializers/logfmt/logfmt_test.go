@@ -0,0 +1,48 @@
+package logfmt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestSerializeMetric(t *testing.T) {
+	now := time.Date(2018, 8, 1, 12, 30, 0, 500, time.UTC)
+	tags := map[string]string{
+		"host": "server01",
+		"cpu":  "cpu0",
+	}
+	fields := map[string]interface{}{
+		"usage_idle": float64(91.5),
+		"count":      int64(3),
+		"ok":         true,
+		"state":      "running fine",
+	}
+	m, err := metric.New("cpu", tags, fields, now)
+	require.NoError(t, err)
+
+	s := LogfmtSerializer{}
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, "name=cpu cpu=cpu0 host=server01 count=3 ok=true "+
+		"state=\"running fine\" usage_idle=91.5 "+
+		"time=2018-08-01T12:30:00.0000005Z\n", string(buf))
+}
+
+func TestSerializeInvalidKeys(t *testing.T) {
+	m, err := metric.New("disk",
+		map[string]string{"mount point": "/"},
+		map[string]interface{}{"a=b": int64(1)},
+		time.Unix(0, 0))
+	require.NoError(t, err)
+
+	s := LogfmtSerializer{}
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, "name=disk mount_point=/ a_b=1 time=1970-01-01T00:00:00Z\n",
+		string(buf))
+}
@@ -0,0 +1,204 @@
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+var invalidNameCharRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// PrometheusSerializer writes metrics in the Prometheus text exposition
+// format, with the metric type of the telegraf.ValueType of each metric.
+//
+// Summaries and histograms become a single Prometheus metric, their fields
+// are the quantiles or bucket upper bounds along with "sum" and "count", as
+// parsed by the prometheus data format. Each numeric field of other metrics
+// becomes a Prometheus metric named after the measurement and field, except
+// the "counter", "gauge" and "value" fields which use the measurement name.
+type PrometheusSerializer struct {
+	// ExportTimestamp adds the time of each metric to its samples. The
+	// timestamps must be left out for node_exporter's textfile collector.
+	ExportTimestamp bool
+}
+
+func (s *PrometheusSerializer) Serialize(m telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, family := range s.metricFamilies(m) {
+		if _, err := expfmt.MetricFamilyToText(&buf, family); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// SerializeBatch writes the samples of the metrics with the same Prometheus
+// name in a single metric family, as required by the text format. Samples
+// whose type differs from the first one of their family are skipped.
+func (s *PrometheusSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	families := make(map[string]*dto.MetricFamily)
+	for _, m := range metrics {
+		for _, family := range s.metricFamilies(m) {
+			existing, ok := families[family.GetName()]
+			if !ok {
+				families[family.GetName()] = family
+				continue
+			}
+			if existing.GetType() == family.GetType() {
+				existing.Metric = append(existing.Metric, family.Metric...)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		if _, err := expfmt.MetricFamilyToText(&buf, families[name]); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// metricFamilies converts m to Prometheus metric families sorted by name.
+func (s *PrometheusSerializer) metricFamilies(m telegraf.Metric) []*dto.MetricFamily {
+	labels := makeLabels(m.Tags())
+	var timestamp *int64
+	if s.ExportTimestamp {
+		timestamp = proto.Int64(m.UnixNano() / 1000000)
+	}
+
+	var families []*dto.MetricFamily
+	switch m.Type() {
+	case telegraf.Summary:
+		d, _ := metric.DecodeDistribution(m)
+		summary := &dto.Summary{
+			SampleSum:   proto.Float64(d.Sum),
+			SampleCount: proto.Uint64(d.Count),
+		}
+		for _, q := range d.Quantiles {
+			summary.Quantile = append(summary.Quantile, &dto.Quantile{
+				Quantile: proto.Float64(q.Quantile),
+				Value:    proto.Float64(q.Value),
+			})
+		}
+		families = append(families, newMetricFamily(sanitize(m.Name()),
+			dto.MetricType_SUMMARY, &dto.Metric{
+				Label:       labels,
+				Summary:     summary,
+				TimestampMs: timestamp,
+			}))
+	case telegraf.Histogram:
+		d, _ := metric.DecodeDistribution(m)
+		histogram := &dto.Histogram{
+			SampleSum:   proto.Float64(d.Sum),
+			SampleCount: proto.Uint64(d.Count),
+		}
+		for _, b := range d.Buckets {
+			histogram.Bucket = append(histogram.Bucket, &dto.Bucket{
+				UpperBound:      proto.Float64(b.UpperBound),
+				CumulativeCount: proto.Uint64(b.Count),
+			})
+		}
+		families = append(families, newMetricFamily(sanitize(m.Name()),
+			dto.MetricType_HISTOGRAM, &dto.Metric{
+				Label:       labels,
+				Histogram:   histogram,
+				TimestampMs: timestamp,
+			}))
+	default:
+		forEachValue(m.Fields(), func(name string, value float64) {
+			sample := &dto.Metric{Label: labels, TimestampMs: timestamp}
+			var metricType dto.MetricType
+			switch m.Type() {
+			case telegraf.Counter:
+				metricType = dto.MetricType_COUNTER
+				sample.Counter = &dto.Counter{Value: proto.Float64(value)}
+			case telegraf.Gauge:
+				metricType = dto.MetricType_GAUGE
+				sample.Gauge = &dto.Gauge{Value: proto.Float64(value)}
+			default:
+				metricType = dto.MetricType_UNTYPED
+				sample.Untyped = &dto.Untyped{Value: proto.Float64(value)}
+			}
+			families = append(families, newMetricFamily(
+				metricName(m, name), metricType, sample))
+		})
+	}
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].GetName() < families[j].GetName()
+	})
+	return families
+}
+
+func newMetricFamily(name string, metricType dto.MetricType, metric *dto.Metric) *dto.MetricFamily {
+	return &dto.MetricFamily{
+		Name:   proto.String(name),
+		Type:   metricType.Enum(),
+		Metric: []*dto.Metric{metric},
+	}
+}
+
+// metricName returns the name of the Prometheus metric of field, which is
+// the measurement name for the fields written by the prometheus input.
+func metricName(m telegraf.Metric, field string) string {
+	switch {
+	case field == "value",
+		field == "counter" && m.Type() == telegraf.Counter,
+		field == "gauge" && m.Type() == telegraf.Gauge:
+		return sanitize(m.Name())
+	}
+	return sanitize(fmt.Sprintf("%s_%s", m.Name(), field))
+}
+
+// forEachValue calls fn with the numeric fields converted to float64, other
+// fields are skipped.
+func forEachValue(fields map[string]interface{}, fn func(name string, value float64)) {
+	for name, v := range fields {
+		switch v := v.(type) {
+		case int64:
+			fn(name, float64(v))
+		case float64:
+			fn(name, v)
+		}
+	}
+}
+
+// makeLabels converts tags to labels sorted by name.
+func makeLabels(tags map[string]string) []*dto.LabelPair {
+	labels := make([]*dto.LabelPair, 0, len(tags))
+	for k, v := range tags {
+		labels = append(labels, &dto.LabelPair{
+			Name:  proto.String(sanitize(k)),
+			Value: proto.String(v),
+		})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].GetName() < labels[j].GetName()
+	})
+	return labels
+}
+
+// sanitize replaces the characters that are not allowed in Prometheus metric
+// and label names with underscores.
+func sanitize(name string) string {
+	name = invalidNameCharRE.ReplaceAllString(name, "_")
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
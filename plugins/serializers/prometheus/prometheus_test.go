@@ -0,0 +1,176 @@
+package prometheus
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// serialize serializes m and parses the output back into metric families.
+func serialize(t *testing.T, s *PrometheusSerializer, m telegraf.Metric) map[string]*dto.MetricFamily {
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(buf))
+	require.NoError(t, err)
+	return families
+}
+
+func labels(m *dto.Metric) map[string]string {
+	result := make(map[string]string)
+	for _, lp := range m.Label {
+		result[lp.GetName()] = lp.GetValue()
+	}
+	return result
+}
+
+func TestSerializeCounter(t *testing.T) {
+	m, err := metric.New("http_requests_total",
+		map[string]string{"code": "200", "host.name": "web01"},
+		map[string]interface{}{"counter": float64(1027)},
+		time.Unix(1500000000, 0), telegraf.Counter)
+	require.NoError(t, err)
+
+	families := serialize(t, &PrometheusSerializer{}, m)
+	require.Len(t, families, 1)
+	family := families["http_requests_total"]
+	require.NotNil(t, family)
+	assert.Equal(t, dto.MetricType_COUNTER, family.GetType())
+	require.Len(t, family.Metric, 1)
+	assert.Equal(t, map[string]string{"code": "200", "host_name": "web01"},
+		labels(family.Metric[0]))
+	assert.Equal(t, float64(1027), family.Metric[0].GetCounter().GetValue())
+	assert.Nil(t, family.Metric[0].TimestampMs)
+}
+
+func TestSerializeGauge(t *testing.T) {
+	m, err := metric.New("mem",
+		map[string]string{"host": "web01"},
+		map[string]interface{}{
+			"used":   int64(512),
+			"free":   float64(1.5),
+			"status": "ok",
+		},
+		time.Unix(1500000000, 0), telegraf.Gauge)
+	require.NoError(t, err)
+
+	buf, err := (&PrometheusSerializer{}).Serialize(m)
+	require.NoError(t, err)
+	// families are sorted by name
+	assert.True(t, bytes.Index(buf, []byte("mem_free")) <
+		bytes.Index(buf, []byte("mem_used")))
+
+	families := serialize(t, &PrometheusSerializer{}, m)
+	require.Len(t, families, 2)
+	assert.Equal(t, dto.MetricType_GAUGE, families["mem_free"].GetType())
+	assert.Equal(t, float64(1.5), families["mem_free"].Metric[0].GetGauge().GetValue())
+	assert.Equal(t, dto.MetricType_GAUGE, families["mem_used"].GetType())
+	assert.Equal(t, float64(512), families["mem_used"].Metric[0].GetGauge().GetValue())
+}
+
+func TestSerializeUntypedWithTimestamp(t *testing.T) {
+	m, err := metric.New("temperature", nil,
+		map[string]interface{}{"value": float64(21.5)},
+		time.Unix(1500000000, 0))
+	require.NoError(t, err)
+
+	families := serialize(t, &PrometheusSerializer{ExportTimestamp: true}, m)
+	family := families["temperature"]
+	require.NotNil(t, family)
+	assert.Equal(t, dto.MetricType_UNTYPED, family.GetType())
+	assert.Equal(t, float64(21.5), family.Metric[0].GetUntyped().GetValue())
+	assert.Equal(t, int64(1500000000000), family.Metric[0].GetTimestampMs())
+}
+
+func TestSerializeSummary(t *testing.T) {
+	m, err := metric.New("rpc_duration_seconds",
+		map[string]string{"service": "api"},
+		map[string]interface{}{
+			"0.99":  float64(0.2),
+			"0.5":   float64(0.05),
+			"sum":   float64(17.5),
+			"count": float64(250),
+		},
+		time.Unix(0, 0), telegraf.Summary)
+	require.NoError(t, err)
+
+	families := serialize(t, &PrometheusSerializer{}, m)
+	family := families["rpc_duration_seconds"]
+	require.NotNil(t, family)
+	assert.Equal(t, dto.MetricType_SUMMARY, family.GetType())
+	require.Len(t, family.Metric, 1)
+	assert.Equal(t, map[string]string{"service": "api"}, labels(family.Metric[0]))
+
+	summary := family.Metric[0].GetSummary()
+	assert.Equal(t, float64(17.5), summary.GetSampleSum())
+	assert.Equal(t, uint64(250), summary.GetSampleCount())
+	require.Len(t, summary.Quantile, 2)
+	assert.Equal(t, float64(0.5), summary.Quantile[0].GetQuantile())
+	assert.Equal(t, float64(0.05), summary.Quantile[0].GetValue())
+	assert.Equal(t, float64(0.99), summary.Quantile[1].GetQuantile())
+	assert.Equal(t, float64(0.2), summary.Quantile[1].GetValue())
+}
+
+func TestSerializeHistogram(t *testing.T) {
+	m, err := metric.New("request_latency", nil,
+		map[string]interface{}{
+			"1":     float64(18),
+			"0.1":   float64(10),
+			"+Inf":  float64(20),
+			"sum":   float64(7.25),
+			"count": float64(20),
+		},
+		time.Unix(0, 0), telegraf.Histogram)
+	require.NoError(t, err)
+
+	families := serialize(t, &PrometheusSerializer{}, m)
+	family := families["request_latency"]
+	require.NotNil(t, family)
+	assert.Equal(t, dto.MetricType_HISTOGRAM, family.GetType())
+
+	histogram := family.Metric[0].GetHistogram()
+	assert.Equal(t, float64(7.25), histogram.GetSampleSum())
+	assert.Equal(t, uint64(20), histogram.GetSampleCount())
+	var counts []uint64
+	for _, b := range histogram.Bucket {
+		counts = append(counts, b.GetCumulativeCount())
+	}
+	assert.Equal(t, []uint64{10, 18, 20}, counts)
+}
+
+func TestSerializeBatch(t *testing.T) {
+	m1, err := metric.New("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"value": float64(42)},
+		time.Unix(0, 0), telegraf.Gauge)
+	require.NoError(t, err)
+	m2, err := metric.New("cpu",
+		map[string]string{"cpu": "cpu1"},
+		map[string]interface{}{"value": float64(43)},
+		time.Unix(0, 0), telegraf.Gauge)
+	require.NoError(t, err)
+
+	s := &PrometheusSerializer{}
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2})
+	require.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(buf, []byte("# TYPE cpu gauge")))
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(buf))
+	require.NoError(t, err)
+	family := families["cpu"]
+	require.NotNil(t, family)
+	require.Len(t, family.Metric, 2)
+	assert.Equal(t, map[string]string{"cpu": "cpu0"}, labels(family.Metric[0]))
+	assert.Equal(t, float64(42), family.Metric[0].GetGauge().GetValue())
+	assert.Equal(t, map[string]string{"cpu": "cpu1"}, labels(family.Metric[1]))
+	assert.Equal(t, float64(43), family.Metric[1].GetGauge().GetValue())
+}
@@ -6,9 +6,13 @@ import (
 
 	"github.com/influxdata/telegraf"
 
+	"github.com/influxdata/telegraf/plugins/serializers/carbon2"
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers/json"
+	"github.com/influxdata/telegraf/plugins/serializers/logfmt"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	"github.com/influxdata/telegraf/plugins/serializers/wavefront"
 )
 
 // SerializerOutput is an interface for output plugins that are able to
@@ -27,21 +31,69 @@ type Serializer interface {
 	Serialize(metric telegraf.Metric) ([]byte, error)
 }
 
+// BatchSerializer is implemented by the serializers able to serialize a
+// batch of metrics as a whole, such as in a single JSON document, for the
+// outputs with use_batch_format set.
+type BatchSerializer interface {
+	// SerializeBatch takes a batch of telegraf metrics and turns them into
+	// a single byte buffer.
+	SerializeBatch(metrics []telegraf.Metric) ([]byte, error)
+}
+
+// SerializeBatch serializes metrics using s if it is a BatchSerializer,
+// or else concatenates the metrics serialized one at a time.
+func SerializeBatch(s Serializer, metrics []telegraf.Metric) ([]byte, error) {
+	if bs, ok := s.(BatchSerializer); ok {
+		return bs.SerializeBatch(metrics)
+	}
+
+	var out []byte
+	for _, m := range metrics {
+		buf, err := s.Serialize(m)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, buf...)
+	}
+	return out, nil
+}
+
 // Config is a struct that covers the data types needed for all serializer types,
 // and can be used to instantiate _any_ of the serializers.
 type Config struct {
-	// Dataformat can be one of: influx, graphite, or json
+	// Dataformat can be one of: influx, graphite, json, logfmt, prometheus,
+	// carbon2 or wavefront
 	DataFormat string
 
-	// Prefix to add to all measurements, only supports Graphite
+	// Prefix to add to all measurements, only supports Graphite and Wavefront
 	Prefix string
 
 	// Template for converting telegraf metrics into Graphite
 	// only supports Graphite
 	Template string
 
+	// Templates for converting telegraf metrics into Graphite, selected by
+	// measurement name, only supports Graphite
+	Templates []string
+
+	// Use the Graphite 1.1 tag syntax, only supports Graphite
+	GraphiteTagSupport bool
+
 	// Timestamp units to use for JSON formatted output
 	TimestampUnits time.Duration
+	// Go time layout of JSON timestamps, unix timestamps are used when empty
+	JSONTimestampFormat string
+	// Write the tags and fields of JSON formatted output as top level keys
+	// rather than in their own objects
+	JSONFlat bool
+
+	// Include the metric timestamp in Prometheus formatted output
+	PrometheusExportTimestamp bool
+
+	// Sanitize Wavefront metric and tag names using a stricter regex
+	WavefrontUseStrict bool
+	// Tags used as the Wavefront source, before falling back to the host tag
+	WavefrontSourceOverride []string
 }
 
 // NewSerializer a Serializer interface based on the given config.
@@ -52,26 +104,68 @@ func NewSerializer(config *Config) (Serializer, error) {
 	case "influx":
 		serializer, err = NewInfluxSerializer()
 	case "graphite":
-		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template)
+		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template,
+			config.GraphiteTagSupport, config.Templates)
 	case "json":
-		serializer, err = NewJsonSerializer(config.TimestampUnits)
+		serializer, err = NewJsonSerializer(config.TimestampUnits,
+			config.JSONTimestampFormat, config.JSONFlat)
+	case "logfmt":
+		serializer, err = NewLogfmtSerializer()
+	case "prometheus":
+		serializer, err = NewPrometheusSerializer(config.PrometheusExportTimestamp)
+	case "carbon2":
+		serializer, err = NewCarbon2Serializer()
+	case "wavefront":
+		serializer, err = NewWavefrontSerializer(config.Prefix,
+			config.WavefrontUseStrict, config.WavefrontSourceOverride)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
 	return serializer, err
 }
 
-func NewJsonSerializer(timestampUnits time.Duration) (Serializer, error) {
-	return &json.JsonSerializer{TimestampUnits: timestampUnits}, nil
+func NewJsonSerializer(timestampUnits time.Duration, timestampFormat string, flat bool) (Serializer, error) {
+	return &json.JsonSerializer{
+		TimestampUnits:  timestampUnits,
+		TimestampFormat: timestampFormat,
+		Flat:            flat,
+	}, nil
+}
+
+func NewLogfmtSerializer() (Serializer, error) {
+	return &logfmt.LogfmtSerializer{}, nil
+}
+
+func NewPrometheusSerializer(exportTimestamp bool) (Serializer, error) {
+	return &prometheus.PrometheusSerializer{ExportTimestamp: exportTimestamp}, nil
+}
+
+func NewCarbon2Serializer() (Serializer, error) {
+	return &carbon2.Carbon2Serializer{}, nil
+}
+
+func NewWavefrontSerializer(prefix string, useStrict bool, sourceOverride []string) (Serializer, error) {
+	return &wavefront.WavefrontSerializer{
+		Prefix:         prefix,
+		UseStrict:      useStrict,
+		SourceOverride: sourceOverride,
+	}, nil
 }
 
 func NewInfluxSerializer() (Serializer, error) {
 	return &influx.InfluxSerializer{}, nil
 }
 
-func NewGraphiteSerializer(prefix, template string) (Serializer, error) {
+func NewGraphiteSerializer(prefix, template string, tagSupport bool, templates []string) (Serializer, error) {
+	graphiteTemplates, err := graphite.InitGraphiteTemplates(templates)
+	if err != nil {
+		return nil, err
+	}
+
 	return &graphite.GraphiteSerializer{
-		Prefix:   prefix,
-		Template: template,
+		Prefix:     prefix,
+		Template:   template,
+		TagSupport: tagSupport,
+		Templates:  graphiteTemplates,
 	}, nil
 }
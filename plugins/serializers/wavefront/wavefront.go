@@ -0,0 +1,158 @@
+package wavefront
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// catch many of the invalid chars that could appear in a metric or tag name
+var sanitizedChars = strings.NewReplacer(
+	"!", "-", "@", "-", "#", "-", "$", "-", "%", "-", "^", "-", "&", "-",
+	"*", "-", "(", "-", ")", "-", "+", "-", "`", "-", "'", "-", "\"", "-",
+	"[", "-", "]", "-", "{", "-", "}", "-", ":", "-", ";", "-", "<", "-",
+	">", "-", ",", "-", "?", "-", "/", "-", "\\", "-", "|", "-", " ", "-",
+	"=", "-",
+)
+
+// strictSanitizedRegex replaces every character Wavefront does not allow,
+// at the cost of being significantly slower than sanitizedChars.
+var strictSanitizedRegex = regexp.MustCompile(`[^a-zA-Z\d_.-]`)
+
+var tagValueReplacer = strings.NewReplacer("\"", "\\\"", "*", "-")
+
+var pathReplacer = strings.NewReplacer("_", ".")
+
+// WavefrontSerializer writes each numeric field of a metric as a line of the
+// Wavefront data format:
+//
+//	<prefix><measurement>.<field> <value> <timestamp> source="<source>" <tags>
+//
+// The "value" field is named after the measurement alone and underscores in
+// names are converted to dots, as done by the wavefront output. Booleans are
+// written as 1 or 0 and string fields are skipped.
+type WavefrontSerializer struct {
+	Prefix string
+	// UseStrict sanitizes names with a regex, which is more thorough but
+	// slower.
+	UseStrict bool
+	// SourceOverride are the tags used as the source, in order of
+	// preference, before falling back to the host tag. The host tag is kept
+	// as telegraf_host when another tag is used.
+	SourceOverride []string
+}
+
+func (s *WavefrontSerializer) Serialize(m telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+
+	source, tags := BuildTags(m.Tags(), s.SourceOverride)
+
+	fields := m.Fields()
+	fieldKeys := make([]string, 0, len(fields))
+	for k := range fields {
+		fieldKeys = append(fieldKeys, k)
+	}
+	sort.Strings(fieldKeys)
+
+	timestamp := strconv.FormatInt(m.UnixNano()/1000000000, 10)
+	for _, fieldName := range fieldKeys {
+		value, ok := buildValue(fields[fieldName])
+		if !ok {
+			continue
+		}
+
+		name := s.Prefix + m.Name()
+		if fieldName != "value" {
+			name += "." + fieldName
+		}
+		buf.WriteString(pathReplacer.Replace(Sanitize(name, s.UseStrict)))
+		buf.WriteString(" ")
+		buf.WriteString(strconv.FormatFloat(value, 'f', 6, 64))
+		buf.WriteString(" ")
+		buf.WriteString(timestamp)
+		WriteTags(&buf, source, tags, s.UseStrict)
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// BuildTags returns the source of a point and its remaining non-empty tags.
+// The source is the first of the sourceOverride tags that is set, or else
+// the host tag. The host tag is kept as telegraf_host when another tag is
+// used.
+func BuildTags(tags map[string]string, sourceOverride []string) (string, map[string]string) {
+	source, sourceTagFound := "", false
+	for _, k := range sourceOverride {
+		if v, ok := tags[k]; ok && v != "" {
+			source, sourceTagFound = v, true
+			delete(tags, k)
+			if host, ok := tags["host"]; ok {
+				tags["telegraf_host"] = host
+			}
+			break
+		}
+	}
+	if !sourceTagFound {
+		source = tags["host"]
+	}
+	delete(tags, "host")
+
+	for k, v := range tags {
+		if v == "" {
+			delete(tags, k)
+		}
+	}
+	return source, tags
+}
+
+// WriteTags writes the source and the tags of a point, sorted by key, each
+// preceded by a space.
+func WriteTags(buf *bytes.Buffer, source string, tags map[string]string, strict bool) {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf.WriteString(" source=\"")
+	buf.WriteString(tagValueReplacer.Replace(source))
+	buf.WriteString("\"")
+	for _, k := range keys {
+		buf.WriteString(" ")
+		buf.WriteString(Sanitize(k, strict))
+		buf.WriteString("=\"")
+		buf.WriteString(tagValueReplacer.Replace(tags[k]))
+		buf.WriteString("\"")
+	}
+}
+
+// Sanitize replaces the characters Wavefront does not allow in metric and
+// tag names. When strict is set a regex is used, which is more thorough but
+// slower.
+func Sanitize(name string, strict bool) string {
+	if strict {
+		return strictSanitizedRegex.ReplaceAllLiteralString(name, "-")
+	}
+	return sanitizedChars.Replace(name)
+}
+
+func buildValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,76 @@
+package wavefront
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestSerializeMetric(t *testing.T) {
+	m, err := metric.New("cpu",
+		map[string]string{"host": "server01", "cpu": "cpu0"},
+		map[string]interface{}{
+			"usage_idle": float64(91.5),
+			"online":     true,
+			"state":      "running",
+		},
+		time.Unix(1455320660, 0))
+	require.NoError(t, err)
+
+	s := WavefrontSerializer{Prefix: "telegraf."}
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"telegraf.cpu.online 1.000000 1455320660 source=\"server01\" cpu=\"cpu0\"\n"+
+			"telegraf.cpu.usage.idle 91.500000 1455320660 source=\"server01\" cpu=\"cpu0\"\n",
+		string(buf))
+}
+
+func TestSerializeSourceOverride(t *testing.T) {
+	m, err := metric.New("disk space",
+		map[string]string{
+			"host":       "server01",
+			"agent_host": "10.0.0.1",
+			"path":       "/var \"lib\"",
+			"empty":      "",
+		},
+		map[string]interface{}{"value": int64(1024)},
+		time.Unix(0, 0))
+	require.NoError(t, err)
+
+	s := WavefrontSerializer{
+		SourceOverride: []string{"hostname", "agent_host"},
+		UseStrict:      true,
+	}
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"disk-space 1024.000000 0 source=\"10.0.0.1\" path=\"/var \\\"lib\\\"\" "+
+			"telegraf_host=\"server01\"\n",
+		string(buf))
+}
+
+// Benchmarks to test performance of string replacement via Regex and Replacer
+var testString = "this_is*my!test/string\\for=replacement"
+
+func BenchmarkReplaceAllString(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		strictSanitizedRegex.ReplaceAllString(testString, "-")
+	}
+}
+
+func BenchmarkReplaceAllLiteralString(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		strictSanitizedRegex.ReplaceAllLiteralString(testString, "-")
+	}
+}
+
+func BenchmarkReplacer(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		sanitizedChars.Replace(testString)
+	}
+}
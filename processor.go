@@ -10,3 +10,26 @@ type Processor interface {
 	// Apply the filter to the given metric
 	Apply(in ...Metric) []Metric
 }
+
+// FlushingProcessor is a Processor which holds on to metrics across calls to
+// Apply and passes them on later, such as once per period. The metrics of
+// each pipeline are held apart and passed on to the same pipeline.
+type FlushingProcessor interface {
+	Processor
+	// ApplyPipeline is called in place of Apply with the metrics of the
+	// given pipeline.
+	ApplyPipeline(pipeline string, in ...Metric) []Metric
+	// Flush returns the held metrics which are due to be passed on, by
+	// pipeline. It is called periodically, and with final set once the
+	// processor is removed or Telegraf stops, when all of them are due.
+	Flush(final bool) map[string][]Metric
+}
+
+// Initializer is a plugin that checks its configuration and prepares to run
+// once it is configured, such as a Processor compiling its patterns, so that
+// a mistake fails loading the configuration.
+type Initializer interface {
+	// Init is called once, after the plugin is configured and before it is
+	// used.
+	Init() error
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -31,11 +32,12 @@ type Accumulator struct {
 	sync.Mutex
 	*sync.Cond
 
-	Metrics  []*Metric
-	nMetrics uint64
-	Discard  bool
-	Errors   []error
-	debug    bool
+	Metrics   []*Metric
+	nMetrics  uint64
+	Discard   bool
+	Errors    []error
+	debug     bool
+	delivered chan telegraf.DeliveryInfo
 }
 
 func (a *Accumulator) NMetrics() uint64 {
@@ -153,6 +155,47 @@ func (a *Accumulator) AddError(err error) {
 	a.Unlock()
 }
 
+// WithTracking returns the Accumulator itself; tracked metrics are recorded
+// like any other metric and reported as delivered immediately.
+func (a *Accumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
+	a.Lock()
+	defer a.Unlock()
+	if a.delivered == nil {
+		a.delivered = make(chan telegraf.DeliveryInfo, maxTracked)
+	}
+	return a
+}
+
+func (a *Accumulator) AddTrackingMetric(m telegraf.Metric) telegraf.TrackingID {
+	return a.AddTrackingMetricGroup([]telegraf.Metric{m})
+}
+
+func (a *Accumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
+	a.WithTracking(len(group))
+	tracked, id := metric.WithGroupTracking(group, a.onDelivery)
+	for _, m := range tracked {
+		a.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+		m.Accept()
+	}
+	return id
+}
+
+// Delivered returns the channel of delivery notifications for tracked metrics.
+func (a *Accumulator) Delivered() <-chan telegraf.DeliveryInfo {
+	a.WithTracking(0)
+	return a.delivered
+}
+
+func (a *Accumulator) onDelivery(info telegraf.DeliveryInfo) {
+	select {
+	case a.delivered <- info:
+	default:
+		go func() {
+			a.delivered <- info
+		}()
+	}
+}
+
 func (a *Accumulator) SetPrecision(precision, interval time.Duration) {
 	return
 }
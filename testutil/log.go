@@ -0,0 +1,51 @@
+package testutil
+
+import (
+	"log"
+)
+
+// Logger is a telegraf.Logger for plugin tests, it writes to the standard
+// logger.
+type Logger struct {
+	Name string
+}
+
+// Errorf logs an error message, formatted as with fmt.Printf.
+func (l Logger) Errorf(format string, args ...interface{}) {
+	log.Printf("E! ["+l.Name+"] "+format, args...)
+}
+
+// Error logs an error message, formatted as with fmt.Print.
+func (l Logger) Error(args ...interface{}) {
+	log.Print(append([]interface{}{"E! [" + l.Name + "] "}, args...)...)
+}
+
+// Warnf logs a warning message, formatted as with fmt.Printf.
+func (l Logger) Warnf(format string, args ...interface{}) {
+	log.Printf("W! ["+l.Name+"] "+format, args...)
+}
+
+// Warn logs a warning message, formatted as with fmt.Print.
+func (l Logger) Warn(args ...interface{}) {
+	log.Print(append([]interface{}{"W! [" + l.Name + "] "}, args...)...)
+}
+
+// Infof logs an information message, formatted as with fmt.Printf.
+func (l Logger) Infof(format string, args ...interface{}) {
+	log.Printf("I! ["+l.Name+"] "+format, args...)
+}
+
+// Info logs an information message, formatted as with fmt.Print.
+func (l Logger) Info(args ...interface{}) {
+	log.Print(append([]interface{}{"I! [" + l.Name + "] "}, args...)...)
+}
+
+// Debugf logs a debug message, formatted as with fmt.Printf.
+func (l Logger) Debugf(format string, args ...interface{}) {
+	log.Printf("D! ["+l.Name+"] "+format, args...)
+}
+
+// Debug logs a debug message, formatted as with fmt.Print.
+func (l Logger) Debug(args ...interface{}) {
+	log.Print(append([]interface{}{"D! [" + l.Name + "] "}, args...)...)
+}